@@ -0,0 +1,160 @@
+package calendar
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DateLayout = "2006-01-02"
+	TimeLayout = "15:04"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type Configs = []Config
+
+// Config defines the rules of a calendar, the times are in its timezone (the timezone of the task otherwise).
+type Config struct {
+	Id       string        `mapstructure:"id" validate:"required,excludesall=!@#$ "`
+	Timezone string        `mapstructure:"timezone" validate:"omitempty,timezone"`
+	Dates    []string      `mapstructure:"dates" validate:"omitempty,dive,datetime=2006-01-02"`
+	Ranges   []RangeConfig `mapstructure:"ranges" validate:"omitempty,dive"`
+	Ics      string        `mapstructure:"ics" validate:"omitempty,filepath"`
+}
+
+// RangeConfig matches the times between From and To on Weekdays (every day when empty).
+// The range crosses midnight when To is before From, the whole day is matched without From and To.
+type RangeConfig struct {
+	Weekdays []string `mapstructure:"weekdays" validate:"omitempty,dive,oneof=mon tue wed thu fri sat sun"`
+	From     string   `mapstructure:"from" validate:"omitempty,datetime=15:04"`
+	To       string   `mapstructure:"to" validate:"omitempty,datetime=15:04"`
+}
+
+func (r RangeConfig) String() string {
+	days := "every day"
+	if len(r.Weekdays) > 0 {
+		days = strings.Join(r.Weekdays, ",")
+	}
+	from, to := r.From, r.To
+	if from == "" && to == "" {
+		return days + " all day"
+	}
+	if from == "" {
+		from = "00:00"
+	}
+	if to == "" {
+		to = "24:00"
+	}
+	return fmt.Sprintf("%s %s-%s", days, from, to)
+}
+
+// match compares minutes of the day, so the range keeps its meaning on DST changes.
+func (r RangeConfig) match(t time.Time) bool {
+	if len(r.Weekdays) > 0 && !slices.ContainsFunc(r.Weekdays, func(day string) bool { return weekdays[day] == t.Weekday() }) {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	from, to := minuteOfDay(r.From, 0), minuteOfDay(r.To, 24*60)
+	if to < from {
+		return minute >= from || minute < to
+	}
+	return minute >= from && minute < to
+}
+
+func minuteOfDay(value string, fallback int) int {
+	t, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		return fallback
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+type Calendars map[string]*Calendar
+
+// Calendar excludes the times which match one of its rules.
+type Calendar struct {
+	Id       string
+	location *time.Location
+	dates    []string
+	ranges   []RangeConfig
+	events   []Event
+}
+
+// Load reads the calendars and their iCalendar files, the events with an RRULE not supported are skipped with a warning.
+func Load(fs afero.Fs, configs Configs, logger *slog.Logger) (Calendars, error) {
+	calendars := Calendars{}
+	for _, cfg := range configs {
+		calendar := &Calendar{Id: cfg.Id, dates: cfg.Dates, ranges: cfg.Ranges}
+		if cfg.Timezone != "" {
+			location, err := time.LoadLocation(cfg.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load timezone of calendar %s: %v", cfg.Id, err)
+			}
+			calendar.location = location
+		}
+		if cfg.Ics != "" {
+			content, err := afero.ReadFile(fs, cfg.Ics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ics of calendar %s: %v", cfg.Id, err)
+			}
+			var skipped []error
+			calendar.events, skipped, err = ParseIcs(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse ics of calendar %s: %v", cfg.Id, err)
+			}
+			for _, skip := range skipped {
+				logger.Warn(fmt.Sprintf("event of calendar %s skipped: %v", cfg.Id, skip))
+			}
+		}
+		calendars[cfg.Id] = calendar
+	}
+	return calendars, nil
+}
+
+// Match returns the rule which matches t, in the location of the calendar or of t.
+func (c *Calendar) Match(t time.Time) (string, bool) {
+	if c.location != nil {
+		t = t.In(c.location)
+	}
+	if date := t.Format(DateLayout); slices.Contains(c.dates, date) {
+		return fmt.Sprintf("date %s", date), true
+	}
+	for _, r := range c.ranges {
+		if r.match(t) {
+			return r.String(), true
+		}
+	}
+	for _, event := range c.events {
+		if event.Match(t) {
+			return fmt.Sprintf("event '%s'", event.Summary), true
+		}
+	}
+	return "", false
+}
+
+// Excluded returns the reason of the exclusion of t by the first matching calendar of ids.
+func (c Calendars) Excluded(ids []string, t time.Time) (string, bool) {
+	for _, id := range ids {
+		calendar, ok := c[id]
+		if !ok {
+			continue
+		}
+		if rule, match := calendar.Match(t); match {
+			return fmt.Sprintf("excluded by calendar %s (%s)", id, rule), true
+		}
+	}
+	return "", false
+}
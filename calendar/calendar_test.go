@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"bytes"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const testIcs = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Christmas\r\n" +
+	"DTSTART;VALUE=DATE:20231225\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Thanksgiving\r\n" +
+	"DTSTART;VALUE=DATE:20201126\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Team meeting\r\n" +
+	"DTSTART:20231225T200000\r\n" +
+	"DTEND:20231225T210000\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Monthly review\r\n" +
+	"DTSTART:20231225T100000\r\n" +
+	"RRULE:FREQ=MONTHLY;BYDAY=1MO\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestLoad(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/holidays.ics", []byte(testIcs), 0644)
+	_ = afero.WriteFile(fs, "/wrong.ics", []byte("BEGIN:VEVENT\nSUMMARY:Wrong\nEND:VEVENT\n"), 0644)
+	tests := []struct {
+		name     string
+		configs  Configs
+		want     int
+		wantLogs string
+		wantErr  string
+	}{
+		{name: "SuccessEmpty", configs: Configs{}, want: 0},
+		{
+			name: "Success",
+			configs: Configs{
+				{Id: "holidays", Ics: "/holidays.ics", Timezone: "Europe/Paris"},
+				{Id: "business", Ranges: []RangeConfig{{Weekdays: []string{"mon"}, From: "09:00", To: "18:00"}}},
+			},
+			want:     2,
+			wantLogs: "event of calendar holidays skipped: invalid RRULE of event 'Monthly review': unsupported frequency MONTHLY",
+		},
+		{
+			name:    "ErrorWrongTimezone",
+			configs: Configs{{Id: "holidays", Timezone: "Europe/Wrong"}},
+			wantErr: "failed to load timezone of calendar holidays: unknown time zone Europe/Wrong",
+		},
+		{
+			name:    "ErrorMissingIcs",
+			configs: Configs{{Id: "holidays", Ics: "/missing.ics"}},
+			wantErr: "failed to read ics of calendar holidays: open /missing.ics: file does not exist",
+		},
+		{
+			name:    "ErrorWrongIcs",
+			configs: Configs{{Id: "holidays", Ics: "/wrong.ics"}},
+			wantErr: "failed to parse ics of calendar holidays: event 'Wrong' has no DTSTART",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			got, err := Load(fs, tt.configs, slog.New(slog.NewTextHandler(logs, nil)))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, tt.want)
+			assert.Contains(t, logs.String(), tt.wantLogs)
+		})
+	}
+}
+
+func TestCalendar_Match(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	events, _, _ := ParseIcs(testIcs)
+	calendar := &Calendar{
+		Id:       "test",
+		location: paris,
+		dates:    []string{"2024-01-01"},
+		ranges: []RangeConfig{
+			{Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}, From: "09:00", To: "18:00"},
+			{Weekdays: []string{"sat"}},
+			{From: "23:30", To: "00:30"},
+		},
+		events: events,
+	}
+	tests := []struct {
+		name      string
+		t         time.Time
+		wantRule  string
+		wantMatch bool
+	}{
+		{name: "SuccessDate", t: time.Date(2024, time.January, 1, 3, 0, 0, 0, paris), wantRule: "date 2024-01-01", wantMatch: true},
+		{name: "SuccessDateInCalendarTimezone", t: time.Date(2023, time.December, 31, 23, 0, 0, 0, time.UTC), wantRule: "date 2024-01-01", wantMatch: true},
+		{name: "SuccessBusinessHours", t: time.Date(2023, time.January, 25, 9, 0, 0, 0, paris), wantRule: "mon,tue,wed,thu,fri 09:00-18:00", wantMatch: true},
+		{name: "SuccessAfterBusinessHours", t: time.Date(2023, time.January, 25, 18, 0, 0, 0, paris), wantMatch: false},
+		{name: "SuccessWholeDay", t: time.Date(2023, time.January, 28, 20, 0, 0, 0, paris), wantRule: "sat all day", wantMatch: true},
+		{name: "SuccessRangeCrossingMidnight", t: time.Date(2023, time.January, 29, 0, 15, 0, 0, paris), wantRule: "every day 23:30-00:30", wantMatch: true},
+		{name: "SuccessEvent", t: time.Date(2023, time.December, 25, 6, 0, 0, 0, paris), wantRule: "event 'Christmas'", wantMatch: true},
+		{name: "SuccessRecurringEvent", t: time.Date(2023, time.November, 23, 6, 0, 0, 0, paris), wantRule: "event 'Thanksgiving'", wantMatch: true},
+		{name: "SuccessWeeklyEvent", t: time.Date(2024, time.January, 8, 20, 30, 0, 0, paris), wantRule: "event 'Team meeting'", wantMatch: true},
+		{name: "SuccessNoMatch", t: time.Date(2023, time.January, 29, 12, 0, 0, 0, paris), wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, match := calendar.Match(tt.t)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantRule, rule)
+		})
+	}
+}
+
+func TestRangeConfig_String(t *testing.T) {
+	tests := []struct {
+		name string
+		r    RangeConfig
+		want string
+	}{
+		{name: "SuccessWholeDay", r: RangeConfig{Weekdays: []string{"sun"}}, want: "sun all day"},
+		{name: "SuccessEveryDay", r: RangeConfig{From: "09:00", To: "18:00"}, want: "every day 09:00-18:00"},
+		{name: "SuccessFromOnly", r: RangeConfig{From: "22:00"}, want: "every day 22:00-24:00"},
+		{name: "SuccessToOnly", r: RangeConfig{Weekdays: []string{"mon"}, To: "06:00"}, want: "mon 00:00-06:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.r.String())
+		})
+	}
+}
+
+func TestCalendars_Excluded(t *testing.T) {
+	calendars := Calendars{
+		"holidays": &Calendar{Id: "holidays", dates: []string{"2023-12-25"}},
+		"weekend":  &Calendar{Id: "weekend", ranges: []RangeConfig{{Weekdays: []string{"sat", "sun"}}}},
+	}
+	christmas := time.Date(2023, time.December, 25, 12, 0, 0, 0, time.UTC)
+
+	reason, excluded := calendars.Excluded([]string{"weekend", "holidays"}, christmas)
+	assert.True(t, excluded)
+	assert.Equal(t, "excluded by calendar holidays (date 2023-12-25)", reason)
+
+	reason, excluded = calendars.Excluded([]string{"weekend", "unknown"}, christmas)
+	assert.False(t, excluded)
+	assert.Equal(t, "", reason)
+
+	_, excluded = Calendars(nil).Excluded([]string{"holidays"}, christmas)
+	assert.False(t, excluded)
+}
@@ -0,0 +1,456 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405"
+
+	FreqYearly = "YEARLY"
+	FreqWeekly = "WEEKLY"
+	FreqDaily  = "DAILY"
+)
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Event is an event of an iCalendar file, floating times (dates, times without timezone) are wall clock times stored in UTC.
+type Event struct {
+	Summary  string
+	Start    time.Time
+	End      time.Time
+	Floating bool
+	// Freq is the frequency of the recurrence (RRULE:FREQ), empty without recurrence.
+	// A yearly recurrence is on the day of Start without BYMONTH, BYMONTHDAY and BYDAY, a weekly one on its weekday without BYDAY.
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []WeekdayRule
+	// WeekStart is the first day of the weeks of a weekly recurrence (RRULE:WKST).
+	WeekStart time.Weekday
+}
+
+// WeekdayRule is a BYDAY value: every Weekday of the month (or of the year without BYMONTH) when Nth is 0,
+// the Nth one otherwise, from the end when it is negative (-1MO is the last Monday). Nth is only allowed in yearly rules.
+type WeekdayRule struct {
+	Nth     int
+	Weekday time.Weekday
+}
+
+// RuleError is an RRULE which is not supported, the event is skipped.
+type RuleError struct {
+	Summary string
+	Err     error
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("invalid RRULE of event '%s': %v", e.Summary, e.Err)
+}
+
+// Match returns true when t is between the start (included) and the end (excluded) of an occurrence of the event.
+func (e Event) Match(t time.Time) bool {
+	if e.Floating {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	switch e.Freq {
+	case "":
+		return matchRange(t, e.Start, e.End)
+	case FreqYearly:
+		return e.matchYearly(t)
+	}
+	return e.matchDays(t)
+}
+
+// matchYearly checks the occurrences of a yearly recurrence from the start until the year of t.
+func (e Event) matchYearly(t time.Time) bool {
+	year := e.Start.Year()
+	// the occurrences are counted from the start, otherwise an occurrence which starts the year before can end in the year of t
+	if first := t.Year() - 1; e.Count == 0 && first > year {
+		year += (first - year + e.Interval - 1) / e.Interval * e.Interval
+	}
+	count := 0
+	for ; year <= t.Year(); year += e.Interval {
+		for _, start := range e.occurrences(year) {
+			if start.Before(e.Start) {
+				continue
+			}
+			count++
+			if (!e.Until.IsZero() && start.After(e.Until)) || (e.Count > 0 && count > e.Count) {
+				return false
+			}
+			if matchRange(t, start, start.Add(e.End.Sub(e.Start))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchDays checks the occurrences of a daily or weekly recurrence which start at most the duration of the event before t.
+func (e Event) matchDays(t time.Time) bool {
+	duration := e.End.Sub(e.Start)
+	local := t.In(e.Start.Location())
+	for day := civilDay(local.Add(-duration)); !day.After(civilDay(local)); day = day.AddDate(0, 0, 1) {
+		start := e.startOn(day)
+		if !e.onDay(day) || start.Before(e.Start) || (!e.Until.IsZero() && start.After(e.Until)) {
+			continue
+		}
+		if matchRange(t, start, start.Add(duration)) && (e.Count == 0 || e.countBefore(start) < e.Count) {
+			return true
+		}
+	}
+	return false
+}
+
+// countBefore returns the number of occurrences of a daily or weekly recurrence which start before start, up to Count.
+func (e Event) countBefore(start time.Time) int {
+	count := 0
+	for day := civilDay(e.Start); count < e.Count; day = day.AddDate(0, 0, 1) {
+		occurrence := e.startOn(day)
+		if !occurrence.Before(start) {
+			break
+		}
+		if e.onDay(day) && !occurrence.Before(e.Start) {
+			count++
+		}
+	}
+	return count
+}
+
+// onDay returns true when a daily or weekly recurrence has an occurrence on day (a date in UTC).
+func (e Event) onDay(day time.Time) bool {
+	if len(e.ByMonth) > 0 && !slices.Contains(e.ByMonth, day.Month()) {
+		return false
+	}
+	first := civilDay(e.Start)
+	if e.Freq == FreqDaily {
+		return daysBetween(first, day)%e.Interval == 0 && e.matchWeekday(day.Weekday()) && e.matchMonthDay(day)
+	}
+	if len(e.ByDay) == 0 && day.Weekday() != first.Weekday() {
+		return false
+	}
+	return e.matchWeekday(day.Weekday()) && daysBetween(e.weekOf(first), e.weekOf(day))/7%e.Interval == 0
+}
+
+// matchMonthDay returns true when BYMONTHDAY is empty or contains the day of the month of day.
+func (e Event) matchMonthDay(day time.Time) bool {
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return len(e.ByMonthDay) == 0 || slices.ContainsFunc(e.ByMonthDay, func(monthDay int) bool {
+		return monthDay == day.Day() || monthDay == day.Day()-last-1
+	})
+}
+
+// weekOf returns the first day of the week of day, weeks start on WeekStart.
+func (e Event) weekOf(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(e.WeekStart) + 7) % 7))
+}
+
+// startOn returns the start of the occurrence on day (a date in UTC), at the time of Start.
+func (e Event) startOn(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), e.Start.Hour(), e.Start.Minute(), e.Start.Second(), 0, e.Start.Location())
+}
+
+// civilDay returns the date of t in UTC.
+func civilDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from) / (24 * time.Hour))
+}
+
+// occurrences returns the sorted starts of the occurrences in year, COUNT and UNTIL ignored.
+func (e Event) occurrences(year int) []time.Time {
+	if len(e.ByMonth) == 0 && len(e.ByMonthDay) == 0 && len(e.ByDay) == 0 {
+		return []time.Time{e.Start.AddDate(year-e.Start.Year(), 0, 0)}
+	}
+	months := e.ByMonth
+	if len(months) == 0 && (len(e.ByMonthDay) > 0 || len(e.ByDay) == 0) {
+		months = []time.Month{e.Start.Month()}
+	}
+	days := []time.Time{}
+	switch {
+	case len(e.ByMonthDay) > 0:
+		for _, month := range months {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			for _, day := range e.ByMonthDay {
+				if day < 0 {
+					day += last + 1
+				}
+				date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+				if day >= 1 && day <= last && e.matchWeekday(date.Weekday()) {
+					days = append(days, date)
+				}
+			}
+		}
+	case len(months) == 0:
+		// BYDAY without BYMONTH is relative to the year
+		days = matchingDays(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC), e.ByDay)
+	default:
+		for _, month := range months {
+			from := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+			days = append(days, matchingDays(from, from.AddDate(0, 1, 0), e.ByDay)...)
+		}
+	}
+
+	starts := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		starts = append(starts, time.Date(day.Year(), day.Month(), day.Day(), e.Start.Hour(), e.Start.Minute(), e.Start.Second(), 0, e.Start.Location()))
+	}
+	slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(starts, time.Time.Equal)
+}
+
+// matchWeekday returns true when BYDAY is empty or contains weekday, BYDAY filters BYMONTHDAY without its Nth.
+func (e Event) matchWeekday(weekday time.Weekday) bool {
+	return len(e.ByDay) == 0 || slices.ContainsFunc(e.ByDay, func(rule WeekdayRule) bool { return rule.Weekday == weekday })
+}
+
+// matchingDays returns the days between from (included) and to (excluded) which match a rule.
+func matchingDays(from time.Time, to time.Time, rules []WeekdayRule) []time.Time {
+	days := []time.Time{}
+	for _, rule := range rules {
+		matching := []time.Time{}
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == rule.Weekday {
+				matching = append(matching, day)
+			}
+		}
+		switch {
+		case rule.Nth == 0:
+			days = append(days, matching...)
+		case rule.Nth > 0 && rule.Nth <= len(matching):
+			days = append(days, matching[rule.Nth-1])
+		case rule.Nth < 0 && -rule.Nth <= len(matching):
+			days = append(days, matching[len(matching)+rule.Nth])
+		}
+	}
+	return days
+}
+
+func matchRange(t time.Time, start time.Time, end time.Time) bool {
+	return !t.Before(start) && (t.Before(end) || t.Equal(start))
+}
+
+// ParseIcs returns the events (VEVENT) of an iCalendar content and the errors of the events skipped for their RRULE.
+func ParseIcs(content string) ([]Event, []error, error) {
+	events := []Event{}
+	skipped := []error{}
+	var props map[string]icsProperty
+	for _, line := range unfold(content) {
+		switch {
+		case line == "BEGIN:VEVENT":
+			props = map[string]icsProperty{}
+		case line == "END:VEVENT" && props != nil:
+			event, err := newEvent(props)
+			var ruleErr *RuleError
+			if errors.As(err, &ruleErr) {
+				skipped = append(skipped, err)
+			} else if err != nil {
+				return nil, nil, err
+			} else {
+				events = append(events, event)
+			}
+			props = nil
+		case props != nil:
+			if prop, ok := parseProperty(line); ok {
+				props[prop.name] = prop
+			}
+		}
+	}
+	return events, skipped, nil
+}
+
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfold joins the lines continued by a space or a tab.
+func unfold(content string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	return lines
+}
+
+func parseProperty(line string) (icsProperty, bool) {
+	head, value, found := strings.Cut(line, ":")
+	if !found {
+		return icsProperty{}, false
+	}
+	parts := strings.Split(head, ";")
+	prop := icsProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: value}
+	for _, param := range parts[1:] {
+		key, paramValue, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+	return prop, true
+}
+
+func newEvent(props map[string]icsProperty) (Event, error) {
+	summary := strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(props["SUMMARY"].value)
+	dtStart, ok := props["DTSTART"]
+	if !ok {
+		return Event{}, fmt.Errorf("event '%s' has no DTSTART", summary)
+	}
+	start, allDay, floating, err := parseIcsTime(dtStart)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid DTSTART of event '%s': %v", summary, err)
+	}
+	event := Event{Summary: summary, Start: start, End: start, Floating: floating}
+	if allDay {
+		event.End = start.AddDate(0, 0, 1)
+	}
+	if dtEnd, ok := props["DTEND"]; ok {
+		event.End, _, _, err = parseIcsTime(dtEnd)
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid DTEND of event '%s': %v", summary, err)
+		}
+	}
+	if rrule, ok := props["RRULE"]; ok {
+		if err = event.parseRule(rrule.value); err != nil {
+			return Event{}, &RuleError{Summary: summary, Err: err}
+		}
+	}
+	return event, nil
+}
+
+// parseRule supports the yearly recurrences, the rules of holidays (like FREQ=YEARLY;BYMONTH=11;BYDAY=4TH),
+// and the weekly and daily ones, the rules of recurring windows (like FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU).
+func (e *Event) parseRule(rule string) error {
+	e.Interval = 1
+	weekStart := time.Monday
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			if value != FreqYearly && value != FreqWeekly && value != FreqDaily {
+				return fmt.Errorf("unsupported frequency %s", value)
+			}
+			e.Freq = value
+		case "INTERVAL":
+			e.Interval, err = strconv.Atoi(value)
+			if err == nil && e.Interval < 1 {
+				err = fmt.Errorf("interval must be positive")
+			}
+		case "COUNT":
+			e.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			e.Until, _, _, err = parseIcsTime(icsProperty{value: value})
+		case "BYMONTH":
+			err = parseList(value, func(item string) error {
+				month, err := strconv.Atoi(item)
+				if err != nil || month < 1 || month > 12 {
+					return fmt.Errorf("invalid month %s", item)
+				}
+				e.ByMonth = append(e.ByMonth, time.Month(month))
+				return nil
+			})
+		case "BYMONTHDAY":
+			err = parseList(value, func(item string) error {
+				day, err := strconv.Atoi(item)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return fmt.Errorf("invalid day of month %s", item)
+				}
+				e.ByMonthDay = append(e.ByMonthDay, day)
+				return nil
+			})
+		case "BYDAY":
+			err = parseList(value, func(item string) error {
+				split := max(len(item)-2, 0)
+				weekday, ok := icsWeekdays[strings.ToUpper(item[split:])]
+				nth := 0
+				var err error
+				if item[:split] != "" {
+					nth, err = strconv.Atoi(item[:split])
+				}
+				if !ok || err != nil || nth < -53 || nth > 53 {
+					return fmt.Errorf("invalid day %s", item)
+				}
+				e.ByDay = append(e.ByDay, WeekdayRule{Nth: nth, Weekday: weekday})
+				return nil
+			})
+		case "WKST":
+			var ok bool
+			if weekStart, ok = icsWeekdays[strings.ToUpper(value)]; !ok {
+				err = fmt.Errorf("invalid week start %s", value)
+			}
+		default:
+			return fmt.Errorf("unsupported part %s", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	switch e.Freq {
+	case "":
+		return fmt.Errorf("missing FREQ")
+	case FreqYearly:
+		return nil
+	case FreqWeekly:
+		// the week start only changes the weekly rules
+		e.WeekStart = weekStart
+		if len(e.ByMonthDay) > 0 {
+			return fmt.Errorf("BYMONTHDAY is not allowed with frequency %s", e.Freq)
+		}
+	}
+	if slices.ContainsFunc(e.ByDay, func(rule WeekdayRule) bool { return rule.Nth != 0 }) {
+		return fmt.Errorf("BYDAY with a position is not allowed with frequency %s", e.Freq)
+	}
+	return nil
+}
+
+func parseList(value string, parse func(item string) error) error {
+	for _, item := range strings.Split(value, ",") {
+		if err := parse(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseIcsTime returns the time of a DATE or DATE-TIME value, with or without TZID.
+func parseIcsTime(prop icsProperty) (t time.Time, allDay bool, floating bool, err error) {
+	switch {
+	case prop.params["VALUE"] == "DATE" || len(prop.value) == len(icsDateLayout):
+		t, err = time.Parse(icsDateLayout, prop.value)
+		return t, true, true, err
+	case strings.HasSuffix(prop.value, "Z"):
+		t, err = time.Parse(icsDateTimeLayout+"Z", prop.value)
+		return t, false, false, err
+	case prop.params["TZID"] != "":
+		location, err := time.LoadLocation(prop.params["TZID"])
+		if err != nil {
+			return t, false, false, err
+		}
+		t, err = time.ParseInLocation(icsDateTimeLayout, prop.value, location)
+		return t, false, false, err
+	}
+	t, err = time.Parse(icsDateTimeLayout, prop.value)
+	return t, false, true, err
+}
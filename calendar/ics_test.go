@@ -0,0 +1,312 @@
+package calendar
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseIcs(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	tests := []struct {
+		name        string
+		content     string
+		want        []Event
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:    "SuccessEmpty",
+			content: "BEGIN:VCALENDAR\nEND:VCALENDAR\n",
+			want:    []Event{},
+		},
+		{
+			name:    "SuccessAllDay",
+			content: "BEGIN:VEVENT\r\nSUMMARY:New year\\, day off\r\nDTSTART;VALUE=DATE:20240101\r\nEND:VEVENT\r\n",
+			want: []Event{{
+				Summary:  "New year, day off",
+				Start:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+				Floating: true,
+			}},
+		},
+		{
+			name: "SuccessFoldedLinesWithTimezones",
+			content: "BEGIN:VEVENT\nSUMMARY:Maintenance of the\n  database\nDTSTART;TZID=Europe/Paris:20240110T220000\nDTEND:20240111T010000Z\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Floating\nDTSTART:20240110T080000\nDTEND:20240110T090000\nEND:VEVENT\n",
+			want: []Event{
+				{
+					Summary: "Maintenance of the database",
+					Start:   time.Date(2024, time.January, 10, 22, 0, 0, 0, paris),
+					End:     time.Date(2024, time.January, 11, 1, 0, 0, 0, time.UTC),
+				},
+				{
+					Summary:  "Floating",
+					Start:    time.Date(2024, time.January, 10, 8, 0, 0, 0, time.UTC),
+					End:      time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC),
+					Floating: true,
+				},
+			},
+		},
+		{
+			name:    "SuccessYearly",
+			content: "BEGIN:VEVENT\nSUMMARY:Christmas\nDTSTART;VALUE=DATE:20201225\nRRULE:FREQ=YEARLY;INTERVAL=1;COUNT=10;UNTIL=20291231\nEND:VEVENT\n",
+			want: []Event{{
+				Summary:  "Christmas",
+				Start:    time.Date(2020, time.December, 25, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2020, time.December, 26, 0, 0, 0, 0, time.UTC),
+				Floating: true,
+				Freq:     FreqYearly,
+				Interval: 1,
+				Count:    10,
+				Until:    time.Date(2029, time.December, 31, 0, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			name:    "ErrorWithoutStart",
+			content: "BEGIN:VEVENT\nSUMMARY:Christmas\nEND:VEVENT\n",
+			wantErr: "event 'Christmas' has no DTSTART",
+		},
+		{
+			name:    "ErrorWrongStart",
+			content: "BEGIN:VEVENT\nSUMMARY:Christmas\nDTSTART:2023-12-25\nEND:VEVENT\n",
+			wantErr: "invalid DTSTART of event 'Christmas': parsing time \"2023-12-25\" as \"20060102T150405\": cannot parse \"-12-25\" as \"01\"",
+		},
+		{
+			name:    "ErrorWrongEnd",
+			content: "BEGIN:VEVENT\nSUMMARY:Christmas\nDTSTART:20231225T000000\nDTEND;TZID=Europe/Wrong:20231226T000000\nEND:VEVENT\n",
+			wantErr: "invalid DTEND of event 'Christmas': unknown time zone Europe/Wrong",
+		},
+		{
+			name: "SuccessYearlyByRules",
+			content: "BEGIN:VEVENT\nSUMMARY:Thanksgiving\nDTSTART;VALUE=DATE:20201126\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;WKST=MO\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Friday 13th\nDTSTART;VALUE=DATE:20200313\nRRULE:FREQ=YEARLY;BYMONTH=3,11;BYMONTHDAY=13,-1;BYDAY=-1FR,FR\nEND:VEVENT\n",
+			want: []Event{
+				{
+					Summary:  "Thanksgiving",
+					Start:    time.Date(2020, time.November, 26, 0, 0, 0, 0, time.UTC),
+					End:      time.Date(2020, time.November, 27, 0, 0, 0, 0, time.UTC),
+					Floating: true,
+					Freq:     FreqYearly,
+					Interval: 1,
+					ByMonth:  []time.Month{time.November},
+					ByDay:    []WeekdayRule{{Nth: 4, Weekday: time.Thursday}},
+				},
+				{
+					Summary:    "Friday 13th",
+					Start:      time.Date(2020, time.March, 13, 0, 0, 0, 0, time.UTC),
+					End:        time.Date(2020, time.March, 14, 0, 0, 0, 0, time.UTC),
+					Floating:   true,
+					Freq:       FreqYearly,
+					Interval:   1,
+					ByMonth:    []time.Month{time.March, time.November},
+					ByMonthDay: []int{13, -1},
+					ByDay:      []WeekdayRule{{Nth: -1, Weekday: time.Friday}, {Weekday: time.Friday}},
+				},
+			},
+		},
+		{
+			name: "SuccessWeeklyAndDaily",
+			content: "BEGIN:VEVENT\nSUMMARY:Weekend freeze\nDTSTART;TZID=Europe/Paris:20240106T000000\nDTEND;TZID=Europe/Paris:20240106T060000\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU;WKST=SU\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Backup window\nDTSTART:20240101T020000Z\nDTEND:20240101T030000Z\nRRULE:FREQ=DAILY;COUNT=5\nEND:VEVENT\n",
+			want: []Event{
+				{
+					Summary:   "Weekend freeze",
+					Start:     time.Date(2024, time.January, 6, 0, 0, 0, 0, paris),
+					End:       time.Date(2024, time.January, 6, 6, 0, 0, 0, paris),
+					Freq:      FreqWeekly,
+					Interval:  2,
+					ByDay:     []WeekdayRule{{Weekday: time.Saturday}, {Weekday: time.Sunday}},
+					WeekStart: time.Sunday,
+				},
+				{
+					Summary:  "Backup window",
+					Start:    time.Date(2024, time.January, 1, 2, 0, 0, 0, time.UTC),
+					End:      time.Date(2024, time.January, 1, 3, 0, 0, 0, time.UTC),
+					Freq:     FreqDaily,
+					Interval: 1,
+					Count:    5,
+				},
+			},
+		},
+		{
+			name: "SuccessSkipUnsupportedRules",
+			content: "BEGIN:VEVENT\nSUMMARY:Meeting\nDTSTART:20231225T100000\nRRULE:FREQ=MONTHLY\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Weekly position\nDTSTART:20231225T100000\nRRULE:FREQ=WEEKLY;BYDAY=1MO\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Weekly month day\nDTSTART:20231225T100000\nRRULE:FREQ=WEEKLY;BYMONTHDAY=1\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Easter\nDTSTART:20231225T100000\nRRULE:FREQ=YEARLY;BYEASTER=0\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Wrong\nDTSTART:20231225T100000\nRRULE:FREQ=YEARLY;BYMONTH=13\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:Christmas\nDTSTART;VALUE=DATE:20231225\nEND:VEVENT\n",
+			want: []Event{{
+				Summary:  "Christmas",
+				Start:    time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2023, time.December, 26, 0, 0, 0, 0, time.UTC),
+				Floating: true,
+			}},
+			wantSkipped: []string{
+				"invalid RRULE of event 'Meeting': unsupported frequency MONTHLY",
+				"invalid RRULE of event 'Weekly position': BYDAY with a position is not allowed with frequency WEEKLY",
+				"invalid RRULE of event 'Weekly month day': BYMONTHDAY is not allowed with frequency WEEKLY",
+				"invalid RRULE of event 'Easter': unsupported part BYEASTER",
+				"invalid RRULE of event 'Wrong': invalid month 13",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped, err := ParseIcs(tt.content)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			var errs []string
+			for _, skip := range skipped {
+				errs = append(errs, skip.Error())
+			}
+			assert.Equal(t, tt.wantSkipped, errs)
+		})
+	}
+}
+
+func TestEvent_Match(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	newYear := Event{
+		Summary:  "New year's eve",
+		Start:    time.Date(2020, time.December, 31, 18, 0, 0, 0, time.UTC),
+		End:      time.Date(2021, time.January, 1, 6, 0, 0, 0, time.UTC),
+		Floating: true,
+		Freq:     FreqYearly,
+		Interval: 2,
+		Count:    3,
+	}
+	maintenance := Event{
+		Summary: "Maintenance",
+		Start:   time.Date(2024, time.January, 10, 22, 0, 0, 0, paris),
+		End:     time.Date(2024, time.January, 11, 1, 0, 0, 0, time.UTC),
+	}
+	thanksgiving := Event{
+		Start:    time.Date(2020, time.November, 26, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2020, time.November, 27, 0, 0, 0, 0, time.UTC),
+		Floating: true,
+		Freq:     FreqYearly,
+		Interval: 1,
+		Count:    6,
+		ByMonth:  []time.Month{time.November},
+		ByDay:    []WeekdayRule{{Nth: 4, Weekday: time.Thursday}},
+	}
+	memorialDay := Event{
+		Start:    time.Date(2020, time.May, 25, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2020, time.May, 26, 0, 0, 0, 0, time.UTC),
+		Floating: true,
+		Freq:     FreqYearly,
+		Interval: 1,
+		ByMonth:  []time.Month{time.May},
+		ByDay:    []WeekdayRule{{Nth: -1, Weekday: time.Monday}},
+	}
+	paydays := Event{
+		Start:      time.Date(2020, time.January, 15, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2020, time.January, 16, 0, 0, 0, 0, time.UTC),
+		Floating:   true,
+		Freq:       FreqYearly,
+		Interval:   2,
+		ByMonth:    []time.Month{time.January, time.February},
+		ByMonthDay: []int{15, -1},
+	}
+	fridayThe13th := Event{
+		Start:      time.Date(2020, time.March, 13, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2020, time.March, 14, 0, 0, 0, 0, time.UTC),
+		Floating:   true,
+		Freq:       FreqYearly,
+		Interval:   1,
+		ByMonth:    []time.Month{time.January, time.February, time.March, time.April, time.May, time.June, time.July, time.August, time.September, time.October, time.November, time.December},
+		ByMonthDay: []int{13},
+		ByDay:      []WeekdayRule{{Weekday: time.Friday}},
+	}
+	mondays := Event{
+		Start:    time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2020, time.January, 7, 0, 0, 0, 0, time.UTC),
+		Floating: true,
+		Freq:     FreqYearly,
+		Interval: 1,
+		ByDay:    []WeekdayRule{{Weekday: time.Monday}},
+	}
+	weekendFreeze := Event{
+		Start:     time.Date(2024, time.January, 6, 22, 0, 0, 0, paris),
+		End:       time.Date(2024, time.January, 7, 6, 0, 0, 0, paris),
+		Freq:      FreqWeekly,
+		Interval:  2,
+		ByDay:     []WeekdayRule{{Weekday: time.Saturday}, {Weekday: time.Sunday}},
+		WeekStart: time.Monday,
+	}
+	standup := Event{
+		Start:     time.Date(2024, time.January, 3, 9, 30, 0, 0, time.UTC),
+		End:       time.Date(2024, time.January, 3, 9, 45, 0, 0, time.UTC),
+		Floating:  true,
+		Freq:      FreqWeekly,
+		Interval:  1,
+		Until:     time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC),
+		WeekStart: time.Monday,
+	}
+	backupWindow := Event{
+		Start:    time.Date(2024, time.January, 1, 2, 0, 0, 0, time.UTC),
+		End:      time.Date(2024, time.January, 1, 3, 0, 0, 0, time.UTC),
+		Freq:     FreqDaily,
+		Interval: 3,
+		Count:    4,
+		ByMonth:  []time.Month{time.January},
+	}
+	lastWeekdays := Event{
+		Start:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+		Floating:   true,
+		Freq:       FreqDaily,
+		Interval:   1,
+		ByMonthDay: []int{-1, -2},
+		ByDay:      []WeekdayRule{{Weekday: time.Monday}, {Weekday: time.Tuesday}, {Weekday: time.Wednesday}, {Weekday: time.Thursday}, {Weekday: time.Friday}},
+	}
+	tests := []struct {
+		name  string
+		event Event
+		t     time.Time
+		want  bool
+	}{
+		{name: "SuccessStart", event: maintenance, t: time.Date(2024, time.January, 10, 21, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessBeforeStart", event: maintenance, t: time.Date(2024, time.January, 10, 20, 59, 0, 0, time.UTC), want: false},
+		{name: "SuccessEndExcluded", event: maintenance, t: time.Date(2024, time.January, 11, 1, 0, 0, 0, time.UTC), want: false},
+		{name: "SuccessFloatingWallClock", event: newYear, t: time.Date(2020, time.December, 31, 18, 0, 0, 0, paris), want: true},
+		{name: "SuccessYearlyNextYear", event: newYear, t: time.Date(2023, time.January, 1, 5, 0, 0, 0, paris), want: true},
+		{name: "SuccessYearlyNotInInterval", event: newYear, t: time.Date(2021, time.December, 31, 20, 0, 0, 0, paris), want: false},
+		{name: "SuccessYearlyAfterCount", event: newYear, t: time.Date(2026, time.December, 31, 20, 0, 0, 0, paris), want: false},
+		{name: "SuccessYearlyBeforeStart", event: newYear, t: time.Date(2019, time.December, 31, 20, 0, 0, 0, paris), want: false},
+		{name: "SuccessNthWeekday", event: thanksgiving, t: time.Date(2023, time.November, 23, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessNotNthWeekday", event: thanksgiving, t: time.Date(2023, time.November, 30, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "SuccessLastWeekdayAfterCount", event: thanksgiving, t: time.Date(2026, time.November, 26, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "SuccessLastWeekday", event: memorialDay, t: time.Date(2024, time.May, 27, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessNotLastWeekday", event: memorialDay, t: time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "SuccessMonthDays", event: paydays, t: time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessMonthDaysOtherMonth", event: paydays, t: time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "SuccessMonthDayAndWeekday", event: fridayThe13th, t: time.Date(2023, time.October, 13, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessMonthDayNotWeekday", event: fridayThe13th, t: time.Date(2023, time.November, 13, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "SuccessWeekdaysOfYear", event: mondays, t: time.Date(2023, time.November, 13, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessWeeklyCrossingMidnight", event: weekendFreeze, t: time.Date(2024, time.January, 7, 3, 0, 0, 0, paris), want: true},
+		{name: "SuccessWeeklyOtherDay", event: weekendFreeze, t: time.Date(2024, time.January, 7, 23, 0, 0, 0, paris), want: true},
+		{name: "SuccessWeeklyNotInInterval", event: weekendFreeze, t: time.Date(2024, time.January, 14, 3, 0, 0, 0, paris), want: false},
+		{name: "SuccessWeeklyInInterval", event: weekendFreeze, t: time.Date(2024, time.December, 22, 3, 0, 0, 0, paris), want: true},
+		{name: "SuccessWeeklyBeforeStart", event: weekendFreeze, t: time.Date(2024, time.January, 6, 21, 0, 0, 0, paris), want: false},
+		{name: "SuccessWeeklyOnStartWeekday", event: standup, t: time.Date(2024, time.February, 7, 9, 40, 0, 0, paris), want: true},
+		{name: "SuccessWeeklyOtherWeekday", event: standup, t: time.Date(2024, time.February, 8, 9, 40, 0, 0, paris), want: false},
+		{name: "SuccessWeeklyAfterUntil", event: standup, t: time.Date(2024, time.April, 3, 9, 40, 0, 0, paris), want: false},
+		{name: "SuccessDailyInInterval", event: backupWindow, t: time.Date(2024, time.January, 10, 2, 30, 0, 0, time.UTC), want: true},
+		{name: "SuccessDailyNotInInterval", event: backupWindow, t: time.Date(2024, time.January, 9, 2, 30, 0, 0, time.UTC), want: false},
+		{name: "SuccessDailyAfterCount", event: backupWindow, t: time.Date(2024, time.January, 13, 2, 30, 0, 0, time.UTC), want: false},
+		{name: "SuccessDailyMonthDays", event: lastWeekdays, t: time.Date(2024, time.January, 30, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "SuccessDailyMonthDaysWeekend", event: lastWeekdays, t: time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.event.Match(tt.t))
+		})
+	}
+}
@@ -15,6 +15,7 @@ const (
 	NoResultPrint = "no-result-print"
 	Force         = "force"
 	EnvVars       = "env"
+	DryRun        = "dry-run"
 )
 
 func AddFlagWorkingDir(cmd *cobra.Command) {
@@ -93,3 +94,21 @@ func AddFlagEnvVars(cmd *cobra.Command) {
 		"Injected env vars. Format: -e KEY1=value1 -e KEY2=value2",
 	)
 }
+
+func AddFlagDryRun(cmd *cobra.Command) {
+	cmd.Flags().Bool(
+		DryRun,
+		false,
+		"Print what tasks would run with their command, directory and environment without running them",
+	)
+}
+
+// GetStringOrDefault returns the value of the flag when it is set on the command line or when value is empty, value otherwise.
+// It lets the flags override the defaults of the config, which override the defaults of the flags.
+func GetStringOrDefault(cmd *cobra.Command, name, value string) string {
+	flagValue, _ := cmd.Flags().GetString(name)
+	if value == "" || cmd.Flags().Changed(name) {
+		return flagValue
+	}
+	return value
+}
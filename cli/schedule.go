@@ -15,6 +15,7 @@ func GetScheduleCmd(ctx *context.Context) *cobra.Command {
 	cmd.AddCommand(
 		schedule.GetScheduleRunCmd(ctx),
 		schedule.GetScheduleStartCmd(ctx),
+		schedule.GetScheduleListCmd(ctx),
 	)
 
 	return cmd
@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/cli/flags"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/schedule"
+	"github.com/spf13/cobra"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	From   = "from"
+	Count  = "count"
+	Output = "output"
+
+	OutputTable = "table"
+	OutputJson  = "json"
+
+	// FromLayout is accepted by --from besides RFC 3339, in the timezone of --timezone.
+	FromLayout = "2006-01-02 15:04"
+)
+
+func GetScheduleListCmd(ctx *context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "list scheduled tasks with their next fire times",
+		Example: "list task1,task2 --from '2023-01-25 15:00' --count 10",
+		RunE:    GetScheduleListRunFn(ctx),
+		Args:    cobra.MatchAll(cobra.MaximumNArgs(1)),
+	}
+
+	flags.AddFlagTimezone(cmd)
+	cmd.Flags().String(
+		From,
+		"",
+		"Define the time to compute the next fire times from (RFC 3339 or 'YYYY-MM-DD HH:MM'), default: now",
+	)
+	cmd.Flags().IntP(
+		Count,
+		"n",
+		5,
+		"Define the number of next fire times of each task",
+	)
+	cmd.Flags().StringP(
+		Output,
+		"o",
+		OutputTable,
+		"Choose output format (table, json)",
+	)
+
+	return cmd
+}
+
+func GetScheduleListRunFn(ctx *context.Context) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		timezone, _ := cmd.Flags().GetString(flags.TimeZone)
+		fromValue, _ := cmd.Flags().GetString(From)
+		count, _ := cmd.Flags().GetInt(Count)
+		output, _ := cmd.Flags().GetString(Output)
+
+		taskFilter := []string{}
+		if len(args) == 1 {
+			taskFilter = strings.Split(args[0], ",")
+		}
+
+		if count < 1 {
+			return fmt.Errorf("count must be positive")
+		}
+		if output != OutputTable && output != OutputJson {
+			return fmt.Errorf("unsupported output %s", output)
+		}
+		location := time.Local
+		if timezone != "" {
+			var err error
+			location, err = time.LoadLocation(timezone)
+			if err != nil {
+				return err
+			}
+		}
+		from, err := parseFrom(fromValue, ctx.Clock.Now(), location)
+		if err != nil {
+			return err
+		}
+
+		previews := schedule.PreviewTasks(ctx, from, count, taskFilter)
+		if output == OutputJson {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(previews)
+		}
+		return writeTable(cmd.OutOrStdout(), previews)
+	}
+}
+
+func parseFrom(value string, now time.Time, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return now.In(location), nil
+	}
+	if from, err := time.Parse(time.RFC3339, value); err == nil {
+		return from.In(location), nil
+	}
+	from, err := time.ParseInLocation(FromLayout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %s, expected RFC 3339 or '%s'", From, value, FromLayout)
+	}
+	return from, nil
+}
+
+// writeTable writes a row by fire time, the columns of the task are only on its first row.
+func writeTable(out io.Writer, previews []schedule.Preview) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tEXPR\tDESCRIPTION\tTIMEZONE\tCONDITION\tNEXT")
+	for _, preview := range previews {
+		next := []string{}
+		for _, t := range preview.Next {
+			next = append(next, t.Format(time.RFC3339))
+		}
+		if preview.Error != "" {
+			next = append(next, "error: "+preview.Error)
+		}
+		if len(next) == 0 {
+			next = append(next, "-")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", preview.Id, orDash(preview.Expr), orDash(preview.Description), orDash(preview.Timezone), orDash(preview.Condition), next[0])
+		for _, t := range next[1:] {
+			_, _ = fmt.Fprintf(w, "\t\t\t\t\t%s\n", t)
+		}
+	}
+	return w.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
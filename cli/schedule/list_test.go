@@ -0,0 +1,104 @@
+package schedule
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/alexandreh2ag/go-task/cli/flags"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/schedule"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestGetScheduleListCmd_SuccessTable(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Clock = clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 15, 4, 13, 0, time.UTC))
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "task1", Command: "echo", CronExpr: "0 12 * * *", Expression: "ENV == prod"},
+		&types.ScheduledTask{Id: "task2", Command: "echo", DependsOn: []string{"task1"}},
+	}
+	out := bytes.NewBufferString("")
+	cmd := GetScheduleListCmd(ctx)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--" + flags.TimeZone, "UTC", "--" + Count, "2"})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	assert.Equal(t, ""+
+		"ID     EXPR        DESCRIPTION         TIMEZONE  CONDITION    NEXT\n"+
+		"task1  0 12 * * *  every day at 12:00  UTC       ENV == prod  2023-01-26T12:00:00Z\n"+
+		"                                                              2023-01-27T12:00:00Z\n"+
+		"task2  -           after task1         UTC       -            -\n",
+		out.String(),
+	)
+}
+
+func TestGetScheduleListCmd_SuccessJson(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "task1", Command: "echo", CronExpr: "30 2 * * *"},
+		&types.ScheduledTask{Id: "task2", Command: "echo", CronExpr: "* * * * *"},
+	}
+	out := bytes.NewBufferString("")
+	cmd := GetScheduleListCmd(ctx)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"task1", "--" + flags.TimeZone, "Europe/Paris", "--" + From, "2023-03-26 00:00", "--" + Output, OutputJson})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	previews := []schedule.Preview{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &previews))
+	assert.Len(t, previews, 1)
+	assert.Equal(t, "Europe/Paris", previews[0].Timezone)
+	assert.Len(t, previews[0].Next, 5)
+	assert.Equal(t, "2023-03-26T03:00:00+02:00", previews[0].Next[0].Format(time.RFC3339))
+}
+
+func TestGetScheduleListCmd_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "ErrorWrongFrom", args: []string{"--" + From, "tomorrow"}, wantErr: "invalid --from tomorrow, expected RFC 3339 or '2006-01-02 15:04'"},
+		{name: "ErrorWrongCount", args: []string{"--" + Count, "0"}, wantErr: "count must be positive"},
+		{name: "ErrorWrongOutput", args: []string{"--" + Output, "xml"}, wantErr: "unsupported output xml"},
+		{name: "ErrorWrongTimezone", args: []string{"--" + flags.TimeZone, "Europe/Wrong"}, wantErr: "unknown time zone Europe/Wrong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			cmd := GetScheduleListCmd(ctx)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(tt.args)
+			assert.EqualError(t, cmd.Execute(), tt.wantErr)
+		})
+	}
+}
+
+func Test_parseFrom(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2023, time.January, 25, 15, 4, 13, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{name: "SuccessNow", value: "", want: now.In(paris)},
+		{name: "SuccessRFC3339", value: "2023-01-25T15:00:00Z", want: time.Date(2023, time.January, 25, 16, 0, 0, 0, paris)},
+		{name: "SuccessInTimezone", value: "2023-01-25 15:00", want: time.Date(2023, time.January, 25, 15, 0, 0, 0, paris)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFrom(tt.value, now, paris)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got))
+			assert.Equal(t, paris, got.Location())
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package schedule
 
 import (
+	"fmt"
 	"github.com/alexandreh2ag/go-task/cli/flags"
 	"github.com/alexandreh2ag/go-task/context"
 	"github.com/alexandreh2ag/go-task/schedule"
-	"github.com/alexandreh2ag/go-task/types"
 	"github.com/spf13/cobra"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
 func GetScheduleRunCmd(ctx *context.Context) *cobra.Command {
@@ -19,35 +21,50 @@ func GetScheduleRunCmd(ctx *context.Context) *cobra.Command {
 	}
 
 	flags.AddFlagWorkingDir(cmd)
+	flags.AddFlagUser(cmd)
 	flags.AddFlagTimezone(cmd)
 	flags.AddFlagNoResultPrint(cmd)
 	flags.AddFlagResultPath(cmd)
 	flags.AddFlagForce(cmd)
 	flags.AddFlagEnvVars(cmd)
+	flags.AddFlagDryRun(cmd)
 
 	return cmd
 }
 
 func GetScheduleRunRunFn(ctx *context.Context) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		workingDir, _ := cmd.Flags().GetString(flags.WorkingDir)
 		timezone, _ := cmd.Flags().GetString(flags.TimeZone)
 		noResultPrint, _ := cmd.Flags().GetBool(flags.NoResultPrint)
 		resultPath, _ := cmd.Flags().GetString(flags.ResultPath)
 		force, _ := cmd.Flags().GetBool(flags.Force)
-		envVars, _ := cmd.Flags().GetStringToString(flags.EnvVars)
+		dryRun, _ := cmd.Flags().GetBool(flags.DryRun)
 
 		taskFilter := []string{}
 		if len(args) == 1 {
 			taskFilter = strings.Split(args[0], ",")
 		}
 
-		types.PrepareScheduledTasks(ctx.Config.Scheduled, ctx.Logger, workingDir, envVars)
+		if err := prepare(ctx, cmd); err != nil {
+			return err
+		}
 		refTime, err := schedule.GetCurrentTime(ctx.Clock.Now(), timezone)
 		if err != nil {
 			return err
 		}
-		schedule.Run(ctx, refTime, taskFilter, force, noResultPrint, resultPath)
+		// the running commands are interrupted on SIGINT or SIGTERM
+		notifyCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		if dryRun {
+			for _, plan := range schedule.DryRun(ctx, refTime, taskFilter, force) {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), schedule.FormatTaskPlan(refTime, plan))
+			}
+			return nil
+		}
+		runCtx := ctx.WithContext(notifyCtx)
+		defer runCtx.Cancel()
+		schedule.Run(runCtx, refTime, taskFilter, force, noResultPrint, resultPath)
 
 		return nil
 	}
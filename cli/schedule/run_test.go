@@ -1,11 +1,15 @@
 package schedule
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/alexandreh2ag/go-task/cli/flags"
 	"github.com/alexandreh2ag/go-task/context"
 	"github.com/alexandreh2ag/go-task/types"
 	"github.com/stretchr/testify/assert"
 	"io"
+	"os"
+	"path"
 	"testing"
 )
 
@@ -89,3 +93,22 @@ func TestGetScheduleRunCmd_FailedWithTimezoneOpt(t *testing.T) {
 	err := cmd.Execute()
 	assert.Error(t, err)
 }
+
+func TestGetScheduleRunCmd_SuccessWithDryRunOpt(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "test", Command: "touch " + output, CronExpr: "0 0 * * *"},
+	}
+	cmd := GetScheduleRunCmd(ctx)
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+
+	cmd.SetArgs([]string{"--" + flags.Force, "--" + flags.DryRun, "--" + flags.WorkingDir, "/app/test"})
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "Task test would run at ")
+	assert.Contains(t, b.String(), fmt.Sprintf("argv: [\"touch\", %q]\ndirectory: /app/test\n", output))
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+}
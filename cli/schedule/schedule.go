@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/alexandreh2ag/go-task/calendar"
+	"github.com/alexandreh2ag/go-task/cli/flags"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/lock"
+	"github.com/alexandreh2ag/go-task/notify"
+	"github.com/alexandreh2ag/go-task/secret"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/spf13/cobra"
+)
+
+// prepare completes the scheduled tasks with the defaults of the config overridden by the flags of cmd
+// and creates the services used to run them.
+// With the dry run flag, the secrets are not resolved and the notifications and the lock are not created:
+// nothing is read from or written to the outside, except the calendar files.
+func prepare(ctx *context.Context, cmd *cobra.Command) error {
+	envVars, _ := cmd.Flags().GetStringToString(flags.EnvVars)
+	dryRun, _ := cmd.Flags().GetBool(flags.DryRun)
+	defaults := ctx.Config.Defaults.Scheduled
+	defaults.User = flags.GetStringOrDefault(cmd, flags.User, defaults.User)
+	defaults.Directory = flags.GetStringOrDefault(cmd, flags.WorkingDir, defaults.Directory)
+	err := types.PrepareScheduledTasks(ctx.Fs, ctx.Config.Scheduled, ctx.Logger, defaults, envVars, ctx.Config.InheritEnv, ctx.Config.EnvFile)
+	if err != nil {
+		return err
+	}
+	for _, task := range ctx.Config.Scheduled {
+		task.Clock = ctx.Clock
+	}
+	if ctx.Config.Lock.Type == "" {
+		for _, task := range ctx.Config.Scheduled {
+			if task.Exclusive {
+				return fmt.Errorf("task %s is exclusive but no lock is configured", task.Id)
+			}
+		}
+	}
+
+	if !dryRun {
+		resolver, err := secret.NewResolver(ctx.Config.Secrets, ctx.Fs)
+		if err != nil {
+			return err
+		}
+		for _, task := range ctx.Config.Scheduled {
+			if err = task.ResolveSecrets(resolver); err != nil {
+				return err
+			}
+		}
+		notifier, err := notify.NewDispatcher(ctx.Config.Notifications, ctx.Logger)
+		if err != nil {
+			return err
+		}
+		ctx.Notifier = notifier
+
+		locker, err := lock.NewLocker(ctx.Config.Lock, ctx.Clock)
+		if err != nil {
+			return fmt.Errorf("failed to create lock: %v", err)
+		}
+		ctx.Locker = locker
+	}
+
+	calendars, err := calendar.Load(ctx.Fs, ctx.Config.Calendars, ctx.Logger)
+	if err != nil {
+		return err
+	}
+	for _, task := range ctx.Config.Scheduled {
+		for _, id := range task.ExcludeCalendars {
+			if _, ok := calendars[id]; !ok {
+				return fmt.Errorf("task %s excludes unknown calendar %s", task.Id, id)
+			}
+		}
+	}
+	ctx.Calendars = calendars
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package schedule
+
+import (
+	"github.com/alexandreh2ag/go-task/calendar"
+	"github.com/alexandreh2ag/go-task/cli/flags"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/lock"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	osUser "os/user"
+	"path"
+	"testing"
+	"time"
+)
+
+func Test_prepare(t *testing.T) {
+	lockDir := path.Join(t.TempDir(), "locks")
+	tests := []struct {
+		name       string
+		lock       lock.Config
+		exclusive  bool
+		calendars  calendar.Configs
+		excludes   []string
+		envs       map[string]string
+		dryRun     bool
+		wantLocker bool
+		wantToken  string
+		wantErr    string
+	}{
+		{
+			name: "SuccessWithoutLock",
+		},
+		{
+			name:       "SuccessWithLock",
+			lock:       lock.Config{Type: lock.TypeFile, Path: t.TempDir()},
+			exclusive:  true,
+			wantLocker: true,
+		},
+		{
+			name:      "ErrorExclusiveWithoutLock",
+			exclusive: true,
+			wantErr:   "task test is exclusive but no lock is configured",
+		},
+		{
+			name:      "SuccessWithCalendars",
+			calendars: calendar.Configs{{Id: "holidays", Dates: []string{"2023-12-25"}}},
+			excludes:  []string{"holidays"},
+		},
+		{
+			name:     "ErrorUnknownCalendar",
+			excludes: []string{"holidays"},
+			wantErr:  "task test excludes unknown calendar holidays",
+		},
+		{
+			name:      "ErrorWrongCalendar",
+			calendars: calendar.Configs{{Id: "holidays", Ics: "/missing.ics"}},
+			wantErr:   "failed to read ics of calendar holidays: open /missing.ics: file does not exist",
+		},
+		{
+			name:    "ErrorWrongLock",
+			lock:    lock.Config{Type: "wrong"},
+			wantErr: "failed to create lock: unsupported lock type wrong",
+		},
+		{
+			name:    "ErrorWrongSecret",
+			envs:    map[string]string{"TOKEN": "vault://app#token"},
+			wantErr: "failed to resolve environment TOKEN of task test: failed to resolve vault://app#token: unsupported scheme vault",
+		},
+		{
+			name:      "SuccessDryRun",
+			lock:      lock.Config{Type: lock.TypeDirectory, Path: lockDir},
+			exclusive: true,
+			envs:      map[string]string{"TOKEN": "vault://app#token"},
+			dryRun:    true,
+			wantToken: "vault://app#token",
+		},
+		{
+			name:      "ErrorDryRunExclusiveWithoutLock",
+			exclusive: true,
+			dryRun:    true,
+			wantErr:   "task test is exclusive but no lock is configured",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			ctx.Config.Lock = tt.lock
+			ctx.Config.Calendars = tt.calendars
+			ctx.Config.Scheduled = types.ScheduledTasks{
+				&types.ScheduledTask{Id: "test", Command: "echo", CronExpr: "0 0 * * *", Exclusive: tt.exclusive, ExcludeCalendars: tt.excludes, Envs: tt.envs},
+			}
+			cmd := GetScheduleRunCmd(ctx)
+			_ = cmd.Flags().Set(flags.WorkingDir, "/app")
+			if tt.dryRun {
+				_ = cmd.Flags().Set(flags.DryRun, "true")
+			}
+			err := prepare(ctx, cmd)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, !tt.dryRun, ctx.Notifier != nil)
+			assert.Equal(t, tt.wantLocker, ctx.Locker != nil)
+			assert.Equal(t, tt.wantToken, ctx.Config.Scheduled[0].Envs["TOKEN"])
+			assert.Len(t, ctx.Calendars, len(tt.calendars))
+			_, err = os.Stat(lockDir)
+			assert.True(t, os.IsNotExist(err))
+			assert.Equal(t, "/app", ctx.Config.Scheduled[0].Directory)
+		})
+	}
+}
+
+func Test_prepare_Defaults(t *testing.T) {
+	workingDir, _ := os.Getwd()
+	currentUser, _ := osUser.Current()
+	defaults := types.ScheduledDefaults{
+		Directory: "/srv",
+		User:      "app",
+		Envs:      map[string]string{"foo": "default", "BAR": "default"},
+		Timeout:   time.Minute,
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		defaults types.ScheduledDefaults
+		task     *types.ScheduledTask
+		want     *types.ScheduledTask
+	}{
+		{
+			name: "SuccessFlagDefaults",
+			task: &types.ScheduledTask{Id: "test"},
+			want: &types.ScheduledTask{Id: "test", Directory: workingDir, User: currentUser.Username, Envs: map[string]string{}},
+		},
+		{
+			name:     "SuccessConfigDefaults",
+			defaults: defaults,
+			task:     &types.ScheduledTask{Id: "test"},
+			want:     &types.ScheduledTask{Id: "test", Directory: "/srv", User: "app", Timeout: durationPtr(time.Minute), Envs: map[string]string{"FOO": "default", "BAR": "default"}},
+		},
+		{
+			name:     "SuccessFlagsOverrideConfigDefaults",
+			args:     []string{"-w", "/app", "-u", "flag", "-e", "FOO=flag"},
+			defaults: defaults,
+			task:     &types.ScheduledTask{Id: "test"},
+			want:     &types.ScheduledTask{Id: "test", Directory: "/app", User: "flag", Timeout: durationPtr(time.Minute), Envs: map[string]string{"FOO": "flag", "BAR": "default"}},
+		},
+		{
+			name:     "SuccessTaskOverridesFlagsAndConfigDefaults",
+			args:     []string{"-w", "/app", "-u", "flag", "-e", "FOO=flag"},
+			defaults: defaults,
+			task:     &types.ScheduledTask{Id: "test", Directory: "/task", User: "task", Timeout: durationPtr(time.Hour), Envs: map[string]string{"foo": "task", "bar": "task"}},
+			want:     &types.ScheduledTask{Id: "test", Directory: "/task", User: "task", Timeout: durationPtr(time.Hour), Envs: map[string]string{"FOO": "flag", "BAR": "task"}},
+		},
+		{
+			name:     "SuccessTaskDisablesDefaultTimeout",
+			defaults: defaults,
+			task:     &types.ScheduledTask{Id: "test", Timeout: durationPtr(0)},
+			want:     &types.ScheduledTask{Id: "test", Directory: "/srv", User: "app", Timeout: durationPtr(0), Envs: map[string]string{"FOO": "default", "BAR": "default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			ctx.Config.Defaults.Scheduled = tt.defaults
+			ctx.Config.Scheduled = types.ScheduledTasks{tt.task}
+			cmd := GetScheduleRunCmd(ctx)
+			assert.NoError(t, cmd.ParseFlags(tt.args))
+
+			assert.NoError(t, prepare(ctx, cmd))
+			got := ctx.Config.Scheduled[0]
+			assert.Equal(t, tt.want.Directory, got.Directory)
+			assert.Equal(t, tt.want.User, got.User)
+			assert.Equal(t, tt.want.Timeout, got.Timeout)
+			tt.want.Envs[types.GtaskIDKey] = "test"
+			tt.want.Envs[types.GtaskDirKey] = tt.want.Directory
+			assert.Equal(t, tt.want.Envs, got.Envs)
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -2,17 +2,31 @@ package schedule
 
 import (
 	"errors"
+	"fmt"
 	"github.com/alexandreh2ag/go-task/cli/flags"
+	"github.com/alexandreh2ag/go-task/config"
 	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/leader"
 	"github.com/alexandreh2ag/go-task/schedule"
-	"github.com/alexandreh2ag/go-task/types"
+	gtaskValidator "github.com/alexandreh2ag/go-task/validator"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"net"
+	"net/http"
 	"strings"
 	"time"
 )
 
 const (
-	Tick = "tick"
+	Tick            = "tick"
+	LeaderElection  = "leader-election"
+	HealthAddr      = "health-addr"
+	ReloadWatch     = "reload-watch"
+	ShutdownTimeout = "shutdown-timeout"
+
+	// DefaultLeaderElectionTick renews the lease when no tick is defined.
+	DefaultLeaderElectionTick = time.Minute
 )
 
 func GetScheduleStartCmd(ctx *context.Context) *cobra.Command {
@@ -24,14 +38,36 @@ func GetScheduleStartCmd(ctx *context.Context) *cobra.Command {
 	}
 
 	flags.AddFlagWorkingDir(cmd)
+	flags.AddFlagUser(cmd)
 	flags.AddFlagTimezone(cmd)
 	flags.AddFlagNoResultPrint(cmd)
 	flags.AddFlagResultPath(cmd)
 	flags.AddFlagEnvVars(cmd)
+	flags.AddFlagDryRun(cmd)
 	cmd.Flags().Duration(
 		Tick,
-		5*time.Minute,
-		"Define duration between each tick",
+		0,
+		"Define the longest duration between two evaluations of the tasks (disabled when 0)",
+	)
+	cmd.Flags().Bool(
+		LeaderElection,
+		false,
+		"Run tasks only when this replica is the leader (see leader_election config)",
+	)
+	cmd.Flags().String(
+		HealthAddr,
+		"",
+		"Define listen address of the health endpoint (ex: :8080), disabled when empty",
+	)
+	cmd.Flags().Duration(
+		ReloadWatch,
+		0,
+		"Define the interval to check changes of the config file to reload it (disabled when 0, reload on SIGHUP otherwise)",
+	)
+	cmd.Flags().Duration(
+		ShutdownTimeout,
+		0,
+		"Define the longest wait of the running tasks at exit before terminating them (0: no drain, terminate them at once)",
 	)
 
 	return cmd
@@ -40,28 +76,109 @@ func GetScheduleStartCmd(ctx *context.Context) *cobra.Command {
 func GetScheduleStartRunFn(ctx *context.Context) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 
-		workingDir, _ := cmd.Flags().GetString(flags.WorkingDir)
 		timezone, _ := cmd.Flags().GetString(flags.TimeZone)
 		noResultPrint, _ := cmd.Flags().GetBool(flags.NoResultPrint)
 		resultPath, _ := cmd.Flags().GetString(flags.ResultPath)
 		tick, _ := cmd.Flags().GetDuration(Tick)
-		envVars, _ := cmd.Flags().GetStringToString(flags.EnvVars)
+		leaderElection, _ := cmd.Flags().GetBool(LeaderElection)
+		healthAddr, _ := cmd.Flags().GetString(HealthAddr)
+		dryRun, _ := cmd.Flags().GetBool(flags.DryRun)
+		reloadWatch, _ := cmd.Flags().GetDuration(ReloadWatch)
+		shutdownTimeout, _ := cmd.Flags().GetDuration(ShutdownTimeout)
 
 		taskFilter := []string{}
 		if len(args) == 1 {
 			taskFilter = strings.Split(args[0], ",")
 		}
 
-		if tick.Minutes() == 0 {
-			return errors.New("tick duration must be higher than 0")
+		if tick < 0 {
+			return errors.New("tick duration must not be negative")
+		}
+		if reloadWatch < 0 {
+			return errors.New("reload watch duration must not be negative")
+		}
+		if shutdownTimeout < 0 {
+			return errors.New("shutdown timeout must not be negative")
+		}
+
+		if err := prepare(ctx, cmd); err != nil {
+			return err
 		}
 
-		if tick%time.Minute != 0 {
-			return errors.New("tick duration must be only in minutes")
+		// a dry run only prints the plans of the tasks, it does not campaign for the leadership
+		if leaderElection && !dryRun {
+			if tick == 0 {
+				tick = DefaultLeaderElectionTick
+			}
+			if err := prepareLeaderElection(ctx, tick); err != nil {
+				return err
+			}
 		}
 
-		types.PrepareScheduledTasks(ctx.Config.Scheduled, ctx.Logger, workingDir, envVars)
+		if healthAddr != "" {
+			listener, err := net.Listen("tcp", healthAddr)
+			if err != nil {
+				return fmt.Errorf("failed to listen health address %s: %v", healthAddr, err)
+			}
+			server := &http.Server{Handler: schedule.HealthHandler(ctx), ReadHeaderTimeout: 10 * time.Second}
+			go func() { _ = server.Serve(listener) }()
+			defer server.Close()
+		}
+
+		reloader := &schedule.Reloader{
+			Load:  reloadFn(ctx, cmd),
+			Path:  viper.ConfigFileUsed(),
+			Watch: reloadWatch,
+		}
+		var dryRunOut io.Writer
+		if dryRun {
+			dryRunOut = cmd.OutOrStdout()
+		}
+		return schedule.Start(ctx, tick, timezone, taskFilter, reloader, shutdownTimeout, dryRunOut, noResultPrint, resultPath)
+	}
+}
+
+// reloadFn reads the config file again in a copy of the context, validated and prepared like at start.
+func reloadFn(ctx *context.Context, cmd *cobra.Command) func() (*context.Context, error) {
+	return func() (*context.Context, error) {
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		cfg := config.DefaultConfig()
+		if err := viper.Unmarshal(&cfg); err != nil {
+			return nil, fmt.Errorf("unable to decode into config struct, %v", err)
+		}
+		if err := gtaskValidator.New().Struct(cfg); err != nil {
+			return nil, fmt.Errorf("configuration file is not valid: %v", err)
+		}
+
+		next := *ctx
+		next.Config = &cfg
+		if err := prepare(&next, cmd); err != nil {
+			return nil, err
+		}
+		return &next, nil
+	}
+}
+
+// prepareLeaderElection creates the leadership, the lease must outlive the tick to be renewed in time.
+func prepareLeaderElection(ctx *context.Context, tick time.Duration) error {
+	cfg := ctx.Config.LeaderElection
+	if cfg.Type == "" {
+		return errors.New("leader election needs a leader_election config")
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = 3 * tick
+	}
+	if cfg.TTL <= tick {
+		return fmt.Errorf("leader election ttl (%s) must be higher than tick (%s)", cfg.TTL, tick)
+	}
 
-		return schedule.Start(ctx, int(tick.Minutes()), timezone, taskFilter, noResultPrint, resultPath)
+	identity := cfg.GetIdentity()
+	elector, err := leader.NewElector(cfg, identity, ctx.Clock)
+	if err != nil {
+		return fmt.Errorf("failed to create leader election: %v", err)
 	}
+	ctx.Leadership = leader.NewLeadership(elector, identity, ctx.Clock, ctx.Logger)
+	return nil
 }
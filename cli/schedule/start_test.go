@@ -1,10 +1,21 @@
 package schedule
 
 import (
+	"bytes"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/cli/flags"
 	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/leader"
+	"github.com/alexandreh2ag/go-task/types"
 	"github.com/jonboulle/clockwork"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -14,32 +25,38 @@ func TestGetScheduleStartCmd_SuccessWithEmptyScheduledTasks(t *testing.T) {
 	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
 	ctx.Clock = fakeClock
 	cmd := GetScheduleStartCmd(ctx)
-	go func() {
-		err := cmd.Execute()
-		assert.NoError(t, err)
-	}()
+	cmd.SetArgs([]string{"--" + Tick, "1m"})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
 
 	fakeClock.BlockUntil(1)
-	fakeClock.Advance(1 * time.Second)
-	time.Sleep(50 * time.Millisecond)
-
+	fakeClock.Advance(1 * time.Minute)
+	fakeClock.BlockUntil(1)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
 }
 
 func TestGetScheduleStartCmd_SuccessWithTaskFilter(t *testing.T) {
 	ctx := context.TestContext(io.Discard)
 	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
 	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "test", Command: fmt.Sprintf("touch %s", output), CronExpr: "* * * * *"},
+		&types.ScheduledTask{Id: "other", Command: "echo", CronExpr: "*/15 * * * * *"},
+	}
 	cmd := GetScheduleStartCmd(ctx)
 	cmd.SetArgs([]string{"test,test2"})
-	go func() {
-		err := cmd.Execute()
-		assert.NoError(t, err)
-	}()
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
 
 	fakeClock.BlockUntil(1)
 	fakeClock.Advance(1 * time.Second)
-	time.Sleep(50 * time.Millisecond)
-
+	assert.Eventually(t, func() bool { _, err := os.Stat(output); return err == nil }, time.Second, 10*time.Millisecond)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+	assert.NotNil(t, ctx.Config.Scheduled[0].LatestTaskResult)
+	assert.Nil(t, ctx.Config.Scheduled[1].LatestTaskResult)
 }
 
 func TestGetScheduleStartCmd_ErrorWithWrongTickDuration(t *testing.T) {
@@ -55,28 +72,235 @@ func TestGetScheduleStartCmd_ErrorWithWrongTickDuration(t *testing.T) {
 	assert.Equal(t, "invalid argument \"m\" for \"--tick\" flag: time: invalid duration \"m\"", err.Error())
 }
 
-func TestGetScheduleStartCmd_ErrorWithTickDurationEqual0(t *testing.T) {
+func TestGetScheduleStartCmd_SuccessWithLeaderElectionWithoutTick(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
+	ctx.Clock = fakeClock
+	ctx.Config.LeaderElection = leader.Config{Type: leader.TypeFile, Path: path.Join(t.TempDir(), "lease")}
+	cmd := GetScheduleStartCmd(ctx)
+	cmd.SetArgs([]string{"--" + LeaderElection})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+
+	// the lease is renewed every minute
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(DefaultLeaderElectionTick)
+	fakeClock.BlockUntil(1)
+	assert.True(t, ctx.Leadership.Status().Leader)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestGetScheduleStartCmd_ErrorWithNegativeTickDuration(t *testing.T) {
 	ctx := context.TestContext(io.Discard)
 	ctx.Clock = clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
 	cmd := GetScheduleStartCmd(ctx)
 	cmd.SetOut(io.Discard)
 	cmd.SetErr(io.Discard)
-	cmd.SetArgs([]string{"--" + Tick, "0m"})
+	cmd.SetArgs([]string{"--" + Tick, "-1s"})
 
 	err := cmd.Execute()
 	assert.Error(t, err)
-	assert.Equal(t, "tick duration must be higher than 0", err.Error())
+	assert.Equal(t, "tick duration must not be negative", err.Error())
 }
 
-func TestGetScheduleStartCmd_ErrorWithTickDurationSecond(t *testing.T) {
+func TestGetScheduleStartCmd_SuccessWithTickDurationSecond(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
+	ctx.Clock = fakeClock
+	cmd := GetScheduleStartCmd(ctx)
+	cmd.SetArgs([]string{"--" + Tick, "15s"})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+
+	fakeClock.BlockUntil(1)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestGetScheduleStartCmd_ErrorWithWrongHealthAddr(t *testing.T) {
 	ctx := context.TestContext(io.Discard)
 	ctx.Clock = clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
 	cmd := GetScheduleStartCmd(ctx)
 	cmd.SetOut(io.Discard)
 	cmd.SetErr(io.Discard)
-	cmd.SetArgs([]string{"--" + Tick, "1s"})
+	cmd.SetArgs([]string{"--" + HealthAddr, "wrong"})
 
 	err := cmd.Execute()
-	assert.Error(t, err)
-	assert.Equal(t, "tick duration must be only in minutes", err.Error())
+	assert.ErrorContains(t, err, "failed to listen health address wrong")
+}
+
+func TestGetScheduleStartCmd_SuccessWithDryRunOpt(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
+	ctx.Clock = fakeClock
+	lease := path.Join(t.TempDir(), "lease")
+	ctx.Config.LeaderElection = leader.Config{Type: leader.TypeFile, Path: lease}
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "test", Command: "touch " + output, CronExpr: "* * * * *"},
+	}
+	cmd := GetScheduleStartCmd(ctx)
+	out := &syncBuffer{}
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--" + flags.DryRun, "--" + LeaderElection, "--" + flags.WorkingDir, "/app/test"})
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(1 * time.Second)
+	assert.Eventually(t, func() bool { return strings.Contains(out.String(), "Task test would run at ") }, time.Second, 10*time.Millisecond)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+	_, err := os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(lease)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGetScheduleStartCmd_ErrorWithLeaderElectionWithoutConfig(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Clock = clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
+	cmd := GetScheduleStartCmd(ctx)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--" + LeaderElection})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "leader election needs a leader_election config")
+}
+
+func Test_prepareLeaderElection(t *testing.T) {
+	leasePath := path.Join(t.TempDir(), "lease")
+	tests := []struct {
+		name    string
+		cfg     leader.Config
+		wantErr string
+	}{
+		{
+			name: "SuccessWithDefaultTTL",
+			cfg:  leader.Config{Type: leader.TypeFile, Path: leasePath},
+		},
+		{
+			name:    "ErrorTTLLowerThanTick",
+			cfg:     leader.Config{Type: leader.TypeFile, Path: leasePath, TTL: time.Minute},
+			wantErr: "leader election ttl (1m0s) must be higher than tick (5m0s)",
+		},
+		{
+			name:    "ErrorWrongKubernetes",
+			cfg:     leader.Config{Type: leader.TypeKubernetes},
+			wantErr: "failed to create leader election: kubernetes config is missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			ctx.Config.LeaderElection = tt.cfg
+			err := prepareLeaderElection(ctx, 5*time.Minute)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, ctx.Leadership)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, ctx.Leadership)
+			assert.True(t, ctx.Leadership.Check())
+		})
+	}
+}
+
+func TestGetScheduleStartCmd_ErrorWithNegativeReloadWatch(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	cmd := GetScheduleStartCmd(ctx)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--" + ReloadWatch, "-1s"})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "reload watch duration must not be negative")
+}
+
+func Test_reloadFn(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "Success",
+			content: "defaults:\n  scheduled: {timeout: 5m}\nscheduled:\n- {id: 'test', command: 'fake', expr: '0 0 * * *'}\n- {id: 'other', command: 'fake', expr: '0 1 * * *', timeout: 0, jitter: 0}\n",
+		},
+		{
+			name:    "ErrorNotValid",
+			content: "scheduled:\n- {id: 'test', expr: 'wrong'}\n",
+			wantErr: "configuration file is not valid",
+		},
+		{
+			name:    "ErrorPrepare",
+			content: "scheduled:\n- {id: 'test', command: 'fake', expr: '0 0 * * *', exclude_calendars: ['wrong']}\n",
+			wantErr: "task test excludes unknown calendar wrong",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			ctx.Config.Scheduled = types.ScheduledTasks{
+				&types.ScheduledTask{Id: "test", Command: "echo", CronExpr: "* * * * *"},
+			}
+			fsFake := afero.NewMemMapFs()
+			viper.Reset()
+			viper.SetFs(fsFake)
+			_ = afero.WriteFile(fsFake, "/app/tasks.yml", []byte(tt.content), 0644)
+			viper.SetConfigFile("/app/tasks.yml")
+
+			cmd := GetScheduleStartCmd(ctx)
+			_ = cmd.ParseFlags([]string{"-w", "/app", "-e", "FOO=bar"})
+			got, err := reloadFn(ctx, cmd)()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotSame(t, ctx, got)
+			assert.Len(t, ctx.Config.Scheduled, 1)
+			assert.Len(t, got.Config.Scheduled, 2)
+			assert.Equal(t, "/app", got.Config.Scheduled[0].Directory)
+			assert.Equal(t, "bar", got.Config.Scheduled[0].Envs["FOO"])
+			assert.Equal(t, 5*time.Minute, got.Config.Scheduled[0].GetTimeout())
+			// a task disables the default timeout and jitter with 0
+			assert.Equal(t, time.Duration(0), got.Config.Scheduled[1].GetTimeout())
+			assert.NotNil(t, got.Config.Scheduled[1].Timeout)
+			assert.NotNil(t, got.Config.Scheduled[1].Jitter)
+			assert.Equal(t, ctx.Clock, got.Clock)
+		})
+	}
+}
+
+func TestGetScheduleStartCmd_ErrorWithNegativeShutdownTimeout(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	cmd := GetScheduleStartCmd(ctx)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--" + ShutdownTimeout, "-1s"})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "shutdown timeout must not be negative")
+}
+
+// syncBuffer is a bytes.Buffer safe for the writes of the scheduler goroutine and the reads of the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
 }
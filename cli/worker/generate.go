@@ -47,8 +47,6 @@ func GetWorkerGenerateCmd(ctx *context.Context) *cobra.Command {
 func GetWorkerGenerateRunFn(ctx *context.Context) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		format, _ := cmd.Flags().GetString(Format)
-		user, _ := cmd.Flags().GetString(flags.User)
-		workingDir, _ := cmd.Flags().GetString(flags.WorkingDir)
 		outputPath, _ := cmd.Flags().GetString(OutputPath)
 		groupName, _ := cmd.Flags().GetString(flags.GroupName)
 
@@ -57,7 +55,13 @@ func GetWorkerGenerateRunFn(ctx *context.Context) func(*cobra.Command, []string)
 		if groupName == "" || outputPath == "" {
 			return fmt.Errorf("missing mandatory arguments (--%s, --%s)", OutputPath, flags.GroupName)
 		}
-		types.PrepareWorkerTasks(ctx.Config.Workers, groupName, user, workingDir, envVars)
+		defaults := ctx.Config.Defaults.Workers
+		defaults.User = flags.GetStringOrDefault(cmd, flags.User, defaults.User)
+		defaults.Directory = flags.GetStringOrDefault(cmd, flags.WorkingDir, defaults.Directory)
+		err := types.PrepareWorkerTasks(ctx.Fs, ctx.Config.Workers, groupName, defaults, envVars, ctx.Config.InheritEnv, ctx.Config.EnvFile)
+		if err != nil {
+			return err
+		}
 		ctx.Logger.Info(fmt.Sprintf("Generate format type %s", generate.FormatSupervisor))
 
 		return generate.Generate(ctx, outputPath, format, groupName)
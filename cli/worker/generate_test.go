@@ -52,3 +52,25 @@ func TestGetWorkerGenerateCmd_MissingArgs(t *testing.T) {
 	assert.NotEqual(t, err, nil)
 	assert.Equal(t, true, strings.Contains(err.Error(), "missing mandatory arguments"))
 }
+
+func TestGetWorkerGenerateCmd_Defaults(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	_ = ctx.Fs.MkdirAll("/tmp/subdir", 0755)
+	ctx.Config.Defaults.Workers = types.WorkerDefaults{Directory: "/srv", User: "app", Envs: map[string]string{"FOO": "default", "BAR": "default"}}
+	ctx.Config.Workers = types.WorkerTasks{
+		{Id: "test", Command: "fake"},
+		{Id: "test2", Command: "ping", User: "test2", Directory: "/tmp/dir", Envs: map[string]string{"BAR": "task"}},
+	}
+
+	cmd := GetWorkerGenerateCmd(ctx)
+	cmd.SetArgs([]string{"--" + flags.GroupName, "test", "--" + OutputPath, "/tmp/subdir/output.txt", "-u", "flag", "-e", "FOO=flag"})
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	assert.Equal(t, "/srv", ctx.Config.Workers[0].Directory)
+	assert.Equal(t, "flag", ctx.Config.Workers[0].User)
+	assert.Equal(t, "flag", ctx.Config.Workers[0].Envs["FOO"])
+	assert.Equal(t, "default", ctx.Config.Workers[0].Envs["BAR"])
+	assert.Equal(t, "/tmp/dir", ctx.Config.Workers[1].Directory)
+	assert.Equal(t, "test2", ctx.Config.Workers[1].User)
+	assert.Equal(t, "task", ctx.Config.Workers[1].Envs["BAR"])
+}
@@ -1,11 +1,37 @@
 package config
 
-import "github.com/alexandreh2ag/go-task/types"
+import (
+	"github.com/alexandreh2ag/go-task/calendar"
+	"github.com/alexandreh2ag/go-task/heartbeat"
+	"github.com/alexandreh2ag/go-task/jitter"
+	"github.com/alexandreh2ag/go-task/leader"
+	"github.com/alexandreh2ag/go-task/lock"
+	"github.com/alexandreh2ag/go-task/notify"
+	"github.com/alexandreh2ag/go-task/secret"
+	"github.com/alexandreh2ag/go-task/types"
+)
 
 type Config struct {
 	//LogLevel string `mapstructure:"log_level"`
-	Workers   types.WorkerTasks    `mapstructure:"workers" validate:"omitempty,required,unique=Id,dive"`
-	Scheduled types.ScheduledTasks `mapstructure:"scheduled" validate:"omitempty,required,unique=Id,dive"`
+	InheritEnv     any                  `mapstructure:"inherit_env" validate:"omitempty,inherit-env"`
+	EnvFile        []string             `mapstructure:"env_file" validate:"omitempty,dive,required"`
+	Defaults       Defaults             `mapstructure:"defaults"`
+	Workers        types.WorkerTasks    `mapstructure:"workers" validate:"omitempty,required,unique=Id,dive"`
+	Scheduled      types.ScheduledTasks `mapstructure:"scheduled" validate:"omitempty,required,unique=Id,depends-on-exists,depends-on-acyclic,dive"`
+	Notifications  notify.Targets       `mapstructure:"notifications" validate:"omitempty,unique=Id,dive"`
+	Heartbeat      heartbeat.Config     `mapstructure:"heartbeat"`
+	Lock           lock.Config          `mapstructure:"lock"`
+	LeaderElection leader.Config        `mapstructure:"leader_election"`
+	EveryEpoch     string               `mapstructure:"every_epoch" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Jitter         jitter.Config        `mapstructure:"jitter"`
+	Calendars      calendar.Configs     `mapstructure:"calendars" validate:"omitempty,unique=Id,dive"`
+	Secrets        secret.Config        `mapstructure:"secrets"`
+}
+
+// Defaults are the values of the tasks which do not define their own.
+type Defaults struct {
+	Workers   types.WorkerDefaults    `mapstructure:"workers"`
+	Scheduled types.ScheduledDefaults `mapstructure:"scheduled"`
 }
 
 func NewConfig() Config {
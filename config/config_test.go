@@ -1,11 +1,15 @@
 package config
 
 import (
+	"github.com/alexandreh2ag/go-task/calendar"
+	"github.com/alexandreh2ag/go-task/lock"
+	"github.com/alexandreh2ag/go-task/notify"
+	"github.com/alexandreh2ag/go-task/secret"
 	"github.com/alexandreh2ag/go-task/types"
 	gtaskValidator "github.com/alexandreh2ag/go-task/validator"
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -20,7 +24,7 @@ func TestDefaultConfig(t *testing.T) {
 
 func Test_ConfigWorkers_SuccessValidateEmpty(t *testing.T) {
 	cfg := DefaultConfig()
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	err := validate.Struct(cfg)
 
 	assert.NoError(t, err)
@@ -76,3 +80,238 @@ func Test_ConfigWorkers_ErrorValidateDuplicateId(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Error:Field validation for 'Workers' failed on the 'unique' tag")
 }
+
+func Test_ConfigScheduled_ErrorValidateDependsOn(t *testing.T) {
+	validate := gtaskValidator.New()
+
+	cfg := DefaultConfig()
+	cfg.Scheduled = types.ScheduledTasks{
+		{Id: "export", CronExpr: "0 2 * * *", Command: "fake"},
+		{Id: "transform", Command: "fake", DependsOn: []string{"export", "wrong"}},
+	}
+	err := validate.Struct(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Error:Field validation for 'Scheduled' failed on the 'depends-on-exists' tag")
+
+	cfg.Scheduled = types.ScheduledTasks{
+		{Id: "export", CronExpr: "0 2 * * *", Command: "fake", DependsOn: []string{"upload"}},
+		{Id: "transform", Command: "fake", DependsOn: []string{"export"}},
+		{Id: "upload", Command: "fake", DependsOn: []string{"transform"}},
+	}
+	err = validate.Struct(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Error:Field validation for 'Scheduled' failed on the 'depends-on-acyclic' tag")
+}
+
+func Test_ConfigNotifications_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	tests := []struct {
+		name          string
+		notifications notify.Targets
+		wantErr       []string
+	}{
+		{
+			name: "SuccessWebhookAndSmtp",
+			notifications: notify.Targets{
+				{Id: "hook", Type: notify.TypeWebhook, Url: "https://example.com/hook"},
+				{Id: "mail", Type: notify.TypeSmtp, Smtp: &notify.SmtpConfig{Host: "localhost", Port: 25, From: "gtask@example.com"}},
+			},
+		},
+		{
+			name: "ErrorMissingUrlAndSmtp",
+			notifications: notify.Targets{
+				{Id: "hook", Type: notify.TypeSlack},
+				{Id: "mail", Type: notify.TypeSmtp},
+			},
+			wantErr: []string{
+				"Config.Notifications[0].Url' Error:Field validation for 'Url' failed on the 'required_unless' tag",
+				"Config.Notifications[1].Smtp' Error:Field validation for 'Smtp' failed on the 'required_if' tag",
+			},
+		},
+		{
+			name: "ErrorWrongSmtp",
+			notifications: notify.Targets{
+				{Id: "mail", Type: notify.TypeSmtp, Smtp: &notify.SmtpConfig{Host: "localhost", Port: 0, From: "wrong"}},
+			},
+			wantErr: []string{
+				"Config.Notifications[0].Smtp.Port' Error:Field validation for 'Port' failed on the 'required' tag",
+				"Config.Notifications[0].Smtp.From' Error:Field validation for 'From' failed on the 'email' tag",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Notifications = tt.notifications
+			err := validate.Struct(cfg)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, wantErr := range tt.wantErr {
+				assert.Contains(t, err.Error(), wantErr)
+			}
+		})
+	}
+}
+
+func Test_ConfigEveryEpoch_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	cfg := DefaultConfig()
+	cfg.EveryEpoch = "2023-01-25T15:00:00+01:00"
+	assert.NoError(t, validate.Struct(cfg))
+
+	cfg.EveryEpoch = "2023-01-25 15:00"
+	err := validate.Struct(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Config.EveryEpoch' Error:Field validation for 'EveryEpoch' failed on the 'datetime' tag")
+}
+
+func Test_ConfigInheritEnv_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	cfg := DefaultConfig()
+	cfg.InheritEnv = []any{"PATH", "TZ"}
+	cfg.Scheduled = types.ScheduledTasks{{Id: "test", CronExpr: "* * * * *", Command: "fake", InheritEnv: false}}
+	assert.NoError(t, validate.Struct(cfg))
+
+	cfg.InheritEnv = 5
+	cfg.Workers = types.WorkerTasks{{Id: "test", Command: "fake", InheritEnv: []any{"PATH", 5}}}
+	err := validate.Struct(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Config.InheritEnv' Error:Field validation for 'InheritEnv' failed on the 'inherit-env' tag")
+	assert.Contains(t, err.Error(), "Config.Workers[0].InheritEnv' Error:Field validation for 'InheritEnv' failed on the 'inherit-env' tag")
+}
+
+func Test_ConfigDefaults_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	cfg := DefaultConfig()
+	cfg.Defaults = Defaults{
+		Workers:   types.WorkerDefaults{Directory: "/app/", User: "app", Envs: map[string]string{"FOO": "bar"}},
+		Scheduled: types.ScheduledDefaults{Directory: "/app/", User: "app", Timeout: time.Minute},
+	}
+	assert.NoError(t, validate.Struct(cfg))
+
+	cfg.Defaults.Workers.User = "wrong user"
+	cfg.Defaults.Scheduled.Timeout = -time.Minute
+	err := validate.Struct(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Config.Defaults.Workers.User' Error:Field validation for 'User' failed on the 'alphanum' tag")
+	assert.Contains(t, err.Error(), "Config.Defaults.Scheduled.Timeout' Error:Field validation for 'Timeout' failed on the 'min' tag")
+}
+
+func Test_ConfigSecrets_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	cfg := DefaultConfig()
+	cfg.Secrets = secret.Config{Vault: &secret.VaultConfig{Address: "https://vault.example.com:8200", KvVersion: 2}}
+	assert.NoError(t, validate.Struct(cfg))
+
+	cfg.Secrets = secret.Config{Vault: &secret.VaultConfig{Address: "wrong", KvVersion: 3}}
+	err := validate.Struct(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Config.Secrets.Vault.Address' Error:Field validation for 'Address' failed on the 'url' tag")
+	assert.Contains(t, err.Error(), "Config.Secrets.Vault.KvVersion' Error:Field validation for 'KvVersion' failed on the 'oneof' tag")
+}
+
+func Test_ConfigLock_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	tests := []struct {
+		name    string
+		lock    lock.Config
+		wantErr []string
+	}{
+		{
+			name: "SuccessWithoutLock",
+			lock: lock.Config{},
+		},
+		{
+			name: "SuccessRedis",
+			lock: lock.Config{Type: lock.TypeRedis, TTL: time.Minute, Redis: &lock.RedisConfig{Address: "localhost:6379"}},
+		},
+		{
+			name: "ErrorMissingPath",
+			lock: lock.Config{Type: lock.TypeDirectory},
+			wantErr: []string{
+				"Config.Lock.Path' Error:Field validation for 'Path' failed on the 'required_if' tag",
+			},
+		},
+		{
+			name: "ErrorWrongType",
+			lock: lock.Config{Type: "wrong"},
+			wantErr: []string{
+				"Config.Lock.Type' Error:Field validation for 'Type' failed on the 'oneof' tag",
+			},
+		},
+		{
+			name: "ErrorWrongRedis",
+			lock: lock.Config{Type: lock.TypeRedis, Redis: &lock.RedisConfig{Address: "localhost"}},
+			wantErr: []string{
+				"Config.Lock.Redis.Address' Error:Field validation for 'Address' failed on the 'hostname_port' tag",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Lock = tt.lock
+			err := validate.Struct(cfg)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, wantErr := range tt.wantErr {
+				assert.Contains(t, err.Error(), wantErr)
+			}
+		})
+	}
+}
+
+func Test_ConfigCalendars_Validate(t *testing.T) {
+	validate := gtaskValidator.New()
+	tests := []struct {
+		name      string
+		calendars calendar.Configs
+		wantErr   []string
+	}{
+		{
+			name: "Success",
+			calendars: calendar.Configs{
+				{Id: "holidays", Dates: []string{"2023-12-25"}, Ics: "/etc/gtask/holidays.ics", Timezone: "Europe/Paris"},
+				{Id: "business", Ranges: []calendar.RangeConfig{{Weekdays: []string{"mon", "fri"}, From: "09:00", To: "18:00"}}},
+			},
+		},
+		{
+			name:      "ErrorDuplicateId",
+			calendars: calendar.Configs{{Id: "holidays"}, {Id: "holidays"}},
+			wantErr:   []string{"Config.Calendars' Error:Field validation for 'Calendars' failed on the 'unique' tag"},
+		},
+		{
+			name: "ErrorWrongRules",
+			calendars: calendar.Configs{
+				{Id: "holidays", Dates: []string{"25/12/2023"}, Timezone: "Europe/Wrong", Ranges: []calendar.RangeConfig{{Weekdays: []string{"monday"}, From: "9h"}}},
+			},
+			wantErr: []string{
+				"Config.Calendars[0].Timezone' Error:Field validation for 'Timezone' failed on the 'timezone' tag",
+				"Config.Calendars[0].Dates[0]' Error:Field validation for 'Dates[0]' failed on the 'datetime' tag",
+				"Config.Calendars[0].Ranges[0].Weekdays[0]' Error:Field validation for 'Weekdays[0]' failed on the 'oneof' tag",
+				"Config.Calendars[0].Ranges[0].From' Error:Field validation for 'From' failed on the 'datetime' tag",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Calendars = tt.calendars
+			err := validate.Struct(cfg)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, wantErr := range tt.wantErr {
+				assert.Contains(t, err.Error(), wantErr)
+			}
+		})
+	}
+}
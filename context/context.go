@@ -1,7 +1,12 @@
 package context
 
 import (
+	stdContext "context"
+	"github.com/alexandreh2ag/go-task/calendar"
 	"github.com/alexandreh2ag/go-task/config"
+	"github.com/alexandreh2ag/go-task/leader"
+	"github.com/alexandreh2ag/go-task/lock"
+	"github.com/alexandreh2ag/go-task/notify"
 	"github.com/jonboulle/clockwork"
 	"github.com/spf13/afero"
 	"io"
@@ -10,21 +15,30 @@ import (
 	"os"
 )
 
+// Context wraps the standard context of the app: its cancellation stops the daemon and interrupts the running commands.
 type Context struct {
-	Logger   *slog.Logger
-	LogLevel *slog.LevelVar
-	Config   *config.Config
-	Clock    clockwork.Clock
-	Fs       afero.Fs
-	done     chan bool
+	stdContext.Context
+	Logger     *slog.Logger
+	LogLevel   *slog.LevelVar
+	Config     *config.Config
+	Clock      clockwork.Clock
+	Fs         afero.Fs
+	Notifier   *notify.Dispatcher
+	Locker     lock.Locker
+	Leadership *leader.Leadership
+	Calendars  calendar.Calendars
+	cancel     stdContext.CancelFunc
 }
 
 func (c *Context) Cancel() {
-	c.done <- true
+	c.cancel()
 }
 
-func (c *Context) Done() <-chan bool {
-	return c.done
+// WithContext returns a copy of the app context with a standard context derived from parent, canceled by Cancel of the copy.
+func (c *Context) WithContext(parent stdContext.Context) *Context {
+	copied := *c
+	copied.Context, copied.cancel = stdContext.WithCancel(parent)
+	return &copied
 }
 
 func DefaultContext() *Context {
@@ -32,13 +46,15 @@ func DefaultContext() *Context {
 	level := &slog.LevelVar{}
 	level.Set(slog.LevelInfo)
 	opts := &slog.HandlerOptions{AddSource: false, Level: level}
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
 	return &Context{
+		Context:  ctx,
 		Logger:   slog.New(slog.NewTextHandler(os.Stdout, opts)),
 		LogLevel: level,
 		Config:   &cfg,
 		Clock:    clockwork.NewRealClock(),
 		Fs:       afero.NewOsFs(),
-		done:     make(chan bool),
+		cancel:   cancel,
 	}
 }
 
@@ -50,12 +66,14 @@ func TestContext(logBuffer io.Writer) *Context {
 	level := &slog.LevelVar{}
 	level.Set(slog.LevelInfo)
 	opts := &slog.HandlerOptions{AddSource: false, Level: level}
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
 	return &Context{
+		Context:  ctx,
 		Logger:   slog.New(slog.NewTextHandler(logBuffer, opts)),
 		LogLevel: level,
 		Config:   &cfg,
 		Clock:    clockwork.NewRealClock(),
 		Fs:       afero.NewMemMapFs(),
-		done:     make(chan bool),
+		cancel:   cancel,
 	}
 }
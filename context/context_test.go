@@ -1,6 +1,7 @@
 package context
 
 import (
+	stdContext "context"
 	"github.com/alexandreh2ag/go-task/config"
 	"github.com/alexandreh2ag/go-task/types"
 	"github.com/jonboulle/clockwork"
@@ -32,8 +33,9 @@ func TestDefaultContext(t *testing.T) {
 		Fs:       fs,
 	}
 	got := DefaultContext()
-	assert.NotNil(t, got.done)
-	got.done = nil
+	assert.NotNil(t, got.Context)
+	assert.NotNil(t, got.cancel)
+	got.Context, got.cancel = nil, nil
 	assert.Equal(t, want, got)
 }
 
@@ -56,8 +58,9 @@ func TestTestContext(t *testing.T) {
 		Fs:       fs,
 	}
 	got := TestContext(nil)
-	assert.NotNil(t, got.done)
-	got.done = nil
+	assert.NotNil(t, got.Context)
+	assert.NotNil(t, got.cancel)
+	got.Context, got.cancel = nil, nil
 	assert.Equal(t, want, got)
 }
 
@@ -80,35 +83,34 @@ func TestTestContext_WithLogBuffer(t *testing.T) {
 		Fs:       fs,
 	}
 	got := TestContext(io.Discard)
-	assert.NotNil(t, got.done)
-	got.done = nil
+	assert.NotNil(t, got.Context)
+	assert.NotNil(t, got.cancel)
+	got.Context, got.cancel = nil, nil
 	assert.Equal(t, want, got)
 }
 
 func TestContext_Cancel(t *testing.T) {
-	ctx := &Context{}
-	ctx.done = make(chan bool)
-	running := true
-	go func() {
-		select {
-		case <-ctx.done:
-			running = false
-		}
-	}()
+	ctx := TestContext(nil)
+	assert.NoError(t, ctx.Err())
 	ctx.Cancel()
-	assert.Equal(t, false, running)
+	<-ctx.Done()
+	assert.ErrorIs(t, ctx.Err(), stdContext.Canceled)
 }
 
-func TestContext_Done(t *testing.T) {
-	ctx := &Context{}
-	ctx.done = make(chan bool)
-	running := true
-	go func() {
-		select {
-		case <-ctx.Done():
-			running = false
-		}
-	}()
-	ctx.done <- true
-	assert.Equal(t, false, running)
+func TestContext_WithContext(t *testing.T) {
+	ctx := TestContext(nil)
+	parent, cancelParent := stdContext.WithCancel(stdContext.Background())
+	defer cancelParent()
+
+	copied := ctx.WithContext(parent)
+	assert.Equal(t, ctx.Config, copied.Config)
+	copied.Cancel()
+	assert.ErrorIs(t, copied.Err(), stdContext.Canceled)
+	assert.NoError(t, ctx.Err())
+	assert.NoError(t, parent.Err())
+
+	copied = ctx.WithContext(ctx)
+	ctx.Cancel()
+	<-copied.Done()
+	assert.ErrorIs(t, copied.Err(), stdContext.Canceled)
 }
@@ -0,0 +1,191 @@
+package cron
+
+import (
+	"fmt"
+	"github.com/adhocore/gronx"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	Reboot      = "@reboot"
+	EveryPrefix = "@every "
+)
+
+var descriptorRegex = regexp.MustCompile(`^@(annually|yearly|monthly|weekly|daily|hourly)$`)
+
+// Schedule computes the fire times of an expression with seconds precision.
+type Schedule interface {
+	// Next returns the first fire time after t, false when the schedule does not fire anymore.
+	Next(t time.Time) (time.Time, bool, error)
+	// IsDue returns true when the schedule fires at ref.
+	IsDue(ref time.Time) (bool, error)
+}
+
+// Parse returns the schedule of the expression, anchor is the start of the intervals of @every.
+func Parse(expr string, anchor time.Time) (Schedule, error) {
+	switch {
+	case expr == Reboot:
+		return RebootSchedule{}, nil
+	case strings.HasPrefix(expr, EveryPrefix):
+		interval, err := parseEvery(expr)
+		if err != nil {
+			return nil, err
+		}
+		return &EverySchedule{Interval: interval, Anchor: anchor.Truncate(time.Second)}, nil
+	case descriptorRegex.MatchString(expr):
+		return &CronSchedule{Expr: expr}, nil
+	}
+	// 5 fields (minute first) or 6 fields (second first), 7th field is the year
+	fields := len(strings.Fields(expr))
+	gron := gronx.New()
+	if fields < 5 || fields > 7 || !gron.IsValid(expr) {
+		return nil, fmt.Errorf("invalid cron expression '%s'", expr)
+	}
+	return &CronSchedule{Expr: expr}, nil
+}
+
+// IsValid returns true when the expression can be scheduled.
+func IsValid(expr string) bool {
+	_, err := Parse(expr, time.Time{})
+	return err == nil
+}
+
+func parseEvery(expr string) (time.Duration, error) {
+	interval, err := time.ParseDuration(strings.TrimPrefix(expr, EveryPrefix))
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval of '%s': %v", expr, err)
+	}
+	if interval < time.Second || interval%time.Second != 0 {
+		return 0, fmt.Errorf("interval of '%s' must be a positive number of seconds", expr)
+	}
+	return interval, nil
+}
+
+// CronSchedule fires at the wall clock times of the expression in the location of the given times.
+// On DST changes, the times skipped by the clock fire once at the end of the gap,
+// and the times repeated by the clock fire once, except when the hour is `*` (like cron does).
+type CronSchedule struct {
+	Expr string
+}
+
+func (c *CronSchedule) Next(t time.Time) (time.Time, bool, error) {
+	next, err := c.nextWallClock(t)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if segments, _ := gronx.Segments(c.Expr); len(segments) > 2 && segments[2] == "*" {
+		if repeated, ok := c.nextRepeated(t); ok && repeated.Before(next) {
+			return repeated, true, nil
+		}
+	}
+	return next, true, nil
+}
+
+func (c *CronSchedule) IsDue(ref time.Time) (bool, error) {
+	ref = ref.Truncate(time.Second)
+	// out of the end of a DST gap, a time must match the expression to be due
+	if start, _ := ref.ZoneBounds(); !ref.Equal(start) {
+		gron := gronx.New()
+		if due, err := gron.IsDue(c.Expr, ref); err != nil || !due {
+			return false, err
+		}
+	}
+	next, _, err := c.Next(ref.Add(-time.Second))
+	if err != nil {
+		return false, err
+	}
+	return next.Equal(ref), nil
+}
+
+// nextWallClock returns the first occurrence of the next wall clock time matching the expression after t.
+func (c *CronSchedule) nextWallClock(t time.Time) (time.Time, error) {
+	wall := wallClock(t)
+	for {
+		fire, err := nextTickAfter(c.Expr, wall, false)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if next := firstInstant(fire, t.Location()); next.After(t) {
+			return next, nil
+		}
+		wall = fire
+	}
+}
+
+// nextRepeated returns the next fire time after t in the wall clock times repeated by a DST fall back around t.
+func (c *CronSchedule) nextRepeated(t time.Time) (time.Time, bool) {
+	start, end := t.ZoneBounds()
+	for _, transition := range []time.Time{start, end} {
+		if transition.IsZero() || zoneShift(transition) >= 0 {
+			continue
+		}
+		from := transition
+		if t.After(from) {
+			from = t
+		}
+		fire, err := nextTickAfter(c.Expr, wallClock(from), transition.After(t))
+		if err == nil && fire.Before(wallClock(transition).Add(-zoneShift(transition))) {
+			return from.Add(fire.Sub(wallClock(from))), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// firstInstant returns the first instant of the wall clock time in loc, the end of the gap when a DST spring forward skips it.
+func firstInstant(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+	start, end := t.ZoneBounds()
+	switch {
+	case wallClock(t).After(wall):
+		return start
+	case wallClock(t).Before(wall):
+		return end
+	case !start.IsZero() && zoneShift(start) < 0 && t.Sub(start) < -zoneShift(start):
+		return t.Add(zoneShift(start))
+	}
+	return t
+}
+
+// zoneShift returns the change of UTC offset at the transition.
+func zoneShift(transition time.Time) time.Duration {
+	_, before := transition.Add(-time.Second).Zone()
+	_, after := transition.Zone()
+	return time.Duration(after-before) * time.Second
+}
+
+// wallClock returns the same wall clock time in UTC, where fire times are computed without DST.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// EverySchedule fires every interval from its anchor.
+type EverySchedule struct {
+	Interval time.Duration
+	Anchor   time.Time
+}
+
+func (e *EverySchedule) Next(t time.Time) (time.Time, bool, error) {
+	elapsed := t.Sub(e.Anchor)
+	count := elapsed / e.Interval
+	if elapsed < 0 && elapsed%e.Interval != 0 {
+		count--
+	}
+	return e.Anchor.Add((count + 1) * e.Interval).In(t.Location()), true, nil
+}
+
+func (e *EverySchedule) IsDue(ref time.Time) (bool, error) {
+	return ref.Truncate(time.Second).Sub(e.Anchor)%e.Interval == 0, nil
+}
+
+// RebootSchedule only fires once when the daemon starts.
+type RebootSchedule struct{}
+
+func (RebootSchedule) Next(_ time.Time) (time.Time, bool, error) {
+	return time.Time{}, false, nil
+}
+
+func (RebootSchedule) IsDue(_ time.Time) (bool, error) {
+	return false, nil
+}
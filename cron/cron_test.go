@@ -0,0 +1,310 @@
+package cron
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	anchor := time.Date(2023, time.January, 25, 15, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name    string
+		expr    string
+		want    Schedule
+		wantErr string
+	}{
+		{name: "SuccessCron", expr: "*/5 * * * *", want: &CronSchedule{Expr: "*/5 * * * *"}},
+		{name: "SuccessCronWithSeconds", expr: "*/15 * * * * *", want: &CronSchedule{Expr: "*/15 * * * * *"}},
+		{name: "SuccessCronRangeStep", expr: "0-30/5 * * * *", want: &CronSchedule{Expr: "0-30/5 * * * *"}},
+		{name: "SuccessCronRangeList", expr: "5-10,20 * * * *", want: &CronSchedule{Expr: "5-10,20 * * * *"}},
+		{name: "SuccessCronNthWeekday", expr: "0 12 * * 1#2", want: &CronSchedule{Expr: "0 12 * * 1#2"}},
+		{name: "SuccessCronNames", expr: "0 9 * JAN MON-FRI", want: &CronSchedule{Expr: "0 9 * JAN MON-FRI"}},
+		{name: "SuccessCronLastDay", expr: "0 0 L * *", want: &CronSchedule{Expr: "0 0 L * *"}},
+		{name: "SuccessDescriptor", expr: "@daily", want: &CronSchedule{Expr: "@daily"}},
+		{name: "SuccessReboot", expr: "@reboot", want: RebootSchedule{}},
+		{
+			name: "SuccessEvery",
+			expr: "@every 1m30s",
+			want: &EverySchedule{Interval: 90 * time.Second, Anchor: time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC)},
+		},
+		{name: "ErrorEveryWrongDuration", expr: "@every 90", wantErr: "invalid interval of '@every 90': time: missing unit in duration \"90\""},
+		{name: "ErrorEveryMilliseconds", expr: "@every 1500ms", wantErr: "interval of '@every 1500ms' must be a positive number of seconds"},
+		{name: "ErrorEveryZero", expr: "@every 0s", wantErr: "interval of '@every 0s' must be a positive number of seconds"},
+		{name: "ErrorUnsupportedDescriptor", expr: "@always", wantErr: "invalid cron expression '@always'"},
+		{name: "ErrorWrongExpr", expr: "wrong * * * *", wantErr: "invalid cron expression 'wrong * * * *'"},
+		{name: "ErrorOutOfRange", expr: "70 * * * * *", wantErr: "invalid cron expression '70 * * * * *'"},
+		{name: "ErrorTooFewFields", expr: "* * * *", wantErr: "invalid cron expression '* * * *'"},
+		{name: "ErrorTooManyFields", expr: "* * * * * * * *", wantErr: "invalid cron expression '* * * * * * * *'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.expr, anchor)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.False(t, IsValid(tt.expr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, IsValid(tt.expr))
+		})
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	schedule := &CronSchedule{Expr: "*/15 * * * * *"}
+	next, ok, err := schedule.Next(time.Date(2023, time.January, 25, 15, 0, 10, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.January, 25, 15, 0, 15, 0, time.UTC), next)
+
+	due, err := schedule.IsDue(next)
+	assert.NoError(t, err)
+	assert.True(t, due)
+
+	// second Monday of the month
+	next, ok, err = (&CronSchedule{Expr: "0 12 * * 1#2"}).Next(time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.February, 13, 12, 0, 0, 0, time.UTC), next)
+
+	_, ok, err = (&CronSchedule{Expr: "0 0 30 2 *"}).Next(next)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		from    time.Time
+		want    time.Time
+		wantErr string
+	}{
+		{
+			name: "SuccessSkippedDaysStartAtMidnight",
+			expr: "*/5 * * * 1-5",
+			from: time.Date(2023, time.March, 25, 23, 50, 0, 0, time.UTC),
+			want: time.Date(2023, time.March, 27, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "SuccessSkippedHoursStartAtMinuteZero",
+			expr: "*/10 9-17 * * *",
+			from: time.Date(2023, time.March, 25, 17, 55, 0, 0, time.UTC),
+			want: time.Date(2023, time.March, 26, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "SuccessYear",
+			expr: "0 0 0 1 1 * 2030",
+			from: time.Date(2023, time.March, 25, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "ErrorNeverFires",
+			expr:    "0 0 30 2 *",
+			from:    time.Date(2023, time.March, 25, 12, 0, 0, 0, time.UTC),
+			wantErr: "no fire time of '0 0 30 2 *' in 100 years",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := (&CronSchedule{Expr: tt.expr}).Next(tt.from)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.False(t, ok)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEverySchedule(t *testing.T) {
+	anchor := time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC)
+	schedule := &EverySchedule{Interval: 90 * time.Second, Anchor: anchor}
+	tests := []struct {
+		name    string
+		t       time.Time
+		want    time.Time
+		wantDue bool
+	}{
+		{name: "SuccessAtAnchor", t: anchor, want: anchor.Add(90 * time.Second), wantDue: true},
+		{name: "SuccessAfterAnchor", t: anchor.Add(100 * time.Second), want: anchor.Add(180 * time.Second)},
+		{name: "SuccessAtFireTime", t: anchor.Add(180 * time.Second), want: anchor.Add(270 * time.Second), wantDue: true},
+		{name: "SuccessBeforeAnchor", t: anchor.Add(-100 * time.Second), want: anchor.Add(-90 * time.Second)},
+		{name: "SuccessBeforeAnchorAtFireTime", t: anchor.Add(-90 * time.Second), want: anchor, wantDue: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok, err := schedule.Next(tt.t)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, next)
+			due, err := schedule.IsDue(tt.t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDue, due)
+		})
+	}
+}
+
+func TestRebootSchedule(t *testing.T) {
+	_, ok, err := RebootSchedule{}.Next(time.Now())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	due, err := RebootSchedule{}.IsDue(time.Now())
+	assert.NoError(t, err)
+	assert.False(t, due)
+}
+
+func TestCronSchedule_DST(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	newYork, _ := time.LoadLocation("America/New_York")
+	lordHowe, _ := time.LoadLocation("Australia/Lord_Howe")
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "SuccessSpringForwardRunsAtEndOfGap",
+			expr: "30 2 * * *",
+			from: time.Date(2023, time.March, 25, 12, 0, 0, 0, paris),
+			want: []time.Time{
+				time.Date(2023, time.March, 26, 1, 0, 0, 0, time.UTC),
+				time.Date(2023, time.March, 27, 0, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessFallBackRunsOnce",
+			expr: "30 2 * * *",
+			from: time.Date(2023, time.October, 28, 12, 0, 0, 0, paris),
+			want: []time.Time{
+				time.Date(2023, time.October, 29, 0, 30, 0, 0, time.UTC),
+				time.Date(2023, time.October, 30, 1, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessFallBackRunsOnceFromRepeatedHour",
+			expr: "30 2 * * *",
+			from: time.Date(2023, time.October, 29, 0, 45, 0, 0, time.UTC).In(paris),
+			want: []time.Time{
+				time.Date(2023, time.October, 30, 1, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessFallBackWildcardHourRunsInRepeatedHour",
+			expr: "0 * * * *",
+			from: time.Date(2023, time.October, 28, 23, 30, 0, 0, time.UTC).In(paris),
+			want: []time.Time{
+				time.Date(2023, time.October, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, time.October, 29, 1, 0, 0, 0, time.UTC),
+				time.Date(2023, time.October, 29, 2, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessSpringForwardNewYork",
+			expr: "30 2 * * *",
+			from: time.Date(2023, time.March, 11, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2023, time.March, 12, 7, 0, 0, 0, time.UTC),
+				time.Date(2023, time.March, 13, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessFallBackNewYork",
+			expr: "30 1 * * *",
+			from: time.Date(2023, time.November, 4, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2023, time.November, 5, 5, 30, 0, 0, time.UTC),
+				time.Date(2023, time.November, 6, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessSpringForwardHalfHourShift",
+			expr: "15 2 * * *",
+			from: time.Date(2023, time.September, 30, 12, 0, 0, 0, lordHowe),
+			want: []time.Time{
+				time.Date(2023, time.September, 30, 15, 30, 0, 0, time.UTC),
+				time.Date(2023, time.October, 1, 15, 15, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "SuccessOutsideGap",
+			expr: "30 3 * * *",
+			from: time.Date(2023, time.March, 25, 12, 0, 0, 0, paris),
+			want: []time.Time{
+				time.Date(2023, time.March, 26, 1, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &CronSchedule{Expr: tt.expr}
+			got := []time.Time{}
+			next := tt.from
+			for range tt.want {
+				var ok bool
+				var err error
+				next, ok, err = schedule.Next(next)
+				assert.NoError(t, err)
+				assert.True(t, ok)
+				got = append(got, next.UTC())
+			}
+			assert.Equal(t, tt.want, got)
+
+			// IsDue agrees with Next minute by minute
+			due := []time.Time{}
+			for ref := tt.from.Truncate(time.Minute); ref.Before(tt.want[len(tt.want)-1].Add(time.Minute)); ref = ref.Add(time.Minute) {
+				if ok, err := schedule.IsDue(ref); assert.NoError(t, err) && ok && ref.After(tt.from) {
+					due = append(due, ref.UTC())
+				}
+			}
+			assert.Equal(t, tt.want, due)
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr string
+	}{
+		{expr: "*/5 * * * 1-5", want: "every 5 minutes, Monday to Friday"},
+		{expr: "* * * * *", want: "every minute"},
+		{expr: "0 12 * * *", want: "every day at 12:00"},
+		{expr: "30 0 9 * * *", want: "every day at 09:00:30"},
+		{expr: "15 */2 * * *", want: "at minute 15, every 2 hours"},
+		{expr: "0 9-17 * * 1-5", want: "at minute 0, hours 9 to 17, Monday to Friday"},
+		{expr: "10-20 3 * * *", want: "minutes 10 to 20, at hour 3"},
+		{expr: "5,35 * * * *", want: "minutes 5, 35"},
+		{expr: "*/15 * * * * *", want: "every 15 seconds"},
+		{expr: "*/1 * * * * *", want: "every second"},
+		{expr: "0 0 * * 0,6", want: "on Sunday, Saturday at 00:00"},
+		{expr: "0 0 1 * *", want: "on day 1 of the month at 00:00"},
+		{expr: "0 0 1,15 1,7 *", want: "on days 1, 15 of the month, in January, July at 00:00"},
+		{expr: "0 0 */2 * *", want: "every 2 days at 00:00"},
+		{expr: "0 8 * 6-8 *", want: "in June to August at 08:00"},
+		{expr: "0 0 1 1 * 2030", want: "on day 1 of the month, in January, in 2030 at 00:00"},
+		{expr: "@weekly", want: "every week on Sunday at 00:00"},
+		{expr: "@every 1m30s", want: "every 1m30s"},
+		{expr: "@reboot", want: "when the daemon starts"},
+		{expr: "@every 1ms", wantErr: "interval of '@every 1ms' must be a positive number of seconds"},
+		{expr: "wrong", wantErr: "invalid cron expression 'wrong'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Describe(tt.expr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
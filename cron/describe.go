@@ -0,0 +1,149 @@
+package cron
+
+import (
+	"fmt"
+	"github.com/adhocore/gronx"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "every year on January 1 at 00:00",
+	"@annually": "every year on January 1 at 00:00",
+	"@monthly":  "every month on day 1 at 00:00",
+	"@weekly":   "every week on Sunday at 00:00",
+	"@daily":    "every day at 00:00",
+	"@hourly":   "every hour",
+	Reboot:      "when the daemon starts",
+}
+
+// Describe returns a description of the expression in english, like "every 5 minutes, Monday to Friday".
+func Describe(expr string) (string, error) {
+	if description, ok := descriptors[expr]; ok {
+		return description, nil
+	}
+	if strings.HasPrefix(expr, EveryPrefix) {
+		interval, err := parseEvery(expr)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("every %s", interval), nil
+	}
+	if !IsValid(expr) {
+		return "", fmt.Errorf("invalid cron expression '%s'", expr)
+	}
+	segments, err := gronx.Segments(expr)
+	if err != nil {
+		return "", err
+	}
+	second, minute, hour := segments[0], segments[1], segments[2]
+
+	days := []string{}
+	if segments[3] != "*" {
+		days = append(days, describeDayOfMonth(segments[3]))
+	}
+	if segments[5] != "*" {
+		days = append(days, describeDayOfWeek(segments[5]))
+	}
+	if segments[4] != "*" {
+		days = append(days, "in "+describeField(segments[4], "month", monthName))
+	}
+	if len(segments) == 7 && segments[6] != "*" {
+		days = append(days, "in "+describeList(segments[6], strconv.Itoa))
+	}
+
+	if isNumber(second) && isNumber(minute) && isNumber(hour) {
+		at := fmt.Sprintf("at %02s:%02s", hour, minute)
+		if second != "0" {
+			at += fmt.Sprintf(":%02s", second)
+		}
+		if len(days) == 0 {
+			return "every day " + at, nil
+		}
+		return strings.Join(days, ", ") + " " + at, nil
+	}
+
+	times := []string{}
+	if second != "0" {
+		times = append(times, describeField(second, "second", strconv.Itoa))
+	}
+	if minute != "*" || second == "0" {
+		times = append(times, describeField(minute, "minute", strconv.Itoa))
+	}
+	if hour != "*" {
+		times = append(times, describeField(hour, "hour", strconv.Itoa))
+	}
+	return strings.Join(append(times, days...), ", "), nil
+}
+
+func describeDayOfMonth(value string) string {
+	switch {
+	case strings.HasPrefix(value, "*/"):
+		return describeField(value, "day", strconv.Itoa)
+	case isNumber(value):
+		return fmt.Sprintf("on day %s of the month", value)
+	}
+	return fmt.Sprintf("on days %s of the month", describeList(value, strconv.Itoa))
+}
+
+func describeDayOfWeek(value string) string {
+	if strings.HasPrefix(value, "*/") || strings.Contains(value, "-") {
+		return describeField(value, "day of week", weekdayName)
+	}
+	return "on " + describeField(value, "day of week", weekdayName)
+}
+
+// describeField describes a segment made of numbers, ranges, steps and lists.
+func describeField(value string, unit string, name func(int) string) string {
+	if value == "*" {
+		return "every " + unit
+	}
+	if step, found := strings.CutPrefix(value, "*/"); found {
+		if step == "1" {
+			return "every " + unit
+		}
+		return fmt.Sprintf("every %s %ss", step, unit)
+	}
+	list := describeList(value, name)
+	switch {
+	case unit == "day of week" || unit == "month":
+		return list
+	case isNumber(value):
+		return fmt.Sprintf("at %s %s", unit, list)
+	}
+	return fmt.Sprintf("%ss %s", unit, list)
+}
+
+func describeList(value string, name func(int) string) string {
+	names := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if from, to, found := strings.Cut(item, "-"); found {
+			names = append(names, fmt.Sprintf("%s to %s", nameOf(from, name), nameOf(to, name)))
+			continue
+		}
+		names = append(names, nameOf(item, name))
+	}
+	return strings.Join(names, ", ")
+}
+
+func nameOf(value string, name func(int) string) string {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return value
+	}
+	return name(number)
+}
+
+func weekdayName(day int) string {
+	return time.Weekday(day % 7).String()
+}
+
+func monthName(month int) string {
+	return time.Month(month).String()
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
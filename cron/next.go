@@ -0,0 +1,63 @@
+package cron
+
+import (
+	"fmt"
+	"github.com/adhocore/gronx"
+	"strings"
+	"time"
+)
+
+// MaxYears bounds the search of the next fire time of an expression.
+const MaxYears = 100
+
+// nextTickAfter returns the first time matching the expression after t (or at t when inclusive), t must be in UTC.
+// Unlike gronx.NextTickAfter, which keeps the time of the day when it skips days,
+// it skips the years, months, days, hours and minutes which do not match, then starts them from their beginning.
+func nextTickAfter(expr string, t time.Time, inclusive bool) (time.Time, error) {
+	segments, err := gronx.Segments(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	year := []string{}
+	if len(segments) == 7 {
+		year = segments[6:]
+	}
+	levels := []struct {
+		expr string
+		skip func(time.Time) time.Time
+	}{
+		{expr: fields([]string{"*", "*", "*", "*", "*", "*"}, year), skip: func(t time.Time) time.Time { return time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC) }},
+		{expr: fields([]string{"*", "*", "*", "*", segments[4], "*"}, year), skip: func(t time.Time) time.Time { return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC) }},
+		{expr: fields([]string{"*", "*", "*", segments[3], segments[4], segments[5]}, year), skip: func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC) }},
+		{expr: fields([]string{"*", "*", segments[2], segments[3], segments[4], segments[5]}, year), skip: func(t time.Time) time.Time { return t.Truncate(time.Hour).Add(time.Hour) }},
+		{expr: fields([]string{"*", segments[1], segments[2], segments[3], segments[4], segments[5]}, year), skip: func(t time.Time) time.Time { return t.Truncate(time.Minute).Add(time.Minute) }},
+		{expr: fields(segments[:6], year), skip: func(t time.Time) time.Time { return t.Add(time.Second) }},
+	}
+
+	gron := gronx.New()
+	t = t.Truncate(time.Second)
+	if !inclusive {
+		t = t.Add(time.Second)
+	}
+	limit := t.AddDate(MaxYears, 0, 0)
+	for t.Before(limit) {
+		due := true
+		for _, level := range levels {
+			if due, err = gron.IsDue(level.expr, t); err != nil {
+				return time.Time{}, err
+			}
+			if !due {
+				t = level.skip(t)
+				break
+			}
+		}
+		if due {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no fire time of '%s' in %d years", expr, MaxYears)
+}
+
+func fields(segments []string, year []string) string {
+	return strings.Join(append(append([]string{}, segments...), year...), " ")
+}
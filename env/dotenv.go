@@ -0,0 +1,111 @@
+package env
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFiles reads the dotenv files in order, the variables of a file replace the ones of the previous files.
+// The relative paths are relative to dir.
+func LoadFiles(fs afero.Fs, dir string, paths []string) (map[string]string, error) {
+	envs := map[string]string{}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		content, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file %s: %v", path, err)
+		}
+		fileEnvs, err := ParseDotenv(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file %s: %v", path, err)
+		}
+		for key, value := range fileEnvs {
+			envs[key] = value
+		}
+	}
+	return envs, nil
+}
+
+// ParseDotenv parses KEY=value lines with an optional export prefix and # comments.
+// Single quoted values are kept as is, double quoted values support \n, \t, \" and \\ escapes, both can span several lines.
+func ParseDotenv(content string) (map[string]string, error) {
+	envs := map[string]string{}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	line := 1
+	for len(content) > 0 {
+		var current string
+		current, content, _ = strings.Cut(content, "\n")
+		start := line
+		line++
+
+		current = strings.TrimSpace(current)
+		if current == "" || strings.HasPrefix(current, "#") {
+			continue
+		}
+		current = strings.TrimSpace(strings.TrimPrefix(current, "export "))
+		key, value, found := strings.Cut(current, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t\"'") {
+			return nil, fmt.Errorf("invalid line %d: %s", start, current)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			// an unquoted value ends at an inline comment
+			if i := strings.Index(value, " #"); i != -1 {
+				value = value[:i]
+			}
+			envs[key] = strings.TrimSpace(value)
+			continue
+		}
+
+		quote := value[0]
+		value = value[1:]
+		for {
+			end := closingQuote(value, quote)
+			if end != -1 {
+				rest := strings.TrimSpace(value[end+1:])
+				if rest != "" && !strings.HasPrefix(rest, "#") {
+					return nil, fmt.Errorf("invalid line %d: unexpected %s after the value of %s", start, rest, key)
+				}
+				value = value[:end]
+				break
+			}
+			if content == "" {
+				return nil, fmt.Errorf("invalid line %d: the value of %s is not closed", start, key)
+			}
+			var next string
+			next, content, _ = strings.Cut(content, "\n")
+			line++
+			value += "\n" + next
+		}
+		if quote == '"' {
+			value = unescape(value)
+		}
+		envs[key] = value
+	}
+	return envs, nil
+}
+
+// closingQuote returns the index of the quote which closes the value, the escaped double quotes are skipped.
+func closingQuote(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescape(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+	return replacer.Replace(value)
+}
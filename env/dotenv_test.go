@@ -0,0 +1,89 @@
+package env
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "SuccessEmpty",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name:    "SuccessSimple",
+			content: "# comment\n\nFOO=bar\r\nexport BAR = baz qux \nEMPTY=\nURL=http://host/#anchor # comment\n",
+			want:    map[string]string{"FOO": "bar", "BAR": "baz qux", "EMPTY": "", "URL": "http://host/#anchor"},
+		},
+		{
+			name:    "SuccessQuotes",
+			content: "SINGLE='it is $HOME \\n' # comment\nDOUBLE=\"say \\\"hi\\\"\\tnow\\n\"\nHASH=\"a # b\"",
+			want:    map[string]string{"SINGLE": "it is $HOME \\n", "DOUBLE": "say \"hi\"\tnow\n", "HASH": "a # b"},
+		},
+		{
+			name:    "SuccessMultiLine",
+			content: "KEY=\"-----BEGIN-----\nline\n-----END-----\"\nNEXT='a\n\nb'\nLAST=1",
+			want:    map[string]string{"KEY": "-----BEGIN-----\nline\n-----END-----", "NEXT": "a\n\nb", "LAST": "1"},
+		},
+		{
+			name:    "ErrorNoEqual",
+			content: "FOO=bar\nwrong\n",
+			wantErr: "invalid line 2: wrong",
+		},
+		{
+			name:    "ErrorWrongKey",
+			content: "MY KEY=bar",
+			wantErr: "invalid line 1: MY KEY=bar",
+		},
+		{
+			name:    "ErrorNotClosed",
+			content: "FOO=bar\nKEY=\"value\nnext line\n",
+			wantErr: "invalid line 2: the value of KEY is not closed",
+		},
+		{
+			name:    "ErrorAfterQuote",
+			content: "KEY='value' wrong",
+			wantErr: "invalid line 1: unexpected wrong after the value of KEY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(tt.content)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/app/.env", []byte("FOO=app\nBAR=app\n"), 0644)
+	_ = afero.WriteFile(fs, "/etc/gtask/.env", []byte("BAR=global\nBAZ=global\n"), 0644)
+	_ = afero.WriteFile(fs, "/app/wrong.env", []byte("wrong"), 0644)
+
+	got, err := LoadFiles(fs, "/app", []string{"/etc/gtask/.env", ".env"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FOO": "app", "BAR": "app", "BAZ": "global"}, got)
+
+	got, err = LoadFiles(fs, "/app", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{}, got)
+
+	_, err = LoadFiles(fs, "/app", []string{"missing.env"})
+	assert.ErrorContains(t, err, "failed to read env file /app/missing.env")
+
+	_, err = LoadFiles(fs, "/app", []string{"wrong.env"})
+	assert.EqualError(t, err, "failed to parse env file /app/wrong.env: invalid line 1: wrong")
+}
@@ -37,6 +37,19 @@ func EvalAll(envs map[string]string) map[string]string {
 	return evaluatedEnvs
 }
 
+// WithDefaults adds to envs the variables of defaults it does not define.
+func WithDefaults(envs map[string]string, defaults map[string]string) map[string]string {
+	if envs == nil {
+		envs = map[string]string{}
+	}
+	for key, value := range defaults {
+		if _, ok := envs[key]; !ok {
+			envs[key] = value
+		}
+	}
+	return envs
+}
+
 func GetEnvs() map[string]string {
 	envHasMap := map[string]string{}
 
@@ -0,0 +1,62 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseInherit reads a value of inherit_env: true (the default when nil) to inherit every variable of gtask,
+// false to inherit none of them or the list of the variables to inherit.
+func ParseInherit(value any) (bool, []string, error) {
+	switch value := value.(type) {
+	case nil:
+		return true, nil, nil
+	case bool:
+		return value, nil, nil
+	case string:
+		if all, err := strconv.ParseBool(value); err == nil {
+			return all, nil, nil
+		}
+		return false, splitList(value), nil
+	case []string:
+		return false, value, nil
+	case []any:
+		allowlist := make([]string, 0, len(value))
+		for _, item := range value {
+			key, ok := item.(string)
+			if !ok || key == "" {
+				return false, nil, fmt.Errorf("inherit_env contains an invalid variable name %v", item)
+			}
+			allowlist = append(allowlist, key)
+		}
+		return false, allowlist, nil
+	default:
+		return false, nil, fmt.Errorf("inherit_env must be true, false or a list of variables, got %v", value)
+	}
+}
+
+// Inherited returns the variables of envs which are inherited according to the value of inherit_env.
+func Inherited(envs map[string]string, inherit any) map[string]string {
+	all, allowlist, _ := ParseInherit(inherit)
+	if all {
+		return envs
+	}
+	inherited := map[string]string{}
+	for _, key := range allowlist {
+		if value, ok := envs[key]; ok {
+			inherited[key] = value
+		}
+	}
+	return inherited
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			list = append(list, key)
+		}
+	}
+	return list
+}
@@ -0,0 +1,47 @@
+package env
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseInherit(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         any
+		wantAll       bool
+		wantAllowlist []string
+		wantErr       string
+	}{
+		{name: "SuccessDefault", value: nil, wantAll: true},
+		{name: "SuccessTrue", value: true, wantAll: true},
+		{name: "SuccessFalse", value: false, wantAll: false},
+		{name: "SuccessStringFalse", value: "false", wantAll: false},
+		{name: "SuccessStringList", value: "PATH, TZ", wantAllowlist: []string{"PATH", "TZ"}},
+		{name: "SuccessStrings", value: []string{"PATH"}, wantAllowlist: []string{"PATH"}},
+		{name: "SuccessList", value: []any{"PATH", "HOME"}, wantAllowlist: []string{"PATH", "HOME"}},
+		{name: "SuccessEmptyList", value: []any{}, wantAllowlist: []string{}},
+		{name: "ErrorListItem", value: []any{"PATH", 5}, wantErr: "inherit_env contains an invalid variable name 5"},
+		{name: "ErrorType", value: 5, wantErr: "inherit_env must be true, false or a list of variables, got 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, allowlist, err := ParseInherit(tt.value)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAll, all)
+			assert.Equal(t, tt.wantAllowlist, allowlist)
+		})
+	}
+}
+
+func TestInherited(t *testing.T) {
+	envs := map[string]string{"PATH": "/bin", "HOME": "/root", "SECRET": "foo"}
+	assert.Equal(t, envs, Inherited(envs, nil))
+	assert.Equal(t, envs, Inherited(envs, true))
+	assert.Equal(t, map[string]string{}, Inherited(envs, false))
+	assert.Equal(t, map[string]string{"PATH": "/bin"}, Inherited(envs, []any{"PATH", "TZ"}))
+}
@@ -15,6 +15,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -23,8 +25,14 @@ import (
 
 const (
 	FormatSupervisor = "supervisor"
+	// EnvCommand clears the environment of supervisord for the workers which do not inherit it.
+	EnvCommand = "/usr/bin/env"
+	// ShellCommand passes the variables kept by EnvCommand by reference, their values are not written in the command.
+	ShellCommand = "/bin/sh"
 )
 
+var varNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 func Generate(ctx *context.Context, outputPath string, format string, groupName string) error {
 	err := checkDir(ctx, outputPath)
 	if err != nil {
@@ -77,6 +85,7 @@ func templateSupervisorFile(ctx *context.Context, writer io.Writer, groupName st
 		"groupName": func() string { return groupName },
 		"programs":  generateProgramList,
 		"envs":      generateEnvVars,
+		"command":   generateCommand,
 	}
 
 	tmpl, err := template.New("supervisor.tmpl").Funcs(extraVars).Parse(string(supervisorTemplateContent))
@@ -121,6 +130,29 @@ func generateEnvVars(worker types.WorkerTask) string {
 	return strings.Join(envVars, ",")
 }
 
+// generateCommand runs the command with only its environments and the variables of supervisord listed by inherit_env
+// when the worker does not inherit the whole environment of supervisord.
+// The shell copies them by name when the worker starts: their values are read from the environment of supervisord
+// and are neither written in the file nor taken from the environment of gtask.
+func generateCommand(worker types.WorkerTask) string {
+	all, allowlist, _ := env.ParseInherit(worker.InheritEnv)
+	if all {
+		return worker.Command
+	}
+
+	names := append(maps.Keys(worker.Envs), allowlist...)
+	sort.Strings(names)
+	refs := []string{"exec", EnvCommand, "-i"}
+	for _, name := range slices.Compact(names) {
+		// a shell can only reference the variables with a valid name
+		if varNameRegex.MatchString(name) {
+			refs = append(refs, fmt.Sprintf(`${%s+"%s=$%s"}`, name, name, name))
+		}
+	}
+	refs = append(refs, `"$@"`)
+	return fmt.Sprintf("%s -c '%s' %s %s", ShellCommand, strings.Join(refs, " "), filepath.Base(ShellCommand), worker.Command)
+}
+
 func deleteFile(ctx *context.Context, path string) error {
 	err := ctx.Fs.Remove(path)
 	if err != nil {
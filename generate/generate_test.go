@@ -217,6 +217,39 @@ func TestTemplateSupervisorFile_OK(t *testing.T) {
 	assert.Equal(t, expectedOutput, buffer.String())
 }
 
+func TestTemplateSupervisorFile_WithoutInheritEnv(t *testing.T) {
+	t.Setenv("GTASK_TESTING_PATH", "/usr/bin")
+	t.Setenv("GTASK_TESTING_SECRET", "secret")
+	ctx := context.TestContext(io.Discard)
+	groupName := "test-group"
+	ctx.Config.Workers = types.WorkerTasks{
+		{
+			Id:         "test",
+			Command:    "fake --flag",
+			GroupName:  groupName,
+			User:       "toto",
+			Directory:  "/tmp/dir",
+			Envs:       map[string]string{"FOO": "BAR"},
+			InheritEnv: []any{"GTASK_TESTING_PATH"},
+		},
+	}
+
+	expectedOutput := "[group:test-group]\n" +
+		"programs=test-group-test\n\n\n" +
+		"[program:test-group-test]\n" +
+		"directory = /tmp/dir\n" +
+		"autorestart = true\n" +
+		"autostart = true\n" +
+		"user = toto\n" +
+		"command = /bin/sh -c 'exec /usr/bin/env -i ${FOO+\"FOO=$FOO\"} ${GTASK_TESTING_PATH+\"GTASK_TESTING_PATH=$GTASK_TESTING_PATH\"} \"$@\"' sh fake --flag\n" +
+		"environment = FOO=\"BAR\"\n"
+
+	buffer := bytes.NewBufferString("")
+	err := templateSupervisorFile(ctx, buffer, groupName)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOutput, buffer.String())
+}
+
 func TestTemplateSupervisorFile_Eval_Fail(t *testing.T) {
 	ctx := context.TestContext(io.Discard)
 	groupName := "test-group"
@@ -387,3 +420,27 @@ func TestGenerateEnvVars(t *testing.T) {
 	_ = os.Unsetenv("MY_VAR")
 
 }
+
+func TestGenerateCommand(t *testing.T) {
+	t.Setenv("GTASK_TESTING_TZ", "UTC")
+	tests := []struct {
+		name       string
+		inheritEnv any
+		want       string
+	}{
+		{name: "InheritByDefault", inheritEnv: nil, want: "fake %(process_num)s"},
+		{name: "Inherit", inheritEnv: true, want: "fake %(process_num)s"},
+		{name: "WithoutInherit", inheritEnv: false, want: "/bin/sh -c 'exec /usr/bin/env -i ${FOO+\"FOO=$FOO\"} \"$@\"' sh fake %(process_num)s"},
+		{
+			name:       "WithAllowlist",
+			inheritEnv: []any{"GTASK_TESTING_TZ", "GTASK_TESTING_MISSING", "FOO", "WRONG-NAME"},
+			want:       "/bin/sh -c 'exec /usr/bin/env -i ${FOO+\"FOO=$FOO\"} ${GTASK_TESTING_MISSING+\"GTASK_TESTING_MISSING=$GTASK_TESTING_MISSING\"} ${GTASK_TESTING_TZ+\"GTASK_TESTING_TZ=$GTASK_TESTING_TZ\"} \"$@\"' sh fake %(process_num)s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker := types.WorkerTask{Id: "test", Command: "fake %(process_num)s", Envs: map[string]string{"FOO": "BAR"}, InheritEnv: tt.inheritEnv}
+			assert.Equal(t, tt.want, generateCommand(worker))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Graph maps each node to the list of nodes it depends on.
+type Graph map[string][]string
+
+// Missing returns the dependencies which are not nodes of the graph (format: "node -> dependency").
+func (g Graph) Missing() []string {
+	missing := []string{}
+	for _, node := range g.nodes() {
+		for _, dependency := range g[node] {
+			if _, ok := g[dependency]; !ok {
+				missing = append(missing, fmt.Sprintf("%s -> %s", node, dependency))
+			}
+		}
+	}
+	return missing
+}
+
+// FindCycle returns the nodes of the first cycle found (the first node is repeated at the end) or nil.
+func (g Graph) FindCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		path = append(path, node)
+		for _, dependency := range g[node] {
+			switch state[dependency] {
+			case visiting:
+				start := slices.Index(path, dependency)
+				return append(slices.Clone(path[start:]), dependency)
+			case unvisited:
+				if _, ok := g[dependency]; !ok {
+					continue
+				}
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for _, node := range g.nodes() {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Dependents returns the roots and every node which depends on them, directly or not.
+func (g Graph) Dependents(roots []string) []string {
+	result := slices.Clone(roots)
+	for i := 0; i < len(result); i++ {
+		for _, node := range g.nodes() {
+			if slices.Contains(g[node], result[i]) && !slices.Contains(result, node) {
+				result = append(result, node)
+			}
+		}
+	}
+	return result
+}
+
+func (g Graph) nodes() []string {
+	nodes := make([]string, 0, len(g))
+	for node := range g {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGraph_Missing(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph Graph
+		want  []string
+	}{
+		{
+			name:  "SuccessEmpty",
+			graph: Graph{},
+			want:  []string{},
+		},
+		{
+			name:  "SuccessNoMissing",
+			graph: Graph{"a": {}, "b": {"a"}},
+			want:  []string{},
+		},
+		{
+			name:  "SuccessWithMissing",
+			graph: Graph{"a": {"wrong"}, "b": {"a", "other"}},
+			want:  []string{"a -> wrong", "b -> other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.graph.Missing())
+		})
+	}
+}
+
+func TestGraph_FindCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph Graph
+		want  []string
+	}{
+		{
+			name:  "SuccessEmpty",
+			graph: Graph{},
+			want:  nil,
+		},
+		{
+			name:  "SuccessChain",
+			graph: Graph{"export": {}, "transform": {"export"}, "upload": {"transform"}},
+			want:  nil,
+		},
+		{
+			name:  "SuccessDiamond",
+			graph: Graph{"a": {}, "b": {"a"}, "c": {"a"}, "d": {"b", "c"}},
+			want:  nil,
+		},
+		{
+			name:  "SuccessMissingIgnored",
+			graph: Graph{"a": {"wrong"}},
+			want:  nil,
+		},
+		{
+			name:  "ErrorSelfCycle",
+			graph: Graph{"a": {"a"}},
+			want:  []string{"a", "a"},
+		},
+		{
+			name:  "ErrorCycle",
+			graph: Graph{"a": {"c"}, "b": {"a"}, "c": {"b"}, "d": {}},
+			want:  []string{"a", "c", "b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.graph.FindCycle())
+		})
+	}
+}
+
+func TestGraph_Dependents(t *testing.T) {
+	g := Graph{"export": {}, "transform": {"export"}, "upload": {"transform"}, "report": {"export"}, "other": {}}
+	tests := []struct {
+		name  string
+		roots []string
+		want  []string
+	}{
+		{
+			name:  "SuccessNoRoots",
+			roots: []string{},
+			want:  []string{},
+		},
+		{
+			name:  "SuccessLeaf",
+			roots: []string{"upload"},
+			want:  []string{"upload"},
+		},
+		{
+			name:  "SuccessTransitive",
+			roots: []string{"export"},
+			want:  []string{"export", "report", "transform", "upload"},
+		},
+		{
+			name:  "SuccessMultipleRoots",
+			roots: []string{"other", "transform"},
+			want:  []string{"other", "transform", "upload"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, g.Dependents(tt.roots))
+		})
+	}
+}
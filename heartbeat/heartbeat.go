@@ -0,0 +1,122 @@
+package heartbeat
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/jonboulle/clockwork"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultAttempts   = 3
+	DefaultRetryDelay = time.Second
+
+	// MaxBodySize is the limit of the output sent in the body, only the end of the output is kept.
+	MaxBodySize = 100000
+)
+
+type Config struct {
+	Timeout    time.Duration `mapstructure:"timeout" validate:"omitempty,min=0"`
+	Attempts   int           `mapstructure:"attempts" validate:"omitempty,min=0"`
+	RetryDelay time.Duration `mapstructure:"retry_delay" validate:"omitempty,min=0"`
+}
+
+type Pinger struct {
+	client     *http.Client
+	clock      clockwork.Clock
+	attempts   int
+	retryDelay time.Duration
+}
+
+func NewPinger(cfg Config, clock clockwork.Clock) *Pinger {
+	pinger := &Pinger{
+		client:     &http.Client{Timeout: cfg.Timeout},
+		clock:      clock,
+		attempts:   cfg.Attempts,
+		retryDelay: cfg.RetryDelay,
+	}
+	if cfg.Timeout == 0 {
+		pinger.client.Timeout = DefaultTimeout
+	}
+	if pinger.attempts == 0 {
+		pinger.attempts = DefaultAttempts
+	}
+	if cfg.RetryDelay == 0 {
+		pinger.retryDelay = DefaultRetryDelay
+	}
+	return pinger
+}
+
+// Start signals that the task begins (<url>/start).
+func (p *Pinger) Start(task *types.ScheduledTask) {
+	if task.HeartbeatUrl == "" {
+		return
+	}
+	p.ping(task, StartUrl(task.HeartbeatUrl), nil)
+}
+
+// Finish signals the end of the task with its exit code (<url>/<code>) or <url>/fail
+// when no exit code is available. The output of the task is sent in the body.
+// A skipped run is not signaled, like it was not started.
+func (p *Pinger) Finish(result *types.TaskResult) {
+	task := result.Task
+	if task.HeartbeatUrl == "" || result.Status == types.Skipped {
+		return
+	}
+	p.ping(task, FinishUrl(task.HeartbeatUrl, result), Body(result))
+}
+
+func (p *Pinger) ping(task *types.ScheduledTask, url string, body []byte) {
+	var err error
+	for attempt := 1; attempt <= p.attempts; attempt++ {
+		if err = p.send(url, body); err == nil {
+			task.Logger.Debug(fmt.Sprintf("heartbeat %s sent for task %s", url, task.Id))
+			return
+		}
+		if attempt < p.attempts {
+			p.clock.Sleep(p.retryDelay)
+		}
+	}
+	task.Logger.Error(fmt.Sprintf("failed to send heartbeat %s for task %s after %d attempt(s): %v", url, task.Id, p.attempts, err))
+}
+
+func (p *Pinger) send(url string, body []byte) error {
+	resp, err := p.client.Post(url, "text/plain; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func StartUrl(baseUrl string) string {
+	return strings.TrimSuffix(baseUrl, "/") + "/start"
+}
+
+func FinishUrl(baseUrl string, result *types.TaskResult) string {
+	baseUrl = strings.TrimSuffix(baseUrl, "/")
+	if result.Status == types.Succeed {
+		return baseUrl + "/0"
+	}
+	if code := result.ExitCode(); code > 0 {
+		return fmt.Sprintf("%s/%d", baseUrl, code)
+	}
+	return baseUrl + "/fail"
+}
+
+func Body(result *types.TaskResult) []byte {
+	body := result.Output.Bytes()
+	if len(body) > MaxBodySize {
+		body = body[len(body)-MaxBodySize:]
+	}
+	return body
+}
@@ -0,0 +1,166 @@
+package heartbeat
+
+import (
+	"bytes"
+	"errors"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type pingRecorder struct {
+	mu       sync.Mutex
+	paths    []string
+	bodies   []string
+	failures int
+}
+
+func (p *pingRecorder) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		p.paths = append(p.paths, r.URL.Path)
+		p.bodies = append(p.bodies, string(body))
+		if p.failures > 0 {
+			p.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func exitError(t *testing.T, code string) error {
+	err := exec.Command("sh", "-c", "exit "+code).Run()
+	assert.Error(t, err)
+	return err
+}
+
+func TestNewPinger(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	pinger := NewPinger(Config{}, clock)
+	assert.Equal(t, DefaultTimeout, pinger.client.Timeout)
+	assert.Equal(t, DefaultAttempts, pinger.attempts)
+	assert.Equal(t, DefaultRetryDelay, pinger.retryDelay)
+
+	pinger = NewPinger(Config{Timeout: time.Second, Attempts: 1, RetryDelay: time.Minute}, clock)
+	assert.Equal(t, time.Second, pinger.client.Timeout)
+	assert.Equal(t, 1, pinger.attempts)
+	assert.Equal(t, time.Minute, pinger.retryDelay)
+}
+
+func TestFinishUrl(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *types.TaskResult
+		want   string
+	}{
+		{
+			name:   "SuccessSucceed",
+			result: &types.TaskResult{Status: types.Succeed},
+			want:   "https://hc-ping.com/uuid/0",
+		},
+		{
+			name:   "SuccessFailedWithExitCode",
+			result: &types.TaskResult{Status: types.Failed, Error: exitError(t, "3")},
+			want:   "https://hc-ping.com/uuid/3",
+		},
+		{
+			name:   "SuccessFailedWithoutExitCode",
+			result: &types.TaskResult{Status: types.Failed, Error: errors.New("not found")},
+			want:   "https://hc-ping.com/uuid/fail",
+		},
+		{
+			name:   "SuccessTimedOut",
+			result: &types.TaskResult{Status: types.TimedOut, Error: errors.New("killed")},
+			want:   "https://hc-ping.com/uuid/fail",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FinishUrl("https://hc-ping.com/uuid/", tt.result))
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	assert.Equal(t, []byte("output"), Body(&types.TaskResult{Status: types.Succeed, Output: *bytes.NewBufferString("output")}))
+
+	long := strings.Repeat("a", MaxBodySize) + "end"
+	body := Body(&types.TaskResult{Status: types.Succeed, Output: *bytes.NewBufferString(long)})
+	assert.Len(t, body, MaxBodySize)
+	assert.True(t, strings.HasSuffix(string(body), "end"))
+}
+
+func TestPinger_StartAndFinish(t *testing.T) {
+	recorder := &pingRecorder{}
+	server := recorder.server(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	task := &types.ScheduledTask{Id: "test", HeartbeatUrl: server.URL + "/uuid", Logger: logger}
+	pinger := NewPinger(Config{}, clockwork.NewRealClock())
+
+	pinger.Start(task)
+	pinger.Finish(&types.TaskResult{Status: types.Failed, Task: task, Error: exitError(t, "2"), Output: *bytes.NewBufferString("boom")})
+
+	assert.Equal(t, []string{"/uuid/start", "/uuid/2"}, recorder.paths)
+	assert.Equal(t, []string{"", "boom"}, recorder.bodies)
+}
+
+func TestPinger_WithoutUrl(t *testing.T) {
+	recorder := &pingRecorder{}
+	_ = recorder.server(t)
+	task := &types.ScheduledTask{Id: "test"}
+	pinger := NewPinger(Config{}, clockwork.NewRealClock())
+
+	pinger.Start(task)
+	pinger.Finish(&types.TaskResult{Status: types.Succeed, Task: task})
+
+	assert.Empty(t, recorder.paths)
+}
+
+func TestPinger_FinishSkipped(t *testing.T) {
+	recorder := &pingRecorder{}
+	server := recorder.server(t)
+	task := &types.ScheduledTask{Id: "test", HeartbeatUrl: server.URL + "/uuid"}
+	pinger := NewPinger(Config{}, clockwork.NewRealClock())
+
+	pinger.Finish(&types.TaskResult{Status: types.Skipped, Task: task})
+
+	assert.Empty(t, recorder.paths)
+}
+
+func TestPinger_Retry(t *testing.T) {
+	recorder := &pingRecorder{failures: 2}
+	server := recorder.server(t)
+	b := bytes.NewBufferString("")
+	task := &types.ScheduledTask{Id: "test", HeartbeatUrl: server.URL, Logger: slog.New(slog.NewTextHandler(b, nil))}
+	pinger := NewPinger(Config{RetryDelay: time.Millisecond}, clockwork.NewRealClock())
+
+	pinger.Start(task)
+
+	assert.Equal(t, []string{"/start", "/start", "/start"}, recorder.paths)
+	assert.NotContains(t, b.String(), "failed to send heartbeat")
+}
+
+func TestPinger_ErrorAfterAllAttempts(t *testing.T) {
+	recorder := &pingRecorder{failures: 2}
+	server := recorder.server(t)
+	b := bytes.NewBufferString("")
+	task := &types.ScheduledTask{Id: "test", HeartbeatUrl: server.URL, Logger: slog.New(slog.NewTextHandler(b, nil))}
+	pinger := NewPinger(Config{Attempts: 2, RetryDelay: time.Millisecond}, clockwork.NewRealClock())
+
+	pinger.Finish(&types.TaskResult{Status: types.Succeed, Task: task})
+
+	assert.Equal(t, []string{"/0", "/0"}, recorder.paths)
+	assert.Contains(t, b.String(), "after 2 attempt(s): unexpected status code 503")
+}
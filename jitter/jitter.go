@@ -0,0 +1,33 @@
+package jitter
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Config defines the jitter of the tasks which do not define their own.
+type Config struct {
+	Default       time.Duration `mapstructure:"default" validate:"omitempty,min=0"`
+	Deterministic bool          `mapstructure:"deterministic"`
+}
+
+// Delay returns a delay lower than bound, the same for a host and a task id when deterministic.
+func (c Config) Delay(id string, bound time.Duration) time.Duration {
+	if bound <= 0 {
+		return 0
+	}
+	if c.Deterministic {
+		return time.Duration(Hash(id) % uint64(bound))
+	}
+	return time.Duration(rand.Int63n(int64(bound)))
+}
+
+// Hash returns a hash of the hostname and the task id.
+func Hash(id string) uint64 {
+	hostname, _ := os.Hostname()
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(hostname + "/" + id))
+	return h.Sum64()
+}
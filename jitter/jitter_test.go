@@ -0,0 +1,41 @@
+package jitter
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestConfig_Delay(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		bound time.Duration
+	}{
+		{name: "SuccessRandom", cfg: Config{}, bound: 2 * time.Minute},
+		{name: "SuccessDeterministic", cfg: Config{Deterministic: true}, bound: 2 * time.Minute},
+		{name: "SuccessSmallBound", cfg: Config{Deterministic: true}, bound: time.Nanosecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := tt.cfg.Delay("task", tt.bound)
+				assert.GreaterOrEqual(t, got, time.Duration(0))
+				assert.Less(t, got, tt.bound)
+			}
+		})
+	}
+}
+
+func TestConfig_DelayWithoutBound(t *testing.T) {
+	assert.Equal(t, time.Duration(0), Config{}.Delay("task", 0))
+	assert.Equal(t, time.Duration(0), Config{Deterministic: true}.Delay("task", -time.Second))
+}
+
+func TestConfig_DelayDeterministic(t *testing.T) {
+	cfg := Config{Deterministic: true}
+	bound := time.Hour
+	assert.Equal(t, cfg.Delay("task1", bound), cfg.Delay("task1", bound))
+	assert.Equal(t, time.Duration(Hash("task1")%uint64(bound)), cfg.Delay("task1", bound))
+	assert.NotEqual(t, cfg.Delay("task1", bound), cfg.Delay("task2", bound))
+}
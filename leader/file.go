@@ -0,0 +1,122 @@
+package leader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+type fileLease struct {
+	Holder   string    `json:"holder"`
+	RenewAt  time.Time `json:"renew_at"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// FileElector stores the lease in a file of a shared storage.
+// The lease is read and written under a flock of <path>.lock, it only works on a filesystem with working flock.
+// The lease is written in a temporary file then renamed to never be read partially.
+type FileElector struct {
+	path     string
+	identity string
+	ttl      time.Duration
+	clock    clockwork.Clock
+}
+
+func NewFileElector(path string, identity string, ttl time.Duration, clock clockwork.Clock) *FileElector {
+	return &FileElector{path: path, identity: identity, ttl: ttl, clock: clock}
+}
+
+func (e *FileElector) Campaign() (bool, error) {
+	unlock, err := e.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	lease, err := e.read()
+	if err != nil {
+		return false, err
+	}
+	now := e.clock.Now()
+	if lease != nil && lease.Holder != e.identity && now.Before(lease.ExpireAt) {
+		return false, nil
+	}
+
+	if err = e.write(&fileLease{Holder: e.identity, RenewAt: now, ExpireAt: now.Add(e.ttl)}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (e *FileElector) Resign() error {
+	unlock, err := e.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lease, err := e.read()
+	if err != nil || lease == nil || lease.Holder != e.identity {
+		return err
+	}
+	return e.write(&fileLease{RenewAt: e.clock.Now(), ExpireAt: e.clock.Now()})
+}
+
+// lock takes an exclusive flock on the lock file of the lease, the returned func releases it.
+// The lease itself can't be locked because it is replaced on each write.
+func (e *FileElector) lock() (func(), error) {
+	file, err := os.OpenFile(e.path+".lock", os.O_CREATE|os.O_RDWR, 0o660)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lease lock file: %v", err)
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", file.Name(), err)
+	}
+	// closing the file releases the flock
+	return func() { _ = file.Close() }, nil
+}
+
+func (e *FileElector) read() (*fileLease, error) {
+	content, err := os.ReadFile(e.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease %s: %v", e.path, err)
+	}
+	lease := &fileLease{}
+	if err = json.Unmarshal(content, lease); err != nil {
+		// a corrupted lease is taken again
+		return nil, nil
+	}
+	return lease, nil
+}
+
+func (e *FileElector) write(lease *fileLease) error {
+	content, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(e.path), filepath.Base(e.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write lease %s: %v", e.path, err)
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), e.path)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("failed to write lease %s: %v", e.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package leader
+
+import (
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileElector(t *testing.T) {
+	leasePath := path.Join(t.TempDir(), "leader.lease")
+	clock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC))
+	first := NewFileElector(leasePath, "first", time.Minute, clock)
+	second := NewFileElector(leasePath, "second", time.Minute, clock)
+
+	isLeader, err := first.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+
+	isLeader, err = second.Campaign()
+	assert.NoError(t, err)
+	assert.False(t, isLeader)
+
+	// renewed before expiration
+	clock.Advance(50 * time.Second)
+	isLeader, err = first.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+	clock.Advance(50 * time.Second)
+	isLeader, err = second.Campaign()
+	assert.NoError(t, err)
+	assert.False(t, isLeader)
+
+	// not renewed
+	clock.Advance(time.Minute)
+	isLeader, err = second.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+	isLeader, err = first.Campaign()
+	assert.NoError(t, err)
+	assert.False(t, isLeader)
+
+	// resign
+	assert.NoError(t, first.Resign())
+	assert.NoError(t, second.Resign())
+	isLeader, err = first.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+}
+
+func TestFileElector_CorruptedLease(t *testing.T) {
+	leasePath := path.Join(t.TempDir(), "leader.lease")
+	assert.NoError(t, os.WriteFile(leasePath, []byte("wrong"), 0o600))
+	elector := NewFileElector(leasePath, "first", time.Minute, clockwork.NewFakeClock())
+
+	isLeader, err := elector.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+}
+
+func TestFileElector_ConcurrentCampaign(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	for round := 0; round < 20; round++ {
+		leasePath := path.Join(t.TempDir(), "leader.lease")
+		var leaders atomic.Int32
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(identity string) {
+				defer wg.Done()
+				elector := NewFileElector(leasePath, identity, time.Minute, clock)
+				<-start
+				isLeader, err := elector.Campaign()
+				assert.NoError(t, err)
+				if isLeader {
+					leaders.Add(1)
+				}
+			}(fmt.Sprintf("replica-%d", i))
+		}
+		close(start)
+		wg.Wait()
+		assert.Equal(t, int32(1), leaders.Load())
+	}
+}
+
+func TestFileElector_CampaignWaitsLock(t *testing.T) {
+	leasePath := path.Join(t.TempDir(), "leader.lease")
+	clock := clockwork.NewFakeClock()
+	other := NewFileElector(leasePath, "other", time.Minute, clock)
+	unlock, err := other.lock()
+	assert.NoError(t, err)
+
+	campaign := make(chan bool, 1)
+	go func() {
+		isLeader, _ := NewFileElector(leasePath, "first", time.Minute, clock).Campaign()
+		campaign <- isLeader
+	}()
+	// the lease taken while the lock is held is seen by the campaign
+	assert.Never(t, func() bool { return len(campaign) > 0 }, 50*time.Millisecond, 10*time.Millisecond)
+	assert.NoError(t, other.write(&fileLease{Holder: "other", RenewAt: clock.Now(), ExpireAt: clock.Now().Add(time.Minute)}))
+	unlock()
+	assert.False(t, <-campaign)
+}
+
+func TestFileElector_Error(t *testing.T) {
+	elector := NewFileElector(path.Join(t.TempDir(), "wrong", "leader.lease"), "first", time.Minute, clockwork.NewFakeClock())
+
+	isLeader, err := elector.Campaign()
+	assert.False(t, isLeader)
+	assert.ErrorContains(t, err, "failed to open lease lock file")
+}
@@ -0,0 +1,239 @@
+package leader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	DefaultKubernetesCaPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+type KubernetesConfig struct {
+	Host               string `mapstructure:"host" validate:"omitempty,url"`
+	Namespace          string `mapstructure:"namespace" validate:"required"`
+	Name               string `mapstructure:"name" validate:"required"`
+	TokenPath          string `mapstructure:"token_path"`
+	CaPath             string `mapstructure:"ca_path"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+type kubernetesLease struct {
+	ApiVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Metadata   kubernetesLeaseMetadata `json:"metadata"`
+	Spec       kubernetesLeaseSpec     `json:"spec"`
+}
+
+type kubernetesLeaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type kubernetesLeaseSpec struct {
+	HolderIdentity       *string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *string `json:"acquireTime,omitempty"`
+	RenewTime            *string `json:"renewTime,omitempty"`
+	LeaseTransitions     *int    `json:"leaseTransitions,omitempty"`
+}
+
+// KubernetesElector uses a Lease object of the coordination.k8s.io API.
+// Concurrent updates are rejected by the API server thanks to the resource version.
+type KubernetesElector struct {
+	cfg      KubernetesConfig
+	identity string
+	ttl      time.Duration
+	clock    clockwork.Clock
+	client   *http.Client
+}
+
+func NewKubernetesElector(cfg KubernetesConfig, identity string, ttl time.Duration, clock clockwork.Clock) (*KubernetesElector, error) {
+	if cfg.Host == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes host is missing and gtask does not run in a cluster")
+		}
+		cfg.Host = "https://" + net.JoinHostPort(host, port)
+	}
+	if cfg.TokenPath == "" {
+		cfg.TokenPath = DefaultKubernetesTokenPath
+	}
+	if cfg.CaPath == "" {
+		cfg.CaPath = DefaultKubernetesCaPath
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if ca, err := os.ReadFile(cfg.CaPath); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		tlsConfig.RootCAs = pool
+	}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+
+	return &KubernetesElector{cfg: cfg, identity: identity, ttl: ttl, clock: clock, client: client}, nil
+}
+
+func (e *KubernetesElector) Campaign() (bool, error) {
+	lease, found, err := e.get()
+	if err != nil {
+		return false, err
+	}
+	now := e.clock.Now()
+	if !found {
+		lease = &kubernetesLease{
+			ApiVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   kubernetesLeaseMetadata{Name: e.cfg.Name, Namespace: e.cfg.Namespace},
+		}
+		e.hold(lease, now, 0)
+		return e.save(http.MethodPost, e.url(false), lease)
+	}
+
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	if holder != "" && holder != e.identity && !leaseExpired(lease, now) {
+		return false, nil
+	}
+	transitions := 0
+	if lease.Spec.LeaseTransitions != nil {
+		transitions = *lease.Spec.LeaseTransitions
+	}
+	if holder != e.identity {
+		transitions++
+		lease.Spec.AcquireTime = nil
+	}
+	e.hold(lease, now, transitions)
+	return e.save(http.MethodPut, e.url(true), lease)
+}
+
+func (e *KubernetesElector) Resign() error {
+	lease, found, err := e.get()
+	if err != nil || !found {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != e.identity {
+		return nil
+	}
+	holder, duration, renewTime := "", 1, e.clock.Now().UTC().Format(microTimeFormat)
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &renewTime
+	_, err = e.save(http.MethodPut, e.url(true), lease)
+	return err
+}
+
+func (e *KubernetesElector) hold(lease *kubernetesLease, now time.Time, transitions int) {
+	identity := e.identity
+	duration := int(math.Ceil(e.ttl.Seconds()))
+	renewTime := now.UTC().Format(microTimeFormat)
+	lease.Spec.HolderIdentity = &identity
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &renewTime
+	lease.Spec.LeaseTransitions = &transitions
+	if lease.Spec.AcquireTime == nil {
+		acquireTime := renewTime
+		lease.Spec.AcquireTime = &acquireTime
+	}
+}
+
+func leaseExpired(lease *kubernetesLease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	renewTime, err := time.Parse(microTimeFormat, *lease.Spec.RenewTime)
+	if err != nil {
+		return true
+	}
+	return !now.Before(renewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+func (e *KubernetesElector) url(withName bool) string {
+	url := fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", strings.TrimSuffix(e.cfg.Host, "/"), e.cfg.Namespace)
+	if withName {
+		url += "/" + e.cfg.Name
+	}
+	return url
+}
+
+func (e *KubernetesElector) get() (*kubernetesLease, bool, error) {
+	resp, err := e.do(http.MethodGet, e.url(true), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, unexpectedStatus(resp)
+	}
+	lease := &kubernetesLease{}
+	if err = json.NewDecoder(resp.Body).Decode(lease); err != nil {
+		return nil, false, fmt.Errorf("failed to decode lease: %v", err)
+	}
+	return lease, true, nil
+}
+
+// save creates or updates the lease, a conflict means that another replica updated it first.
+func (e *KubernetesElector) save(method string, url string, lease *kubernetesLease) (bool, error) {
+	body, err := json.Marshal(lease)
+	if err != nil {
+		return false, err
+	}
+	resp, err := e.do(method, url, body)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, unexpectedStatus(resp)
+	}
+}
+
+func (e *KubernetesElector) do(method string, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// the token is read at each request because it is rotated by kubelet
+	if token, err := os.ReadFile(e.cfg.TokenPath); err == nil {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call kubernetes API: %v", err)
+	}
+	return resp, nil
+}
+
+func unexpectedStatus(resp *http.Response) error {
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected status code %d from kubernetes API: %s", resp.StatusCode, strings.TrimSpace(string(content)))
+}
@@ -0,0 +1,180 @@
+package leader
+
+import (
+	"encoding/json"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeApiServer stores one lease and rejects updates with a stale resource version like the API server.
+type fakeApiServer struct {
+	mu      sync.Mutex
+	lease   *kubernetesLease
+	version int
+	tokens  []string
+}
+
+func (f *fakeApiServer) start(t *testing.T) *httptest.Server {
+	leasesPath := "/apis/coordination.k8s.io/v1/namespaces/default/leases"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == leasesPath+"/gtask":
+			if f.lease == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(f.lease)
+		case r.Method == http.MethodPost && r.URL.Path == leasesPath:
+			if f.lease != nil {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.save(w, r, http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == leasesPath+"/gtask":
+			lease := &kubernetesLease{}
+			_ = json.NewDecoder(r.Body).Decode(lease)
+			if f.lease == nil || lease.Metadata.ResourceVersion != f.lease.Metadata.ResourceVersion {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.store(w, lease, http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeApiServer) save(w http.ResponseWriter, r *http.Request, status int) {
+	lease := &kubernetesLease{}
+	_ = json.NewDecoder(r.Body).Decode(lease)
+	f.store(w, lease, status)
+}
+
+func (f *fakeApiServer) store(w http.ResponseWriter, lease *kubernetesLease, status int) {
+	f.version++
+	lease.Metadata.ResourceVersion = strconv.Itoa(f.version)
+	f.lease = lease
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(lease)
+}
+
+func (f *fakeApiServer) holder() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.lease.Spec.HolderIdentity
+}
+
+func newTestKubernetesElector(t *testing.T, host string, identity string, clock clockwork.Clock) *KubernetesElector {
+	tokenPath := path.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("token\n"), 0o600))
+	elector, err := NewKubernetesElector(KubernetesConfig{Host: host, Namespace: "default", Name: "gtask", TokenPath: tokenPath}, identity, time.Minute, clock)
+	assert.NoError(t, err)
+	return elector
+}
+
+func TestKubernetesElector(t *testing.T) {
+	apiServer := &fakeApiServer{}
+	server := apiServer.start(t)
+	clock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC))
+	first := newTestKubernetesElector(t, server.URL, "first", clock)
+	second := newTestKubernetesElector(t, server.URL, "second", clock)
+
+	isLeader, err := first.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+	assert.Equal(t, "first", apiServer.holder())
+	assert.Equal(t, 60, *apiServer.lease.Spec.LeaseDurationSeconds)
+	assert.Equal(t, "2023-01-25T02:00:00.000000Z", *apiServer.lease.Spec.AcquireTime)
+	assert.Equal(t, "Bearer token", apiServer.tokens[0])
+
+	isLeader, err = second.Campaign()
+	assert.NoError(t, err)
+	assert.False(t, isLeader)
+
+	clock.Advance(30 * time.Second)
+	isLeader, err = first.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+	assert.Equal(t, "2023-01-25T02:00:00.000000Z", *apiServer.lease.Spec.AcquireTime)
+	assert.Equal(t, "2023-01-25T02:00:30.000000Z", *apiServer.lease.Spec.RenewTime)
+
+	clock.Advance(2 * time.Minute)
+	isLeader, err = second.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+	assert.Equal(t, "second", apiServer.holder())
+	assert.Equal(t, 1, *apiServer.lease.Spec.LeaseTransitions)
+	assert.Equal(t, "2023-01-25T02:02:30.000000Z", *apiServer.lease.Spec.AcquireTime)
+
+	assert.NoError(t, first.Resign())
+	assert.Equal(t, "second", apiServer.holder())
+	assert.NoError(t, second.Resign())
+	assert.Equal(t, "", apiServer.holder())
+
+	isLeader, err = first.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+}
+
+func TestKubernetesElector_Conflict(t *testing.T) {
+	apiServer := &fakeApiServer{}
+	server := apiServer.start(t)
+	clock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC))
+	elector := newTestKubernetesElector(t, server.URL, "first", clock)
+
+	lease, found, err := elector.get()
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Nil(t, lease)
+
+	isLeader, err := elector.Campaign()
+	assert.NoError(t, err)
+	assert.True(t, isLeader)
+	lease, _, err = elector.get()
+	assert.NoError(t, err)
+
+	// another replica updated the lease since the read
+	apiServer.lease.Metadata.ResourceVersion = "100"
+	isLeader, err = elector.save(http.MethodPut, elector.url(true), lease)
+	assert.NoError(t, err)
+	assert.False(t, isLeader)
+}
+
+func TestKubernetesElector_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("leases is forbidden"))
+	}))
+	t.Cleanup(server.Close)
+	elector := newTestKubernetesElector(t, server.URL, "first", clockwork.NewFakeClock())
+
+	isLeader, err := elector.Campaign()
+	assert.False(t, isLeader)
+	assert.EqualError(t, err, "unexpected status code 403 from kubernetes API: leases is forbidden")
+}
+
+func TestNewKubernetesElector_InCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	elector, err := NewKubernetesElector(KubernetesConfig{Namespace: "default", Name: "gtask"}, "first", time.Minute, clockwork.NewFakeClock())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://10.0.0.1:443/apis/coordination.k8s.io/v1/namespaces/default/leases/gtask", elector.url(true))
+	assert.Equal(t, DefaultKubernetesTokenPath, elector.cfg.TokenPath)
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err = NewKubernetesElector(KubernetesConfig{Namespace: "default", Name: "gtask"}, "first", time.Minute, clockwork.NewFakeClock())
+	assert.EqualError(t, err, "kubernetes host is missing and gtask does not run in a cluster")
+}
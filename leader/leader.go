@@ -0,0 +1,125 @@
+package leader
+
+import (
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	TypeFile       = "file"
+	TypeKubernetes = "kubernetes"
+)
+
+type Config struct {
+	Type       string            `mapstructure:"type" validate:"omitempty,oneof=file kubernetes"`
+	Identity   string            `mapstructure:"identity"`
+	TTL        time.Duration     `mapstructure:"ttl" validate:"omitempty,min=0"`
+	Path       string            `mapstructure:"path" validate:"required_if=Type file"`
+	Kubernetes *KubernetesConfig `mapstructure:"kubernetes" validate:"required_if=Type kubernetes,omitempty"`
+}
+
+type Elector interface {
+	// Campaign acquires or renews the lease and returns true when the replica holds it.
+	Campaign() (bool, error)
+	// Resign releases the lease when the replica holds it.
+	Resign() error
+}
+
+func NewElector(cfg Config, identity string, clock clockwork.Clock) (Elector, error) {
+	switch cfg.Type {
+	case TypeFile:
+		return NewFileElector(cfg.Path, identity, cfg.TTL, clock), nil
+	case TypeKubernetes:
+		if cfg.Kubernetes == nil {
+			return nil, fmt.Errorf("kubernetes config is missing")
+		}
+		return NewKubernetesElector(*cfg.Kubernetes, identity, cfg.TTL, clock)
+	default:
+		return nil, fmt.Errorf("unsupported leader election type %s", cfg.Type)
+	}
+}
+
+// GetIdentity returns the identity of the replica in the lease (default: hostname:pid).
+func (c Config) GetIdentity() string {
+	if c.Identity != "" {
+		return c.Identity
+	}
+	return DefaultIdentity()
+}
+
+func DefaultIdentity() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
+// Status is the leadership of the replica exposed by the health output.
+type Status struct {
+	Identity    string    `json:"identity"`
+	Leader      bool      `json:"leader"`
+	LeaderSince time.Time `json:"leader_since,omitempty"`
+	LastCheck   time.Time `json:"last_check,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Leadership keeps the result of the last campaign and logs each change.
+type Leadership struct {
+	elector Elector
+	clock   clockwork.Clock
+	logger  *slog.Logger
+	mu      sync.Mutex
+	status  Status
+}
+
+func NewLeadership(elector Elector, identity string, clock clockwork.Clock, logger *slog.Logger) *Leadership {
+	return &Leadership{elector: elector, clock: clock, logger: logger, status: Status{Identity: identity}}
+}
+
+// Check renews the lease, the replica loses the leadership when the lease cannot be renewed.
+func (l *Leadership) Check() bool {
+	isLeader, err := l.elector.Campaign()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.status.LastCheck = now
+	l.status.Error = ""
+	if err != nil {
+		isLeader = false
+		l.status.Error = err.Error()
+		l.logger.Error(fmt.Sprintf("leader election failed: %v", err))
+	}
+	if isLeader && !l.status.Leader {
+		l.status.LeaderSince = now
+		l.logger.Info(fmt.Sprintf("%s is now the leader", l.status.Identity))
+	} else if !isLeader && l.status.Leader {
+		l.status.LeaderSince = time.Time{}
+		l.logger.Info(fmt.Sprintf("%s lost the leadership", l.status.Identity))
+	}
+	l.status.Leader = isLeader
+	return isLeader
+}
+
+// Resign releases the lease to let another replica take the leadership without waiting its expiration.
+func (l *Leadership) Resign() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.status.Leader {
+		return
+	}
+	if err := l.elector.Resign(); err != nil {
+		l.logger.Error(fmt.Sprintf("failed to release leader lease: %v", err))
+	}
+	l.status.Leader = false
+	l.status.LeaderSince = time.Time{}
+	l.logger.Info(fmt.Sprintf("%s resigned the leadership", l.status.Identity))
+}
+
+func (l *Leadership) Status() Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.status
+}
@@ -0,0 +1,107 @@
+package leader
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"path"
+	"testing"
+	"time"
+)
+
+type fakeElector struct {
+	results  []bool
+	err      error
+	resigned int
+}
+
+func (f *fakeElector) Campaign() (bool, error) {
+	result := f.results[0]
+	f.results = f.results[1:]
+	return result, f.err
+}
+
+func (f *fakeElector) Resign() error {
+	f.resigned++
+	return nil
+}
+
+func TestNewElector(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	leasePath := path.Join(t.TempDir(), "lease")
+
+	elector, err := NewElector(Config{Type: TypeFile, Path: leasePath, TTL: time.Minute}, "me", clock)
+	assert.NoError(t, err)
+	assert.Equal(t, &FileElector{path: leasePath, identity: "me", ttl: time.Minute, clock: clock}, elector)
+
+	elector, err = NewElector(Config{Type: TypeKubernetes, Kubernetes: &KubernetesConfig{Host: "https://k8s", Namespace: "default", Name: "gtask"}}, "me", clock)
+	assert.NoError(t, err)
+	assert.IsType(t, &KubernetesElector{}, elector)
+
+	_, err = NewElector(Config{Type: TypeKubernetes}, "me", clock)
+	assert.EqualError(t, err, "kubernetes config is missing")
+
+	_, err = NewElector(Config{Type: "wrong"}, "me", clock)
+	assert.EqualError(t, err, "unsupported leader election type wrong")
+}
+
+func TestConfig_GetIdentity(t *testing.T) {
+	assert.Equal(t, "me", Config{Identity: "me"}.GetIdentity())
+	assert.Equal(t, DefaultIdentity(), Config{}.GetIdentity())
+}
+
+func TestLeadership_Check(t *testing.T) {
+	b := bytes.NewBufferString("")
+	clock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC))
+	elector := &fakeElector{results: []bool{false, true, true, false}}
+	leadership := NewLeadership(elector, "me", clock, slog.New(slog.NewTextHandler(b, nil)))
+
+	assert.False(t, leadership.Check())
+	assert.Equal(t, Status{Identity: "me", LastCheck: clock.Now()}, leadership.Status())
+
+	clock.Advance(time.Minute)
+	assert.True(t, leadership.Check())
+	assert.Equal(t, Status{Identity: "me", Leader: true, LeaderSince: clock.Now(), LastCheck: clock.Now()}, leadership.Status())
+	since := clock.Now()
+
+	clock.Advance(time.Minute)
+	assert.True(t, leadership.Check())
+	assert.Equal(t, since, leadership.Status().LeaderSince)
+
+	clock.Advance(time.Minute)
+	assert.False(t, leadership.Check())
+	assert.Equal(t, Status{Identity: "me", LastCheck: clock.Now()}, leadership.Status())
+
+	assert.Contains(t, b.String(), "me is now the leader")
+	assert.Contains(t, b.String(), "me lost the leadership")
+}
+
+func TestLeadership_CheckError(t *testing.T) {
+	b := bytes.NewBufferString("")
+	elector := &fakeElector{results: []bool{true, true}}
+	leadership := NewLeadership(elector, "me", clockwork.NewFakeClock(), slog.New(slog.NewTextHandler(b, nil)))
+	assert.True(t, leadership.Check())
+
+	elector.err = errors.New("connection refused")
+	assert.False(t, leadership.Check())
+	assert.Equal(t, "connection refused", leadership.Status().Error)
+	assert.Contains(t, b.String(), "leader election failed: connection refused")
+	assert.Contains(t, b.String(), "me lost the leadership")
+}
+
+func TestLeadership_Resign(t *testing.T) {
+	b := bytes.NewBufferString("")
+	elector := &fakeElector{results: []bool{true}}
+	leadership := NewLeadership(elector, "me", clockwork.NewFakeClock(), slog.New(slog.NewTextHandler(b, nil)))
+
+	leadership.Resign()
+	assert.Equal(t, 0, elector.resigned)
+
+	leadership.Check()
+	leadership.Resign()
+	assert.Equal(t, 1, elector.resigned)
+	assert.False(t, leadership.Status().Leader)
+	assert.Contains(t, b.String(), "me resigned the leadership")
+}
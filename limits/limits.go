@@ -0,0 +1,179 @@
+package limits
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	IoniceRealtime   = "realtime"
+	IoniceBestEffort = "best-effort"
+	IoniceIdle       = "idle"
+
+	DefaultCgroupParent = "/sys/fs/cgroup"
+	// LeafCgroup is the cgroup created under the parent for gtask when it lives in the parent.
+	LeafCgroup = "gtask"
+	// CpuPeriod is the period of cpu.max in microseconds.
+	CpuPeriod = 100000
+)
+
+// leafMu serializes the moves of gtask into its leaf cgroup.
+var leafMu sync.Mutex
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+}
+
+// Config defines the limits of the command of a task, they are applied before its program runs (see Start).
+type Config struct {
+	// Memory is the RLIMIT_AS of the command, its virtual address space.
+	Memory    string        `mapstructure:"memory" validate:"omitempty,byte-size"`
+	CpuTime   time.Duration `mapstructure:"cpu_time" validate:"omitempty,min=0"`
+	OpenFiles uint64        `mapstructure:"open_files"`
+	Nice      int           `mapstructure:"nice" validate:"min=-20,max=19"`
+	Ionice    string        `mapstructure:"ionice" validate:"omitempty,oneof=realtime best-effort idle"`
+	Cgroup    *CgroupConfig `mapstructure:"cgroup" validate:"omitempty"`
+}
+
+// CgroupConfig defines the transient cgroup v2 created under Parent for each run of the command.
+type CgroupConfig struct {
+	Parent    string  `mapstructure:"parent" validate:"omitempty,dirpath"`
+	MemoryMax string  `mapstructure:"memory_max" validate:"omitempty,byte-size"`
+	CpuMax    float64 `mapstructure:"cpu_max" validate:"omitempty,gt=0"`
+}
+
+// Cgroup is a transient cgroup, removed when the command has ended.
+type Cgroup struct {
+	Path string
+
+	dir *os.File
+}
+
+// Create creates the cgroup name under the parent with its memory.max and cpu.max.
+func (c CgroupConfig) Create(name string) (*Cgroup, error) {
+	parent := c.Parent
+	if parent == "" {
+		parent = DefaultCgroupParent
+	}
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 is not available in %s: %v", parent, err)
+	}
+
+	var controllers []string
+	if c.MemoryMax != "" {
+		controllers = append(controllers, "+memory")
+	}
+	if c.CpuMax > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if len(controllers) > 0 {
+		if err := leaveParent(parent); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0644); err != nil {
+			return nil, fmt.Errorf("failed to enable controllers in %s: %v", parent, err)
+		}
+	}
+
+	cgroup := &Cgroup{Path: filepath.Join(parent, name)}
+	if err := os.Mkdir(cgroup.Path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %v", cgroup.Path, err)
+	}
+	if err := c.write(cgroup.Path); err != nil {
+		_ = os.Remove(cgroup.Path)
+		return nil, err
+	}
+	return cgroup, nil
+}
+
+// leaveParent moves gtask into the leaf cgroup LeafCgroup of the parent when it lives in the parent (ex: in a container):
+// the controllers of a cgroup can't be enabled for its children while it has processes.
+func leaveParent(parent string) error {
+	leafMu.Lock()
+	defer leafMu.Unlock()
+	content, err := os.ReadFile(filepath.Join(parent, "cgroup.procs"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read processes of cgroup %s: %v", parent, err)
+	}
+	pid := strconv.Itoa(os.Getpid())
+	if !slices.Contains(strings.Fields(string(content)), pid) {
+		return nil
+	}
+
+	leaf := filepath.Join(parent, LeafCgroup)
+	if err = os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to create cgroup %s: %v", leaf, err)
+	}
+	if err = os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(pid), 0644); err != nil {
+		return fmt.Errorf("failed to move gtask to cgroup %s: %v", leaf, err)
+	}
+	return nil
+}
+
+func (c CgroupConfig) write(path string) error {
+	if c.MemoryMax != "" {
+		size, err := ParseSize(c.MemoryMax)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(filepath.Join(path, "memory.max"), []byte(strconv.FormatInt(size, 10)), 0644); err != nil {
+			return fmt.Errorf("failed to set memory.max of cgroup %s: %v", path, err)
+		}
+	}
+	if c.CpuMax > 0 {
+		quota := int64(c.CpuMax * CpuPeriod)
+		if err := os.WriteFile(filepath.Join(path, "cpu.max"), []byte(fmt.Sprintf("%d %d", quota, CpuPeriod)), 0644); err != nil {
+			return fmt.Errorf("failed to set cpu.max of cgroup %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// Remove removes the cgroup, it fails while processes are still in it.
+func (g *Cgroup) Remove() error {
+	if g.dir != nil {
+		_ = g.dir.Close()
+		g.dir = nil
+	}
+	return os.Remove(g.Path)
+}
+
+// ParseSize returns the bytes of a size like 512, 64K, 512MiB or 2G (powers of 1024).
+func ParseSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	if i == -1 {
+		i = len(value)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(value[i:]))]
+	if i == 0 || !ok {
+		return 0, fmt.Errorf("invalid size %s", value)
+	}
+	size, err := strconv.ParseInt(value[:i], 10, 64)
+	if err != nil || size > (1<<63-1)/unit {
+		return 0, fmt.Errorf("invalid size %s", value)
+	}
+	return size * unit, nil
+}
@@ -0,0 +1,131 @@
+package limits
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// StubShell runs the command once the limits are applied, it waits for them on a pipe then execs the program.
+	StubShell = "/bin/sh"
+
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	// ioprioLevel is the default priority level of the realtime and best-effort classes.
+	ioprioLevel = 4
+)
+
+var ioprioClasses = map[string]int{
+	IoniceRealtime:   1,
+	IoniceBestEffort: 2,
+	IoniceIdle:       3,
+}
+
+// Apply sets the rlimits, the niceness and the io scheduling class of the process pid.
+func (c Config) Apply(pid int) error {
+	if c.Memory != "" {
+		size, err := ParseSize(c.Memory)
+		if err != nil {
+			return err
+		}
+		if err = prlimit(pid, syscall.RLIMIT_AS, uint64(size)); err != nil {
+			return fmt.Errorf("failed to limit memory to %s: %v", c.Memory, err)
+		}
+	}
+	if c.CpuTime > 0 {
+		seconds := uint64((c.CpuTime + 999_999_999) / 1_000_000_000)
+		if err := prlimit(pid, syscall.RLIMIT_CPU, seconds); err != nil {
+			return fmt.Errorf("failed to limit cpu time to %s: %v", c.CpuTime, err)
+		}
+	}
+	if c.OpenFiles > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_NOFILE, c.OpenFiles); err != nil {
+			return fmt.Errorf("failed to limit open files to %d: %v", c.OpenFiles, err)
+		}
+	}
+	if c.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, c.Nice); err != nil {
+			return fmt.Errorf("failed to set nice to %d: %v", c.Nice, err)
+		}
+	}
+	if c.Ionice != "" {
+		class, ok := ioprioClasses[c.Ionice]
+		if !ok {
+			return fmt.Errorf("unsupported ionice class %s", c.Ionice)
+		}
+		level := ioprioLevel
+		if c.Ionice == IoniceIdle {
+			level = 0
+		}
+		_, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(class<<ioprioClassShift|level))
+		if errno != 0 {
+			return fmt.Errorf("failed to set ionice to %s: %v", c.Ionice, errno)
+		}
+	}
+	return nil
+}
+
+// Start starts cmd under StubShell, which waits until the limits are applied to its process then execs the program:
+// the program and the processes it forks never run without the limits.
+// The limits are applied by gtask, with its privileges, the command is killed when they can not be applied.
+func (c Config) Start(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		return cmd.Start()
+	}
+	program := cmd.Path
+	if !filepath.IsAbs(program) && cmd.Dir != "" {
+		program = filepath.Join(cmd.Dir, program)
+	}
+	if _, err := exec.LookPath(program); err != nil {
+		return err
+	}
+
+	wait, resume, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to apply limits: %v", err)
+	}
+	defer func() { _ = resume.Close() }()
+	fd := 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, wait)
+	// the stub reads the pipe until gtask closes it, the pipe is not passed to the program
+	script := fmt.Sprintf(`read -r _ <&%d; exec "$@" %d<&-`, fd, fd)
+	cmd.Args = append([]string{StubShell, "-c", script, filepath.Base(StubShell), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = StubShell
+	err = cmd.Start()
+	_ = wait.Close()
+	if err != nil {
+		return err
+	}
+
+	if err = c.Apply(cmd.Process.Pid); err != nil {
+		_ = syscall.Kill(cmd.Process.Pid, syscall.SIGKILL)
+		_ = cmd.Wait()
+		return fmt.Errorf("failed to apply limits: %v", err)
+	}
+	return nil
+}
+
+// Attach makes the process started with attr begin in the cgroup.
+func (g *Cgroup) Attach(attr *syscall.SysProcAttr) error {
+	dir, err := os.Open(g.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open cgroup %s: %v", g.Path, err)
+	}
+	g.dir = dir
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(dir.Fd())
+	return nil
+}
+
+func prlimit(pid int, resource int, value uint64) error {
+	limit := syscall.Rlimit{Cur: value, Max: value}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(&limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package limits
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConfig_Apply(t *testing.T) {
+	cmd := exec.Command("sleep", "5")
+	assert.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+
+	cfg := Config{Memory: "1G", CpuTime: 1500 * time.Millisecond, OpenFiles: 64, Nice: 5, Ionice: IoniceIdle}
+	assert.NoError(t, cfg.Apply(pid))
+
+	content, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", pid))
+	assert.NoError(t, err)
+	limits := string(content)
+	assert.Regexp(t, `Max address space\s+1073741824\s+1073741824\s+bytes`, limits)
+	assert.Regexp(t, `Max cpu time\s+2\s+2\s+seconds`, limits)
+	assert.Regexp(t, `Max open files\s+64\s+64\s+files`, limits)
+
+	nice, err := syscall.Getpriority(syscall.PRIO_PROCESS, pid)
+	assert.NoError(t, err)
+	// the raw syscall returns 20 - nice
+	assert.Equal(t, 15, nice)
+
+	ioprio, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(pid), 0)
+	assert.Zero(t, errno)
+	assert.Equal(t, uintptr(3<<ioprioClassShift), ioprio)
+}
+
+func TestConfig_Start(t *testing.T) {
+	// the pipeline forks at once, both sides must get the limits
+	cmd := exec.Command("sh", "-c", "ulimit -n | cat; ulimit -t; nice")
+	output := &strings.Builder{}
+	cmd.Stdout = output
+
+	cfg := Config{CpuTime: time.Minute, OpenFiles: 64, Nice: 5}
+	assert.NoError(t, cfg.Start(cmd))
+	assert.NoError(t, cmd.Wait())
+	assert.Equal(t, "64\n60\n5\n", output.String())
+}
+
+func TestConfig_StartProgram(t *testing.T) {
+	// the program replaces the stub in the same process, without the pipe of the stub
+	cmd := exec.Command("sh", "-c", "echo $$; grep TracerPid /proc/self/status; ls /proc/$$/fd")
+	output := &strings.Builder{}
+	cmd.Stdout = output
+
+	assert.NoError(t, Config{OpenFiles: 64}.Start(cmd))
+	assert.NoError(t, cmd.Wait())
+	assert.Equal(t, fmt.Sprintf("%d\nTracerPid:\t0\n0\n1\n2\n", cmd.Process.Pid), output.String())
+}
+
+func TestConfig_StartError(t *testing.T) {
+	cmd := exec.Command("sleep", "5")
+	err := Config{Memory: "wrong"}.Start(cmd)
+	assert.EqualError(t, err, "failed to apply limits: invalid size wrong")
+	// the command is killed and waited for
+	assert.NotNil(t, cmd.ProcessState)
+
+	err = Config{}.Start(exec.Command("/missing"))
+	assert.ErrorContains(t, err, "no such file or directory")
+}
+
+func TestConfig_ApplyError(t *testing.T) {
+	err := Config{Memory: "wrong"}.Apply(os.Getpid())
+	assert.EqualError(t, err, "invalid size wrong")
+
+	err = Config{OpenFiles: 64}.Apply(1 << 30)
+	assert.ErrorContains(t, err, "failed to limit open files to 64")
+	assert.True(t, strings.HasSuffix(err.Error(), syscall.ESRCH.Error()))
+}
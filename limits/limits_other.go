@@ -0,0 +1,25 @@
+//go:build !linux
+
+package limits
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// Apply fails, the limits are only supported on linux.
+func (c Config) Apply(pid int) error {
+	return fmt.Errorf("limits are not supported on %s", runtime.GOOS)
+}
+
+// Start fails, the limits are only supported on linux.
+func (c Config) Start(cmd *exec.Cmd) error {
+	return fmt.Errorf("limits are not supported on %s", runtime.GOOS)
+}
+
+// Attach fails, the cgroups are only supported on linux.
+func (g *Cgroup) Attach(attr *syscall.SysProcAttr) error {
+	return fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
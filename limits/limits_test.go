@@ -0,0 +1,87 @@
+package limits
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "SuccessBytes", value: "512", want: 512},
+		{name: "SuccessKilo", value: "64K", want: 64 << 10},
+		{name: "SuccessMebi", value: "512MiB", want: 512 << 20},
+		{name: "SuccessGigaLower", value: "2g", want: 2 << 30},
+		{name: "SuccessSpace", value: "1 TB", want: 1 << 40},
+		{name: "ErrorEmpty", value: "", wantErr: true},
+		{name: "ErrorNoNumber", value: "MB", wantErr: true},
+		{name: "ErrorWrongUnit", value: "5X", wantErr: true},
+		{name: "ErrorNegative", value: "-5M", wantErr: true},
+		{name: "ErrorOverflow", value: "9999999999T", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSize(tt.value)
+			if tt.wantErr {
+				assert.EqualError(t, err, "invalid size "+tt.value)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCgroupConfig_Create(t *testing.T) {
+	parent := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(parent, "cgroup.controllers"), []byte("cpu memory"), 0644))
+
+	cgroup, err := CgroupConfig{Parent: parent, MemoryMax: "256M", CpuMax: 0.5}.Create("gtask-test")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(parent, "gtask-test"), cgroup.Path)
+	content, _ := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	assert.Equal(t, "+memory +cpu", string(content))
+	content, _ = os.ReadFile(filepath.Join(cgroup.Path, "memory.max"))
+	assert.Equal(t, "268435456", string(content))
+	content, _ = os.ReadFile(filepath.Join(cgroup.Path, "cpu.max"))
+	assert.Equal(t, "50000 100000", string(content))
+
+	assert.NoError(t, os.Remove(filepath.Join(cgroup.Path, "memory.max")))
+	assert.NoError(t, os.Remove(filepath.Join(cgroup.Path, "cpu.max")))
+	assert.NoError(t, cgroup.Remove())
+	assert.NoDirExists(t, cgroup.Path)
+}
+
+func TestCgroupConfig_CreateFromParent(t *testing.T) {
+	parent := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(parent, "cgroup.controllers"), []byte("cpu memory"), 0644))
+	pid := strconv.Itoa(os.Getpid())
+	assert.NoError(t, os.WriteFile(filepath.Join(parent, "cgroup.procs"), []byte("1\n"+pid+"\n"), 0644))
+
+	cgroup, err := CgroupConfig{Parent: parent, MemoryMax: "256M"}.Create("gtask-test")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(parent, "gtask-test"), cgroup.Path)
+	// gtask is moved to its leaf cgroup before the controllers are enabled
+	content, _ := os.ReadFile(filepath.Join(parent, LeafCgroup, "cgroup.procs"))
+	assert.Equal(t, pid, string(content))
+	content, _ = os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	assert.Equal(t, "+memory", string(content))
+}
+
+func TestCgroupConfig_CreateError(t *testing.T) {
+	parent := t.TempDir()
+	_, err := CgroupConfig{Parent: parent, MemoryMax: "256M"}.Create("gtask-test")
+	assert.ErrorContains(t, err, "cgroup v2 is not available in "+parent)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(parent, "cgroup.controllers"), []byte("cpu memory"), 0644))
+	_, err = CgroupConfig{Parent: parent, MemoryMax: "wrong"}.Create("gtask-test")
+	assert.EqualError(t, err, "invalid size wrong")
+	assert.NoDirExists(t, filepath.Join(parent, "gtask-test"))
+}
@@ -0,0 +1,65 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirectoryLocker creates a lease file per run in a shared directory (NFS...).
+// A lease is kept until its TTL expires so the run cannot be taken again by a late replica.
+type DirectoryLocker struct {
+	path  string
+	ttl   time.Duration
+	clock clockwork.Clock
+}
+
+func NewDirectoryLocker(path string, ttl time.Duration, clock clockwork.Clock) (*DirectoryLocker, error) {
+	if err := os.MkdirAll(path, 0o770); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory %s: %v", path, err)
+	}
+	return &DirectoryLocker{path: path, ttl: ttl, clock: clock}, nil
+}
+
+func (l *DirectoryLocker) Acquire(key Key) (bool, error) {
+	l.removeExpired(key.Id)
+	leasePath := filepath.Join(l.path, key.String()+".lease")
+	file, err := os.OpenFile(leasePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o660)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create lease %s: %v", leasePath, err)
+	}
+	defer file.Close()
+
+	expireAt := l.clock.Now().Add(l.ttl).UnixNano()
+	if _, err = fmt.Fprintf(file, "%d\n%s\n", expireAt, owner()); err != nil {
+		return false, fmt.Errorf("failed to write lease %s: %v", leasePath, err)
+	}
+	return true, nil
+}
+
+// Release does nothing: the lease expires after its TTL.
+func (l *DirectoryLocker) Release(_ Key) error {
+	return nil
+}
+
+func (l *DirectoryLocker) removeExpired(id string) {
+	leases, _ := filepath.Glob(filepath.Join(l.path, id+"-*.lease"))
+	for _, lease := range leases {
+		content, err := os.ReadFile(lease)
+		if err != nil {
+			continue
+		}
+		expireAt, err := strconv.ParseInt(strings.SplitN(string(content), "\n", 2)[0], 10, 64)
+		if err == nil && l.clock.Now().UnixNano() > expireAt {
+			_ = os.Remove(lease)
+		}
+	}
+}
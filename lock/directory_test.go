@@ -0,0 +1,55 @@
+package lock
+
+import (
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDirectoryLocker(t *testing.T) {
+	dir := t.TempDir()
+	clock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC))
+	first, err := NewDirectoryLocker(dir, time.Hour, clock)
+	assert.NoError(t, err)
+	second, err := NewDirectoryLocker(dir, time.Hour, clock)
+	assert.NoError(t, err)
+	key := Key{Id: "test", At: clock.Now()}
+
+	acquired, err := first.Acquire(key)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, first.Release(key))
+
+	acquired, err = second.Acquire(key)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// expired leases are removed
+	clock.Advance(2 * time.Hour)
+	next := Key{Id: "test", At: clock.Now()}
+	acquired, err = second.Acquire(next)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	_, err = os.Stat(path.Join(dir, key.String()+".lease"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path.Join(dir, next.String()+".lease"))
+	assert.NoError(t, err)
+}
+
+func TestDirectoryLocker_ErrorCreate(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := NewDirectoryLocker(dir, time.Hour, clockwork.NewFakeClock())
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chmod(dir, 0o500))
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o700) })
+	if os.Geteuid() == 0 {
+		t.Skip("root can write in a read only directory")
+	}
+
+	acquired, err := locker.Acquire(Key{Id: "test"})
+	assert.False(t, acquired)
+	assert.ErrorContains(t, err, "failed to create lease")
+}
@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// FileLocker takes a flock on a file per task, which stores the last run to avoid running it twice.
+// It only works between processes which share the same filesystem with working flock.
+type FileLocker struct {
+	path  string
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+func NewFileLocker(path string) (*FileLocker, error) {
+	if err := os.MkdirAll(path, 0o770); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory %s: %v", path, err)
+	}
+	return &FileLocker{path: path, files: map[string]*os.File{}}, nil
+}
+
+func (l *FileLocker) Acquire(key Key) (bool, error) {
+	file, err := os.OpenFile(filepath.Join(l.path, key.Id+".lock"), os.O_CREATE|os.O_RDWR, 0o660)
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock %s: %v", file.Name(), err)
+	}
+
+	last, err := io.ReadAll(file)
+	if err == nil && string(last) == key.String() {
+		_ = file.Close()
+		return false, nil
+	}
+	if err == nil {
+		err = file.Truncate(0)
+	}
+	if err == nil {
+		_, err = file.WriteAt([]byte(key.String()), 0)
+	}
+	if err != nil {
+		_ = file.Close()
+		return false, fmt.Errorf("failed to write lock file %s: %v", file.Name(), err)
+	}
+
+	l.mu.Lock()
+	l.files[key.String()] = file
+	l.mu.Unlock()
+	return true, nil
+}
+
+func (l *FileLocker) Release(key Key) error {
+	l.mu.Lock()
+	file, ok := l.files[key.String()]
+	delete(l.files, key.String())
+	l.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	// closing the file releases the flock
+	return file.Close()
+}
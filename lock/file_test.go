@@ -0,0 +1,55 @@
+package lock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestFileLocker(t *testing.T) {
+	dir := t.TempDir()
+	first, err := NewFileLocker(dir)
+	assert.NoError(t, err)
+	second, err := NewFileLocker(dir)
+	assert.NoError(t, err)
+	key := Key{Id: "test", At: time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)}
+
+	acquired, err := first.Acquire(key)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// locked by a running task
+	acquired, err = second.Acquire(key)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	assert.NoError(t, first.Release(key))
+	content, err := os.ReadFile(path.Join(dir, "test.lock"))
+	assert.NoError(t, err)
+	assert.Equal(t, "test-1674612000", string(content))
+
+	// the run is already done
+	acquired, err = second.Acquire(key)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	next := Key{Id: "test", At: key.At.Add(time.Minute)}
+	acquired, err = second.Acquire(next)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, second.Release(next))
+	assert.NoError(t, second.Release(next))
+}
+
+func TestFileLocker_ErrorOpen(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := NewFileLocker(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Mkdir(path.Join(dir, "test.lock"), 0o700))
+
+	acquired, err := locker.Acquire(Key{Id: "test"})
+	assert.False(t, acquired)
+	assert.ErrorContains(t, err, "failed to open lock file")
+}
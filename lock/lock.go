@@ -0,0 +1,67 @@
+package lock
+
+import (
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"os"
+	"time"
+)
+
+const (
+	TypeFile      = "file"
+	TypeDirectory = "directory"
+	TypeRedis     = "redis"
+
+	DefaultTTL = time.Hour
+)
+
+type Config struct {
+	Type  string        `mapstructure:"type" validate:"omitempty,oneof=file directory redis"`
+	Path  string        `mapstructure:"path" validate:"required_if=Type file,required_if=Type directory"`
+	TTL   time.Duration `mapstructure:"ttl" validate:"omitempty,min=0"`
+	Redis *RedisConfig  `mapstructure:"redis" validate:"required_if=Type redis,omitempty"`
+}
+
+// Key identifies one run of a task: the task id and its scheduled time.
+type Key struct {
+	Id string
+	At time.Time
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s-%d", k.Id, k.At.Unix())
+}
+
+type Locker interface {
+	// Acquire returns false when the run is locked elsewhere.
+	Acquire(key Key) (bool, error)
+	Release(key Key) error
+}
+
+// NewLocker returns the locker of the config or nil when no lock is configured.
+func NewLocker(cfg Config, clock clockwork.Clock) (Locker, error) {
+	ttl := cfg.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case TypeFile:
+		return NewFileLocker(cfg.Path)
+	case TypeDirectory:
+		return NewDirectoryLocker(cfg.Path, ttl, clock)
+	case TypeRedis:
+		if cfg.Redis == nil {
+			return nil, fmt.Errorf("redis config is missing")
+		}
+		return NewRedisLocker(*cfg.Redis, ttl), nil
+	default:
+		return nil, fmt.Errorf("unsupported lock type %s", cfg.Type)
+	}
+}
+
+func owner() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
@@ -0,0 +1,68 @@
+package lock
+
+import (
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestKey_String(t *testing.T) {
+	key := Key{Id: "test", At: time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)}
+	assert.Equal(t, "test-1674612000", key.String())
+}
+
+func TestNewLocker(t *testing.T) {
+	dir := t.TempDir()
+	clock := clockwork.NewFakeClock()
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    Locker
+		wantErr string
+	}{
+		{
+			name: "SuccessWithoutLock",
+			cfg:  Config{},
+			want: nil,
+		},
+		{
+			name: "SuccessFile",
+			cfg:  Config{Type: TypeFile, Path: path.Join(dir, "file")},
+			want: &FileLocker{path: path.Join(dir, "file"), files: map[string]*os.File{}},
+		},
+		{
+			name: "SuccessDirectoryWithDefaultTTL",
+			cfg:  Config{Type: TypeDirectory, Path: path.Join(dir, "directory")},
+			want: &DirectoryLocker{path: path.Join(dir, "directory"), ttl: DefaultTTL, clock: clock},
+		},
+		{
+			name: "SuccessRedis",
+			cfg:  Config{Type: TypeRedis, TTL: time.Minute, Redis: &RedisConfig{Address: "127.0.0.1:6379"}},
+			want: &RedisLocker{cfg: RedisConfig{Address: "127.0.0.1:6379", Prefix: DefaultRedisPrefix, Timeout: 5 * time.Second}, ttl: time.Minute},
+		},
+		{
+			name:    "ErrorRedisWithoutConfig",
+			cfg:     Config{Type: TypeRedis},
+			wantErr: "redis config is missing",
+		},
+		{
+			name:    "ErrorUnsupportedType",
+			cfg:     Config{Type: "wrong"},
+			wantErr: "unsupported lock type wrong",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLocker(tt.cfg, clock)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package lock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultRedisPrefix = "gtask:lock:"
+
+type RedisConfig struct {
+	Address  string        `mapstructure:"address" validate:"required,hostname_port"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Db       int           `mapstructure:"db" validate:"min=0"`
+	Prefix   string        `mapstructure:"prefix"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// RedisLocker takes a lock per run with SET NX PX, the lock expires after its TTL.
+type RedisLocker struct {
+	cfg RedisConfig
+	ttl time.Duration
+}
+
+func NewRedisLocker(cfg RedisConfig, ttl time.Duration) *RedisLocker {
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultRedisPrefix
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &RedisLocker{cfg: cfg, ttl: ttl}
+}
+
+func (l *RedisLocker) Acquire(key Key) (bool, error) {
+	conn, err := net.DialTimeout("tcp", l.cfg.Address, l.cfg.Timeout)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to redis %s: %v", l.cfg.Address, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(l.cfg.Timeout))
+	client := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if l.cfg.Password != "" {
+		args := []string{"AUTH", l.cfg.Password}
+		if l.cfg.Username != "" {
+			args = []string{"AUTH", l.cfg.Username, l.cfg.Password}
+		}
+		if _, err = client.do(args...); err != nil {
+			return false, fmt.Errorf("failed to authenticate to redis: %v", err)
+		}
+	}
+	if l.cfg.Db != 0 {
+		if _, err = client.do("SELECT", strconv.Itoa(l.cfg.Db)); err != nil {
+			return false, fmt.Errorf("failed to select redis db %d: %v", l.cfg.Db, err)
+		}
+	}
+
+	reply, err := client.do("SET", l.cfg.Prefix+key.String(), owner(), "NX", "PX", strconv.FormatInt(l.ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, fmt.Errorf("failed to set redis lock: %v", err)
+	}
+	return reply != nil, nil
+}
+
+// Release does nothing: the lock expires after its TTL.
+func (l *RedisLocker) Release(_ Key) error {
+	return nil
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// do sends a command and returns its reply, nil for a null reply.
+func (c *redisConn) do(args ...string) (*string, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
+	}
+	if _, err := c.conn.Write([]byte(builder.String())); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (*string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		value := line[1:]
+		return &value, nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		value := string(data[:size])
+		return &value, nil
+	default:
+		return nil, fmt.Errorf("unsupported reply %q", line)
+	}
+}
@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is an in-process stand-in which understands AUTH, SELECT and SET NX PX.
+type fakeRedis struct {
+	mu       sync.Mutex
+	password string
+	keys     map[string]time.Time
+	commands []string
+}
+
+func (f *fakeRedis) start(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte(f.handle(args)))
+	}
+}
+
+func (f *fakeRedis) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, args[0])
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		if args[len(args)-1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		if len(args) != 6 {
+			return "-ERR syntax error\r\n"
+		}
+		ttl, _ := strconv.Atoi(args[5])
+		if expireAt, ok := f.keys[args[1]]; ok && time.Now().Before(expireAt) {
+			return "$-1\r\n"
+		}
+		f.keys[args[1]] = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+		return "+OK\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:size]))
+	}
+	return args, nil
+}
+
+func TestRedisLocker(t *testing.T) {
+	server := &fakeRedis{password: "secret", keys: map[string]time.Time{}}
+	address := server.start(t)
+	locker := NewRedisLocker(RedisConfig{Address: address, Password: "secret", Db: 1}, time.Minute)
+	key := Key{Id: "test", At: time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)}
+
+	acquired, err := locker.Acquire(key)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, locker.Release(key))
+
+	acquired, err = locker.Acquire(key)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	acquired, err = locker.Acquire(Key{Id: "other", At: key.At})
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	assert.Contains(t, server.keys, "gtask:lock:test-1674612000")
+	assert.Equal(t, []string{"AUTH", "SELECT", "SET", "AUTH", "SELECT", "SET", "AUTH", "SELECT", "SET"}, server.commands)
+}
+
+func TestRedisLocker_Error(t *testing.T) {
+	server := &fakeRedis{password: "secret", keys: map[string]time.Time{}}
+	address := server.start(t)
+
+	locker := NewRedisLocker(RedisConfig{Address: address, Password: "wrong"}, time.Minute)
+	acquired, err := locker.Acquire(Key{Id: "test"})
+	assert.False(t, acquired)
+	assert.EqualError(t, err, "failed to authenticate to redis: WRONGPASS invalid password")
+
+	locker = NewRedisLocker(RedisConfig{Address: "127.0.0.1:1", Timeout: time.Second}, time.Minute)
+	acquired, err = locker.Acquire(Key{Id: "test"})
+	assert.False(t, acquired)
+	assert.ErrorContains(t, err, "failed to connect to redis 127.0.0.1:1")
+}
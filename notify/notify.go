@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"fmt"
+	"github.com/alexandreh2ag/go-task/types"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Event string
+
+const (
+	EventFailure  Event = "failure"
+	EventRecovery Event = "recovery"
+	EventSuccess  Event = "success"
+	EventTimeout  Event = "timeout"
+	EventOutput   Event = "output"
+
+	TypeWebhook = "webhook"
+	TypeSlack   = "slack"
+	TypeTeams   = "teams"
+	TypeSmtp    = "smtp"
+)
+
+type Targets = []*Target
+
+type Target struct {
+	Id       string            `mapstructure:"id" validate:"required,excludesall=!@#$ "`
+	Type     string            `mapstructure:"type" validate:"required,oneof=webhook slack teams smtp"`
+	Url      string            `mapstructure:"url" validate:"required_unless=Type smtp,omitempty,url"`
+	Method   string            `mapstructure:"method" validate:"omitempty,oneof=POST PUT PATCH"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Template string            `mapstructure:"template"`
+	Smtp     *SmtpConfig       `mapstructure:"smtp" validate:"required_if=Type smtp,omitempty"`
+}
+
+type Message struct {
+	Event    Event
+	TaskId   string
+	Status   string
+	Hostname string
+	Output   string
+	Error    string
+	Report   string
+	MailTo   []string
+	StartAt  time.Time
+	FinishAt time.Time
+	Duration time.Duration
+	Usage    *types.Usage
+	Result   *types.TaskResult
+}
+
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+type Dispatcher struct {
+	notifiers  map[string]Notifier
+	logger     *slog.Logger
+	hostname   string
+	mu         sync.Mutex
+	lastStatus map[string]int
+}
+
+func NewDispatcher(targets Targets, logger *slog.Logger) (*Dispatcher, error) {
+	hostname, _ := os.Hostname()
+	dispatcher := &Dispatcher{
+		notifiers:  map[string]Notifier{},
+		logger:     logger,
+		hostname:   hostname,
+		lastStatus: map[string]int{},
+	}
+	for _, target := range targets {
+		notifier, err := CreateNotifier(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notifier %s: %v", target.Id, err)
+		}
+		dispatcher.notifiers[target.Id] = notifier
+	}
+	return dispatcher, nil
+}
+
+// UpdateTargets replaces the targets of d by the ones of from, the last status of each task is kept
+// to detect the recoveries across a reload of the config.
+func (d *Dispatcher) UpdateTargets(from *Dispatcher) {
+	from.mu.Lock()
+	notifiers := from.notifiers
+	from.mu.Unlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers = notifiers
+}
+
+func CreateNotifier(target *Target) (Notifier, error) {
+	switch target.Type {
+	case TypeWebhook, TypeSlack, TypeTeams:
+		return NewHttpNotifier(target)
+	case TypeSmtp:
+		return NewSmtpNotifier(target)
+	default:
+		return nil, fmt.Errorf("unsupported notification type %s", target.Type)
+	}
+}
+
+// Dispatch sends the result of a task to every target when the task subscribed
+// to the matching event. The last status of each task is kept to detect recoveries.
+func (d *Dispatcher) Dispatch(result *types.TaskResult, report string) {
+	task := result.Task
+
+	d.mu.Lock()
+	previous, hasPrevious := d.lastStatus[task.Id]
+	if result.Status != types.Skipped {
+		d.lastStatus[task.Id] = result.Status
+	}
+	notifiers := d.notifiers
+	d.mu.Unlock()
+
+	if !hasPrevious {
+		previous = types.Pending
+	}
+	event, ok := ResolveEvent(task.NotifyOn, result.Status, previous, result.Output.Len() > 0)
+	if !ok {
+		return
+	}
+
+	msg := d.newMessage(event, result, report)
+	for id, notifier := range notifiers {
+		if err := notifier.Notify(msg); err != nil {
+			task.Logger.Error(fmt.Sprintf("failed to send %s notification with %s for task %s: %v", event, id, task.Id, err))
+			continue
+		}
+		task.Logger.Debug(fmt.Sprintf("%s notification sent with %s for task %s", event, id, task.Id))
+	}
+}
+
+func (d *Dispatcher) newMessage(event Event, result *types.TaskResult, report string) Message {
+	msg := Message{
+		Event:    event,
+		TaskId:   result.Task.Id,
+		Status:   result.StatusString(),
+		Hostname: d.hostname,
+		Output:   result.Output.String(),
+		Report:   report,
+		MailTo:   result.Task.MailTo,
+		StartAt:  result.StartAt,
+		FinishAt: result.FinishAt,
+		Duration: result.FinishAt.Sub(result.StartAt),
+		Usage:    result.Usage,
+		Result:   result,
+	}
+	if result.Error != nil {
+		msg.Error = result.Task.Mask(result.Error.Error())
+	}
+	return msg
+}
+
+// ResolveEvent returns the event to send for a status. A recovery takes precedence
+// over a success and a timeout falls back to a failure when only failures are wanted.
+// Like cron MAILTO, the output event is sent for any run which printed something.
+func ResolveEvent(notifyOn []string, status int, previous int, hasOutput bool) (Event, bool) {
+	wants := func(event Event) bool {
+		return slices.Contains(notifyOn, string(event))
+	}
+	switch status {
+	case types.Succeed:
+		if isFailure(previous) && wants(EventRecovery) {
+			return EventRecovery, true
+		}
+		if wants(EventSuccess) {
+			return EventSuccess, true
+		}
+	case types.TimedOut:
+		if wants(EventTimeout) {
+			return EventTimeout, true
+		}
+		if wants(EventFailure) {
+			return EventFailure, true
+		}
+	case types.Failed, types.Interrupted:
+		if wants(EventFailure) {
+			return EventFailure, true
+		}
+	}
+	if hasOutput && wants(EventOutput) {
+		return EventOutput, true
+	}
+	return "", false
+}
+
+func isFailure(status int) bool {
+	return status == types.Failed || status == types.TimedOut || status == types.Interrupted
+}
@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/alexandreh2ag/go-task/secret"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		notifyOn  []string
+		status    int
+		previous  int
+		hasOutput bool
+		want      Event
+		wantFound bool
+	}{
+		{
+			name:      "SuccessNothingSubscribed",
+			notifyOn:  []string{},
+			status:    types.Failed,
+			previous:  types.Succeed,
+			wantFound: false,
+		},
+		{
+			name:      "SuccessFailure",
+			notifyOn:  []string{"failure"},
+			status:    types.Failed,
+			previous:  types.Succeed,
+			want:      EventFailure,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessSuccess",
+			notifyOn:  []string{"success"},
+			status:    types.Succeed,
+			previous:  types.Succeed,
+			want:      EventSuccess,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessRecovery",
+			notifyOn:  []string{"success", "recovery"},
+			status:    types.Succeed,
+			previous:  types.Failed,
+			want:      EventRecovery,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessRecoveryAfterTimeout",
+			notifyOn:  []string{"recovery"},
+			status:    types.Succeed,
+			previous:  types.TimedOut,
+			want:      EventRecovery,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessNoRecoveryWithoutPreviousFailure",
+			notifyOn:  []string{"recovery"},
+			status:    types.Succeed,
+			previous:  types.Pending,
+			wantFound: false,
+		},
+		{
+			name:      "SuccessTimeout",
+			notifyOn:  []string{"timeout", "failure"},
+			status:    types.TimedOut,
+			previous:  types.Succeed,
+			want:      EventTimeout,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessTimeoutFallbackOnFailure",
+			notifyOn:  []string{"failure"},
+			status:    types.TimedOut,
+			previous:  types.Succeed,
+			want:      EventFailure,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessInterruptedAsFailure",
+			notifyOn:  []string{"timeout", "failure"},
+			status:    types.Interrupted,
+			previous:  types.Succeed,
+			want:      EventFailure,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessRecoveryAfterInterrupted",
+			notifyOn:  []string{"recovery"},
+			status:    types.Succeed,
+			previous:  types.Interrupted,
+			want:      EventRecovery,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessOutput",
+			notifyOn:  []string{"output"},
+			status:    types.Succeed,
+			previous:  types.Succeed,
+			hasOutput: true,
+			want:      EventOutput,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessOutputWithoutOutput",
+			notifyOn:  []string{"output"},
+			status:    types.Succeed,
+			previous:  types.Succeed,
+			hasOutput: false,
+			wantFound: false,
+		},
+		{
+			name:      "SuccessFailureBeforeOutput",
+			notifyOn:  []string{"output", "failure"},
+			status:    types.Failed,
+			previous:  types.Succeed,
+			hasOutput: true,
+			want:      EventFailure,
+			wantFound: true,
+		},
+		{
+			name:      "SuccessSkippedIgnored",
+			notifyOn:  []string{"failure", "success"},
+			status:    types.Skipped,
+			previous:  types.Succeed,
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := ResolveEvent(tt.notifyOn, tt.status, tt.previous, tt.hasOutput)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewDispatcher_ErrorWithWrongTemplate(t *testing.T) {
+	targets := Targets{{Id: "hook", Type: TypeWebhook, Url: "http://localhost", Template: "{{ .Wrong "}}
+	_, err := NewDispatcher(targets, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create notifier hook")
+}
+
+func TestNewDispatcher_ErrorWithUnsupportedType(t *testing.T) {
+	targets := Targets{{Id: "hook", Type: "wrong", Url: "http://localhost"}}
+	_, err := NewDispatcher(targets, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported notification type wrong")
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	var mu sync.Mutex
+	events := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload["event"])
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher, err := NewDispatcher(Targets{{Id: "hook", Type: TypeWebhook, Url: server.URL}}, logger)
+	assert.NoError(t, err)
+
+	task := &types.ScheduledTask{Id: "test", Logger: logger, NotifyOn: []string{"failure", "recovery"}}
+	statuses := []int{types.Succeed, types.Failed, types.Skipped, types.Failed, types.Succeed, types.Succeed}
+	for _, status := range statuses {
+		result := &types.TaskResult{Status: status, Task: task, Output: *bytes.NewBufferString("out")}
+		if status == types.Failed {
+			result.Error = errors.New("fail")
+		}
+		dispatcher.Dispatch(result, "report")
+	}
+
+	assert.Equal(t, []string{"failure", "failure", "recovery"}, events)
+}
+
+func TestDispatcher_UpdateTargets(t *testing.T) {
+	var mu sync.Mutex
+	events := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events[r.URL.Path] = append(events[r.URL.Path], payload["event"])
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher, err := NewDispatcher(Targets{{Id: "hook", Type: TypeWebhook, Url: server.URL + "/old"}}, logger)
+	assert.NoError(t, err)
+	reloaded, err := NewDispatcher(Targets{{Id: "hook", Type: TypeWebhook, Url: server.URL + "/new"}}, logger)
+	assert.NoError(t, err)
+
+	task := &types.ScheduledTask{Id: "test", Logger: logger, NotifyOn: []string{"failure", "recovery"}}
+	dispatcher.Dispatch(&types.TaskResult{Status: types.Failed, Task: task, Error: errors.New("fail")}, "report")
+	dispatcher.UpdateTargets(reloaded)
+	dispatcher.Dispatch(&types.TaskResult{Status: types.Succeed, Task: task}, "report")
+
+	assert.Equal(t, map[string][]string{"/old": {"failure"}, "/new": {"recovery"}}, events)
+}
+
+func TestDispatcher_Dispatch_ErrorIsLogged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	b := bytes.NewBufferString("")
+	logger := slog.New(slog.NewTextHandler(b, nil))
+	dispatcher, err := NewDispatcher(Targets{{Id: "hook", Type: TypeSlack, Url: server.URL}}, logger)
+	assert.NoError(t, err)
+
+	task := &types.ScheduledTask{Id: "test", Logger: logger, NotifyOn: []string{"failure"}}
+	dispatcher.Dispatch(&types.TaskResult{Status: types.Failed, Task: task, StartAt: time.Now(), FinishAt: time.Now()}, "report")
+
+	assert.Contains(t, b.String(), "failed to send failure notification with hook for task test: unexpected status code 500")
+}
+
+func TestDispatcher_newMessage_MaskSecrets(t *testing.T) {
+	t.Setenv("GTASK_TESTING_SECRET", "p@ss")
+	resolver, err := secret.NewResolver(secret.Config{}, afero.NewMemMapFs())
+	assert.NoError(t, err)
+	task := &types.ScheduledTask{Id: "test", Envs: map[string]string{"DB_PASSWORD": "env://GTASK_TESTING_SECRET"}}
+	assert.NoError(t, task.ResolveSecrets(resolver))
+
+	dispatcher := &Dispatcher{hostname: "host"}
+	msg := dispatcher.newMessage(EventFailure, &types.TaskResult{Status: types.Failed, Task: task, Error: errors.New("wrong p@ss")}, "report")
+	assert.Equal(t, "wrong ***", msg.Error)
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	DefaultSubjectTemplate = "[gtask] Task {{ .TaskId }} {{ .Status }} on {{ .Hostname }}"
+
+	smtpTimeout = 10 * time.Second
+)
+
+type SmtpConfig struct {
+	Host               string   `mapstructure:"host" validate:"required"`
+	Port               int      `mapstructure:"port" validate:"required,min=1,max=65535"`
+	StartTls           bool     `mapstructure:"starttls"`
+	InsecureSkipVerify bool     `mapstructure:"insecure_skip_verify"`
+	Username           string   `mapstructure:"username"`
+	Password           string   `mapstructure:"password"`
+	From               string   `mapstructure:"from" validate:"required,email"`
+	To                 []string `mapstructure:"to" validate:"omitempty,dive,email"`
+	Subject            string   `mapstructure:"subject"`
+}
+
+type SmtpNotifier struct {
+	target  *Target
+	subject *template.Template
+}
+
+func NewSmtpNotifier(target *Target) (*SmtpNotifier, error) {
+	if target.Smtp == nil {
+		return nil, errors.New("missing smtp config")
+	}
+	content := target.Smtp.Subject
+	if content == "" {
+		content = DefaultSubjectTemplate
+	}
+	subject, err := template.New(target.Id).Funcs(templateFuncs()).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %v", err)
+	}
+	return &SmtpNotifier{target: target, subject: subject}, nil
+}
+
+// Notify sends the report of the task by mail. The recipients of the task
+// (mail_to) replace the default recipients of the target.
+func (s *SmtpNotifier) Notify(msg Message) error {
+	cfg := s.target.Smtp
+	recipients := cfg.To
+	if len(msg.MailTo) > 0 {
+		recipients = msg.MailTo
+	}
+	if len(recipients) == 0 {
+		return errors.New("no recipient defined")
+	}
+
+	subject := &bytes.Buffer{}
+	if err := s.subject.Execute(subject, msg); err != nil {
+		return fmt.Errorf("failed to render subject: %v", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), smtpTimeout)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.StartTls {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server %s does not support STARTTLS", cfg.Host)
+		}
+		if err = client.StartTLS(&tls.Config{ServerName: cfg.Host, InsecureSkipVerify: cfg.InsecureSkipVerify}); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err = client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(buildMail(cfg.From, recipients, subject.String(), msg.Report)); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMail(from string, to []string, subject string, body string) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("From: %s\r\n", from))
+	buf.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
+	buf.WriteString(fmt.Sprintf("Subject: %s\r\n", strings.ReplaceAll(subject, "\n", " ")))
+	buf.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return buf.Bytes()
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type fakeSmtpSession struct {
+	Auth       string
+	From       string
+	Recipients []string
+	Data       string
+}
+
+// startFakeSmtpServer accepts one session, records it and sends it on the returned channel.
+func startFakeSmtpServer(t *testing.T, extensions []string) (string, int, chan fakeSmtpSession) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	sessions := make(chan fakeSmtpSession, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		session := fakeSmtpSession{}
+		reader := bufio.NewReader(conn)
+		write := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		write("220 localhost ESMTP fake")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				sessions <- session
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				for _, extension := range extensions {
+					write("250-" + extension)
+				}
+				write("250 localhost")
+			case "AUTH":
+				session.Auth = line
+				write("235 2.7.0 Authentication successful")
+			case "MAIL":
+				session.From = line
+				write("250 OK")
+			case "RCPT":
+				session.Recipients = append(session.Recipients, line)
+				write("250 OK")
+			case "DATA":
+				write("354 End data with <CR><LF>.<CR><LF>")
+				data := ""
+				for {
+					dataLine, _ := reader.ReadString('\n')
+					if dataLine == ".\r\n" {
+						break
+					}
+					data += dataLine
+				}
+				session.Data = data
+				write("250 OK")
+			case "QUIT":
+				write("221 Bye")
+				sessions <- session
+				return
+			default:
+				write("502 Command not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, sessions
+}
+
+func TestSmtpNotifier_Notify(t *testing.T) {
+	host, port, sessions := startFakeSmtpServer(t, []string{"AUTH PLAIN"})
+	target := &Target{
+		Id:   "mail",
+		Type: TypeSmtp,
+		Smtp: &SmtpConfig{
+			Host:     host,
+			Port:     port,
+			Username: "user",
+			Password: "pass",
+			From:     "gtask@example.com",
+			To:       []string{"ops@example.com"},
+			Subject:  "{{ .TaskId }} is {{ .Status }} on {{ .Hostname }}",
+		},
+	}
+	notifier, err := NewSmtpNotifier(target)
+	assert.NoError(t, err)
+
+	err = notifier.Notify(Message{Event: EventFailure, TaskId: "test", Status: "failed", Hostname: "host", Report: "my report\nline 2"})
+	assert.NoError(t, err)
+
+	session := <-sessions
+	assert.Equal(t, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00user\x00pass")), session.Auth)
+	assert.Equal(t, "MAIL FROM:<gtask@example.com>", session.From)
+	assert.Equal(t, []string{"RCPT TO:<ops@example.com>"}, session.Recipients)
+	assert.Contains(t, session.Data, "Subject: test is failed on host\r\n")
+	assert.Contains(t, session.Data, "To: ops@example.com\r\n")
+	assert.Contains(t, session.Data, "\r\n\r\nmy report\r\nline 2")
+}
+
+func TestSmtpNotifier_Notify_WithTaskMailTo(t *testing.T) {
+	host, port, sessions := startFakeSmtpServer(t, []string{})
+	target := &Target{
+		Id:   "mail",
+		Type: TypeSmtp,
+		Smtp: &SmtpConfig{Host: host, Port: port, From: "gtask@example.com", To: []string{"ops@example.com"}},
+	}
+	notifier, err := NewSmtpNotifier(target)
+	assert.NoError(t, err)
+
+	err = notifier.Notify(Message{Event: EventOutput, TaskId: "test", Status: "succeed", Hostname: "host", MailTo: []string{"dev@example.com", "qa@example.com"}})
+	assert.NoError(t, err)
+
+	session := <-sessions
+	assert.Empty(t, session.Auth)
+	assert.Equal(t, []string{"RCPT TO:<dev@example.com>", "RCPT TO:<qa@example.com>"}, session.Recipients)
+	assert.Contains(t, session.Data, "Subject: [gtask] Task test succeed on host\r\n")
+}
+
+func TestSmtpNotifier_Notify_ErrorWithoutStartTls(t *testing.T) {
+	host, port, _ := startFakeSmtpServer(t, []string{})
+	target := &Target{
+		Id:   "mail",
+		Type: TypeSmtp,
+		Smtp: &SmtpConfig{Host: host, Port: port, StartTls: true, From: "gtask@example.com", To: []string{"ops@example.com"}},
+	}
+	notifier, err := NewSmtpNotifier(target)
+	assert.NoError(t, err)
+
+	err = notifier.Notify(Message{TaskId: "test"})
+	assert.Error(t, err)
+	assert.Equal(t, "server "+host+" does not support STARTTLS", err.Error())
+}
+
+func TestSmtpNotifier_Notify_ErrorWithoutRecipient(t *testing.T) {
+	notifier, err := NewSmtpNotifier(&Target{Id: "mail", Type: TypeSmtp, Smtp: &SmtpConfig{Host: "127.0.0.1", Port: 25, From: "gtask@example.com"}})
+	assert.NoError(t, err)
+
+	err = notifier.Notify(Message{TaskId: "test"})
+	assert.Error(t, err)
+	assert.Equal(t, "no recipient defined", err.Error())
+}
+
+func TestSmtpNotifier_Notify_ErrorWithConnection(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+	notifier, err := NewSmtpNotifier(&Target{Id: "mail", Type: TypeSmtp, Smtp: &SmtpConfig{Host: "127.0.0.1", Port: port, From: "gtask@example.com", To: []string{"ops@example.com"}}})
+	assert.NoError(t, err)
+
+	err = notifier.Notify(Message{TaskId: "test"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "127.0.0.1:"+strconv.Itoa(port))
+}
+
+func TestNewSmtpNotifier_Error(t *testing.T) {
+	_, err := NewSmtpNotifier(&Target{Id: "mail", Type: TypeSmtp})
+	assert.Error(t, err)
+	assert.Equal(t, "missing smtp config", err.Error())
+
+	_, err = NewSmtpNotifier(&Target{Id: "mail", Type: TypeSmtp, Smtp: &SmtpConfig{Subject: "{{ .Wrong"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid subject template")
+}
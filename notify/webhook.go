@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	DefaultWebhookTemplate = `{"event": {{ json .Event }}, "task": {{ json .TaskId }}, "status": {{ json .Status }}, "hostname": {{ json .Hostname }}, "start_at": {{ json .StartAt }}, "finish_at": {{ json .FinishAt }}, "duration": {{ json .Duration.String }}, "usage": {{ json .Usage }}, "error": {{ json .Error }}, "output": {{ json .Output }}}`
+	DefaultTextTemplate    = "[gtask] Task {{ .TaskId }} {{ .Event }} on {{ .Hostname }} with status '{{ .Status }}' ({{ .Duration }}){{ if .Error }}: {{ .Error }}{{ end }}"
+
+	httpTimeout = 10 * time.Second
+)
+
+type HttpNotifier struct {
+	target *Target
+	client *http.Client
+	tmpl   *template.Template
+}
+
+func NewHttpNotifier(target *Target) (*HttpNotifier, error) {
+	content := target.Template
+	if content == "" {
+		content = DefaultTextTemplate
+		if target.Type == TypeWebhook {
+			content = DefaultWebhookTemplate
+		}
+	}
+	tmpl, err := template.New(target.Id).Funcs(templateFuncs()).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return &HttpNotifier{target: target, client: &http.Client{Timeout: httpTimeout}, tmpl: tmpl}, nil
+}
+
+func (h *HttpNotifier) Notify(msg Message) error {
+	body, err := h.payload(msg)
+	if err != nil {
+		return err
+	}
+
+	method := h.target.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, h.target.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.target.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, h.target.Url)
+	}
+	return nil
+}
+
+func (h *HttpNotifier) payload(msg Message) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := h.tmpl.Execute(buf, msg); err != nil {
+		return nil, fmt.Errorf("failed to render template: %v", err)
+	}
+
+	switch h.target.Type {
+	case TypeSlack:
+		return json.Marshal(map[string]string{"text": buf.String()})
+	case TypeTeams:
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"themeColor": themeColor(msg.Event),
+			"summary":    fmt.Sprintf("Task %s %s", msg.TaskId, msg.Event),
+			"title":      fmt.Sprintf("Task %s %s", msg.TaskId, msg.Event),
+			"text":       buf.String(),
+		})
+	default:
+		return buf.Bytes(), nil
+	}
+}
+
+func themeColor(event Event) string {
+	switch event {
+	case EventSuccess, EventRecovery:
+		return "2EB886"
+	default:
+		return "D00000"
+	}
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"encoding/json"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpNotifier_Notify(t *testing.T) {
+	msg := Message{
+		Event:    EventFailure,
+		TaskId:   "test",
+		Status:   "failed",
+		Hostname: "host",
+		Output:   "my \"output\"",
+		Error:    "exit status 1",
+		StartAt:  time.Date(2023, time.January, 25, 15, 4, 0, 0, time.UTC),
+		FinishAt: time.Date(2023, time.January, 25, 15, 4, 5, 0, time.UTC),
+		Duration: 5 * time.Second,
+		Usage:    &types.Usage{MaxRSS: 2048, UserTime: 1500 * time.Millisecond, SystemTime: 250 * time.Millisecond, VoluntaryContextSwitches: 12, InvoluntaryContextSwitches: 3},
+	}
+	tests := []struct {
+		name       string
+		target     Target
+		wantMethod string
+		wantHeader map[string]string
+		want       map[string]any
+	}{
+		{
+			name:       "SuccessWebhookDefaultTemplate",
+			target:     Target{Id: "hook", Type: TypeWebhook},
+			wantMethod: http.MethodPost,
+			want: map[string]any{
+				"event":     "failure",
+				"task":      "test",
+				"status":    "failed",
+				"hostname":  "host",
+				"start_at":  "2023-01-25T15:04:00Z",
+				"finish_at": "2023-01-25T15:04:05Z",
+				"duration":  "5s",
+				"usage": map[string]any{
+					"max_rss_bytes":                float64(2048),
+					"user_cpu_seconds":             1.5,
+					"system_cpu_seconds":           0.25,
+					"voluntary_context_switches":   float64(12),
+					"involuntary_context_switches": float64(3),
+				},
+				"error":  "exit status 1",
+				"output": "my \"output\"",
+			},
+		},
+		{
+			name: "SuccessWebhookCustomTemplate",
+			target: Target{
+				Id:       "hook",
+				Type:     TypeWebhook,
+				Method:   http.MethodPut,
+				Headers:  map[string]string{"authorization": "Bearer token"},
+				Template: `{"summary": {{ json (printf "%s is %s" .TaskId .Status) }}}`,
+			},
+			wantMethod: http.MethodPut,
+			wantHeader: map[string]string{"Authorization": "Bearer token"},
+			want:       map[string]any{"summary": "test is failed"},
+		},
+		{
+			name:       "SuccessSlack",
+			target:     Target{Id: "slack", Type: TypeSlack},
+			wantMethod: http.MethodPost,
+			want:       map[string]any{"text": "[gtask] Task test failure on host with status 'failed' (5s): exit status 1"},
+		},
+		{
+			name:       "SuccessTeams",
+			target:     Target{Id: "teams", Type: TypeTeams, Template: "{{ .TaskId }} {{ .Event }}"},
+			wantMethod: http.MethodPost,
+			want: map[string]any{
+				"@type":      "MessageCard",
+				"@context":   "https://schema.org/extensions",
+				"themeColor": "D00000",
+				"summary":    "Task test failure",
+				"title":      "Task test failure",
+				"text":       "test failure",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod string
+			var gotHeader http.Header
+			got := map[string]any{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod = r.Method
+				gotHeader = r.Header
+				body, _ := io.ReadAll(r.Body)
+				assert.NoError(t, json.Unmarshal(body, &got), string(body))
+			}))
+			defer server.Close()
+
+			tt.target.Url = server.URL
+			notifier, err := NewHttpNotifier(&tt.target)
+			assert.NoError(t, err)
+			assert.NoError(t, notifier.Notify(msg))
+			assert.Equal(t, tt.wantMethod, gotMethod)
+			assert.Equal(t, "application/json", gotHeader.Get("Content-Type"))
+			for key, value := range tt.wantHeader {
+				assert.Equal(t, value, gotHeader.Get(key))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHttpNotifier_Notify_ErrorWithStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier, err := NewHttpNotifier(&Target{Id: "hook", Type: TypeWebhook, Url: server.URL})
+	assert.NoError(t, err)
+	err = notifier.Notify(Message{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 400")
+}
+
+func TestHttpNotifier_Notify_ErrorWithTemplate(t *testing.T) {
+	notifier, err := NewHttpNotifier(&Target{Id: "hook", Type: TypeWebhook, Url: "http://localhost", Template: "{{ .Result.Output.String }}"})
+	assert.NoError(t, err)
+	err = notifier.Notify(Message{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to render template")
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/types"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DryRun returns what Run would do at ref for each task, without running anything.
+func DryRun(ctx *context.Context, ref time.Time, taskFilter []string, force bool) []*types.TaskPlan {
+	anchor := EveryAnchor(ctx, time.Unix(0, 0))
+	dueTasks := []string{}
+	for _, task := range ctx.Config.Scheduled {
+		if len(taskFilter) != 0 && !slices.Contains(taskFilter, task.Id) {
+			continue
+		}
+		if isDue(task, ref, anchor) || force {
+			dueTasks = append(dueTasks, task.Id)
+		}
+	}
+
+	selected := selectTasks(ctx.Config.Scheduled, dueTasks, taskFilter)
+	plans := []*types.TaskPlan{}
+	for _, task := range ctx.Config.Scheduled {
+		switch {
+		case len(taskFilter) != 0 && !slices.Contains(taskFilter, task.Id):
+			plans = append(plans, &types.TaskPlan{Task: task, Action: types.PlanFilter, Reason: fmt.Sprintf("not in the filter %v", taskFilter)})
+		case !slices.Contains(selected, task):
+			reason := fmt.Sprintf("not due at %s", ref.Format(time.RFC3339))
+			if task.CronExpr == "" {
+				reason = fmt.Sprintf("none of %s is due at %s", strings.Join(task.DependsOn, ", "), ref.Format(time.RFC3339))
+			}
+			plans = append(plans, &types.TaskPlan{Task: task, Action: types.PlanSkip, Reason: reason})
+		default:
+			plans = append(plans, planTask(ctx, task, ref, dueTasks))
+		}
+	}
+	return plans
+}
+
+// planTask returns what executeTask would do with a selected task.
+func planTask(ctx *context.Context, task *types.ScheduledTask, ref time.Time, dueTasks []string) *types.TaskPlan {
+	if reason, excluded := excludedBy(ctx, task, ref); excluded {
+		return &types.TaskPlan{Task: task, Action: types.PlanSkip, Reason: reason}
+	}
+	plan := task.Plan()
+	if plan.Action == types.PlanRun && !slices.Contains(dueTasks, task.Id) {
+		plan.Reason = fmt.Sprintf("after %s, when they succeed", strings.Join(task.DependsOn, ", "))
+	}
+	return plan
+}
+
+// printPlans prints to out what the due tasks and the tasks which depend on them would do (dry run of Start).
+func printPlans(ctx *context.Context, out io.Writer, ref time.Time, dueTasks []string, taskFilter []string) {
+	for _, task := range selectTasks(ctx.Config.Scheduled, dueTasks, taskFilter) {
+		_, _ = fmt.Fprintln(out, FormatTaskPlan(ref, planTask(ctx, task, ref, dueTasks)))
+	}
+}
+
+func FormatTaskPlan(ref time.Time, plan *types.TaskPlan) string {
+	var verb string
+	switch plan.Action {
+	case types.PlanRun:
+		verb = "would run"
+	case types.PlanSkip:
+		verb = "would be skipped"
+	case types.PlanFail:
+		verb = "would fail"
+	case types.PlanFilter:
+		verb = "is filtered out"
+	}
+
+	var reasonStr = ""
+	var commandStr = ""
+	if plan.Reason != "" {
+		reasonStr = fmt.Sprintf("Reason: %s\n", plan.Reason)
+	}
+	if plan.Action == types.PlanRun {
+		args := make([]string, len(plan.Args))
+		for i, arg := range plan.Args {
+			args[i] = fmt.Sprintf("%q", arg)
+		}
+		env := slices.Clone(plan.Env)
+		sort.Strings(env)
+		userStr := ""
+		if plan.User != "" {
+			userStr = fmt.Sprintf("user: %s\n", plan.User)
+		}
+		commandStr = fmt.Sprintf(
+			"argv: [%s]\ndirectory: %s\n%senvironment:\n  %s\n",
+			strings.Join(args, ", "),
+			plan.Directory,
+			userStr,
+			strings.Join(env, "\n  "),
+		)
+	}
+	return plan.Task.Mask(fmt.Sprintf(
+		"%s\nTask %s %s at %s (dry run)\n%s%s%s\n",
+		BlocSeparator,
+		plan.Task.Id,
+		verb,
+		ref.Format("2006-01-02T15:04:05 MST"),
+		reasonStr,
+		commandStr,
+		BlocSeparator,
+	))
+}
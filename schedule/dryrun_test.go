@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"github.com/alexandreh2ag/go-task/calendar"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Setenv("DRY_RUN_ENV", "prod")
+	ctx := context.TestContext(io.Discard)
+	calendars, err := calendar.Load(ctx.Fs, calendar.Configs{{Id: "holidays", Dates: []string{"2023-01-25"}}}, ctx.Logger)
+	assert.NoError(t, err)
+	ctx.Calendars = calendars
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		{Id: "export", CronExpr: "0 2 * * *", Command: "echo ${GTASK_ID} 'hello world'", Expression: "DRY_RUN_ENV == \"prod\""},
+		{Id: "transform", Command: "echo", DependsOn: []string{"export"}},
+		{Id: "staging", CronExpr: "0 2 * * *", Command: "echo", Expression: "DRY_RUN_ENV == \"staging\""},
+		{Id: "wrong", CronExpr: "0 2 * * *", Command: "echo", Expression: "DRY_RUN_ENV =="},
+		{Id: "holidays", CronExpr: "0 2 * * *", Command: "echo", ExcludeCalendars: []string{"holidays"}},
+		{Id: "later", CronExpr: "0 3 * * *", Command: "echo"},
+		{Id: "report", Command: "echo", DependsOn: []string{"later"}},
+		{Id: "filtered", CronExpr: "0 2 * * *", Command: "echo"},
+	}
+	_ = types.PrepareScheduledTasks(ctx.Fs, ctx.Config.Scheduled, slog.New(slog.NewTextHandler(io.Discard, nil)), types.ScheduledDefaults{Directory: "/tmp"}, map[string]string{}, nil, nil)
+	ref := time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)
+
+	got := DryRun(ctx, ref, []string{"export", "transform", "staging", "wrong", "holidays", "later", "report"}, false)
+	want := []struct {
+		action string
+		reason string
+	}{
+		{action: types.PlanRun},
+		{action: types.PlanRun, reason: "after export, when they succeed"},
+		{action: types.PlanSkip, reason: "condition `DRY_RUN_ENV == \"staging\"` is false"},
+		{action: types.PlanFail, reason: "failed to evaluate expression for wrong"},
+		{action: types.PlanSkip, reason: "excluded by calendar holidays (date 2023-01-25)"},
+		{action: types.PlanSkip, reason: "not due at 2023-01-25T02:00:00Z"},
+		{action: types.PlanSkip, reason: "none of later is due at 2023-01-25T02:00:00Z"},
+		{action: types.PlanFilter, reason: "not in the filter"},
+	}
+	assert.Len(t, got, len(want))
+	for i, plan := range got {
+		assert.Equal(t, ctx.Config.Scheduled[i], plan.Task)
+		assert.Equal(t, want[i].action, plan.Action, plan.Task.Id)
+		assert.Contains(t, plan.Reason, want[i].reason, plan.Task.Id)
+	}
+	assert.Equal(t, []string{"echo", "export", "hello world"}, got[0].Args)
+	assert.Equal(t, "/tmp", got[0].Directory)
+	assert.Contains(t, got[0].Env, "DRY_RUN_ENV=prod")
+	assert.Nil(t, got[0].Task.LatestTaskResult)
+
+	got = DryRun(ctx, ref, []string{"later"}, true)
+	assert.Equal(t, types.PlanRun, got[5].Action)
+}
+
+func TestFormatTaskPlan(t *testing.T) {
+	ref := time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)
+	task := &types.ScheduledTask{Id: "export"}
+	tests := []struct {
+		name string
+		plan *types.TaskPlan
+		want string
+	}{
+		{
+			name: "Run",
+			plan: &types.TaskPlan{Task: task, Action: types.PlanRun, Args: []string{"echo", "hello world"}, Directory: "/tmp", Env: []string{"B=2", "A=1"}},
+			want: BlocSeparator + "\nTask export would run at 2023-01-25T02:00:00 UTC (dry run)\nargv: [\"echo\", \"hello world\"]\ndirectory: /tmp\nenvironment:\n  A=1\n  B=2\n" + BlocSeparator + "\n",
+		},
+		{
+			name: "RunAsUser",
+			plan: &types.TaskPlan{Task: task, Action: types.PlanRun, Args: []string{"id"}, Directory: "/tmp", User: "nobody (uid 65534, gid 65534, groups [65534])", Env: []string{"HOME=/nonexistent"}},
+			want: BlocSeparator + "\nTask export would run at 2023-01-25T02:00:00 UTC (dry run)\nargv: [\"id\"]\ndirectory: /tmp\nuser: nobody (uid 65534, gid 65534, groups [65534])\nenvironment:\n  HOME=/nonexistent\n" + BlocSeparator + "\n",
+		},
+		{
+			name: "Skip",
+			plan: &types.TaskPlan{Task: task, Action: types.PlanSkip, Reason: "not due"},
+			want: BlocSeparator + "\nTask export would be skipped at 2023-01-25T02:00:00 UTC (dry run)\nReason: not due\n" + BlocSeparator + "\n",
+		},
+		{
+			name: "Fail",
+			plan: &types.TaskPlan{Task: task, Action: types.PlanFail, Reason: "wrong"},
+			want: BlocSeparator + "\nTask export would fail at 2023-01-25T02:00:00 UTC (dry run)\nReason: wrong\n" + BlocSeparator + "\n",
+		},
+		{
+			name: "Filter",
+			plan: &types.TaskPlan{Task: task, Action: types.PlanFilter, Reason: "not in the filter [test]"},
+			want: BlocSeparator + "\nTask export is filtered out at 2023-01-25T02:00:00 UTC (dry run)\nReason: not in the filter [test]\n" + BlocSeparator + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatTaskPlan(ref, tt.plan))
+		})
+	}
+}
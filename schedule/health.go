@@ -0,0 +1,26 @@
+package schedule
+
+import (
+	"encoding/json"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/leader"
+	"net/http"
+)
+
+type Health struct {
+	Status     string         `json:"status"`
+	Leadership *leader.Status `json:"leadership,omitempty"`
+}
+
+// HealthHandler returns the state of the daemon in JSON, with its leadership when the leader election is enabled.
+func HealthHandler(ctx *context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := Health{Status: "ok"}
+		if ctx.Leadership != nil {
+			status := ctx.Leadership.Status()
+			health.Leadership = &status
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(health)
+	})
+}
@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/leader"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeElector struct {
+	isLeader bool
+}
+
+func (f *fakeElector) Campaign() (bool, error) {
+	return f.isLeader, nil
+}
+
+func (f *fakeElector) Resign() error {
+	return nil
+}
+
+func TestHealthHandler(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	clock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC))
+
+	recorder := httptest.NewRecorder()
+	HealthHandler(ctx).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status": "ok"}`, recorder.Body.String())
+
+	ctx.Leadership = leader.NewLeadership(&fakeElector{isLeader: true}, "me", clock, ctx.Logger)
+	ctx.Leadership.Check()
+	recorder = httptest.NewRecorder()
+	HealthHandler(ctx).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.JSONEq(
+		t,
+		`{"status": "ok", "leadership": {"identity": "me", "leader": true, "leader_since": "2023-01-25T02:00:00Z", "last_check": "2023-01-25T02:00:00Z"}}`,
+		recorder.Body.String(),
+	)
+}
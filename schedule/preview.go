@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/cron"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Preview is the schedule of a task with its next fire times.
+type Preview struct {
+	Id          string      `json:"id"`
+	Expr        string      `json:"expr"`
+	Description string      `json:"description"`
+	Timezone    string      `json:"timezone"`
+	Condition   string      `json:"condition"`
+	DependsOn   []string    `json:"depends_on,omitempty"`
+	Next        []time.Time `json:"next"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// PreviewTasks computes the count next fire times of the tasks after from, @every is anchored at from (or every_epoch).
+func PreviewTasks(ctx *context.Context, from time.Time, count int, taskFilter []string) []Preview {
+	anchor := EveryAnchor(ctx, from)
+	previews := []Preview{}
+	for _, task := range ctx.Config.Scheduled {
+		if len(taskFilter) != 0 && !slices.Contains(taskFilter, task.Id) {
+			continue
+		}
+		preview := Preview{Id: task.Id, Expr: task.CronExpr, Condition: task.Expression, DependsOn: task.DependsOn, Next: []time.Time{}}
+		location, err := task.GetLocation(from.Location())
+		if err != nil {
+			preview.Error = err.Error()
+			previews = append(previews, preview)
+			continue
+		}
+		preview.Timezone = location.String()
+
+		if task.CronExpr == "" {
+			preview.Description = fmt.Sprintf("after %s", strings.Join(task.DependsOn, ", "))
+			previews = append(previews, preview)
+			continue
+		}
+		preview.Description, err = cron.Describe(task.CronExpr)
+		if err == nil {
+			preview.Next, err = nextFireTimes(task.CronExpr, anchor, from.In(location), count)
+		}
+		if err != nil {
+			preview.Error = err.Error()
+		}
+		previews = append(previews, preview)
+	}
+	return previews
+}
+
+func nextFireTimes(expr string, anchor time.Time, from time.Time, count int) ([]time.Time, error) {
+	schedule, err := cron.Parse(expr, anchor)
+	if err != nil {
+		return []time.Time{}, err
+	}
+	next := []time.Time{}
+	for t := from; len(next) < count; {
+		var ok bool
+		t, ok, err = schedule.Next(t)
+		if err != nil || !ok {
+			return next, err
+		}
+		next = append(next, t)
+	}
+	return next, nil
+}
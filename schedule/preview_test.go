@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestPreviewTasks(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	ctx := context.TestContext(io.Discard)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "weekdays", CronExpr: "*/5 * * * 1-5", Expression: "ENV == prod"},
+		&types.ScheduledTask{Id: "paris", CronExpr: "30 2 * * *", Timezone: "Europe/Paris"},
+		&types.ScheduledTask{Id: "dependent", DependsOn: []string{"weekdays"}},
+		&types.ScheduledTask{Id: "every", CronExpr: "@every 90s"},
+		&types.ScheduledTask{Id: "reboot", CronExpr: "@reboot"},
+		&types.ScheduledTask{Id: "never", CronExpr: "0 0 30 2 *"},
+		&types.ScheduledTask{Id: "wrong", CronExpr: "* * * * *", Timezone: "Europe/Wrong"},
+	}
+	from := time.Date(2023, time.March, 25, 23, 50, 0, 0, time.UTC)
+	want := []Preview{
+		{
+			Id: "weekdays", Expr: "*/5 * * * 1-5", Description: "every 5 minutes, Monday to Friday", Timezone: "UTC", Condition: "ENV == prod",
+			Next: []time.Time{time.Date(2023, time.March, 27, 0, 0, 0, 0, time.UTC), time.Date(2023, time.March, 27, 0, 5, 0, 0, time.UTC)},
+		},
+		{
+			Id: "paris", Expr: "30 2 * * *", Description: "every day at 02:30", Timezone: "Europe/Paris",
+			Next: []time.Time{time.Date(2023, time.March, 26, 3, 0, 0, 0, paris), time.Date(2023, time.March, 27, 2, 30, 0, 0, paris)},
+		},
+		{Id: "dependent", Description: "after weekdays", Timezone: "UTC", DependsOn: []string{"weekdays"}, Next: []time.Time{}},
+		{
+			Id: "every", Expr: "@every 90s", Description: "every 1m30s", Timezone: "UTC",
+			Next: []time.Time{from.Add(90 * time.Second), from.Add(180 * time.Second)},
+		},
+		{Id: "reboot", Expr: "@reboot", Description: "when the daemon starts", Timezone: "UTC", Next: []time.Time{}},
+		{Id: "never", Expr: "0 0 30 2 *", Description: "on day 30 of the month, in February at 00:00", Timezone: "UTC", Next: []time.Time{}, Error: "no fire time of '0 0 30 2 *' in 100 years"},
+		{Id: "wrong", Expr: "* * * * *", Next: []time.Time{}, Error: "unknown time zone Europe/Wrong"},
+	}
+
+	got := PreviewTasks(ctx, from, 2, []string{})
+	assert.Equal(t, len(want), len(got))
+	for i := range want {
+		assert.Equal(t, want[i].Id, got[i].Id)
+		assert.Equal(t, want[i].Description, got[i].Description, want[i].Id)
+		assert.Equal(t, want[i].Timezone, got[i].Timezone, want[i].Id)
+		assert.Equal(t, want[i].Condition, got[i].Condition, want[i].Id)
+		assert.Equal(t, want[i].DependsOn, got[i].DependsOn, want[i].Id)
+		assert.Equal(t, want[i].Error, got[i].Error, want[i].Id)
+		assert.Equal(t, len(want[i].Next), len(got[i].Next), want[i].Id)
+		for j := range want[i].Next {
+			assert.True(t, want[i].Next[j].Equal(got[i].Next[j]), "%s: %s != %s", want[i].Id, want[i].Next[j], got[i].Next[j])
+		}
+	}
+
+	got = PreviewTasks(ctx, from, 1, []string{"paris"})
+	assert.Len(t, got, 1)
+	assert.Equal(t, "paris", got[0].Id)
+}
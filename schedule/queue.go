@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/cron"
+	"github.com/alexandreh2ag/go-task/types"
+	"slices"
+	"time"
+)
+
+type queueItem struct {
+	task     *types.ScheduledTask
+	schedule cron.Schedule
+	location *time.Location
+	next     time.Time
+	order    int
+}
+
+type queueItems []*queueItem
+
+func (q queueItems) Len() int { return len(q) }
+
+func (q queueItems) Less(i, j int) bool {
+	if q[i].next.Equal(q[j].next) {
+		return q[i].order < q[j].order
+	}
+	return q[i].next.Before(q[j].next)
+}
+
+func (q queueItems) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *queueItems) Push(x any) { *q = append(*q, x.(*queueItem)) }
+
+func (q *queueItems) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// DueTasks are the tasks which must run at the same time.
+type DueTasks struct {
+	At  time.Time
+	Ids []string
+}
+
+// Queue keeps the next fire time of each task, ordered by time (then by order in config).
+// The due tasks are returned in the location of the queue, whatever the timezone of the tasks.
+type Queue struct {
+	items    queueItems
+	location *time.Location
+}
+
+// NewQueue computes the first fire time of the tasks after now, anchor is the start of the intervals of @every.
+// The fire times of a task are computed in its timezone, the location of now otherwise.
+func NewQueue(tasks types.ScheduledTasks, now time.Time, anchor time.Time, taskFilter []string) *Queue {
+	q := &Queue{items: queueItems{}, location: now.Location()}
+	for order, task := range tasks {
+		if task.CronExpr == "" || (len(taskFilter) != 0 && !slices.Contains(taskFilter, task.Id)) {
+			continue
+		}
+		schedule, err := cron.Parse(task.CronExpr, anchor)
+		if err != nil {
+			task.Logger.Error(fmt.Sprintf("Scheduled task %s fail to compute next run: %v", task.Id, err))
+			continue
+		}
+		location, err := task.GetLocation(now.Location())
+		if err != nil {
+			task.Logger.Error(fmt.Sprintf("Scheduled task %s fail to load timezone: %v", task.Id, err))
+			continue
+		}
+		item := &queueItem{task: task, schedule: schedule, location: location, order: order}
+		if item.reschedule(now, false) {
+			q.items = append(q.items, item)
+		}
+	}
+	heap.Init(&q.items)
+	return q
+}
+
+// Next returns the earliest fire time.
+func (q *Queue) Next() (time.Time, bool) {
+	if len(q.items) == 0 {
+		return time.Time{}, false
+	}
+	return q.items[0].next, true
+}
+
+// PopDue returns the tasks due until now grouped by fire time and computes their next fire time.
+// Each fire time is returned once, the fire times missed before now (suspended host...) are skipped.
+func (q *Queue) PopDue(now time.Time) []DueTasks {
+	now = now.Truncate(time.Second)
+	due := []DueTasks{}
+	for len(q.items) > 0 && !q.items[0].next.After(now) {
+		item := q.items[0]
+		if len(due) == 0 || !due[len(due)-1].At.Equal(item.next) {
+			due = append(due, DueTasks{At: item.next.In(q.location)})
+		}
+		due[len(due)-1].Ids = append(due[len(due)-1].Ids, item.task.Id)
+
+		if !item.reschedule(item.next, false) {
+			heap.Pop(&q.items)
+			continue
+		}
+		if item.next.Before(now) {
+			item.task.Logger.Warn(fmt.Sprintf("Scheduled task %s missed runs between %s and %s", item.task.Id, item.next.Format(time.DateTime), now.Format(time.DateTime)))
+			if !item.reschedule(now, true) {
+				heap.Pop(&q.items)
+				continue
+			}
+		}
+		heap.Fix(&q.items, 0)
+	}
+	return due
+}
+
+// reschedule computes the next fire time after t (or at t when included), it returns false when the task does not fire anymore.
+func (i *queueItem) reschedule(t time.Time, included bool) bool {
+	t = t.In(i.location)
+	if included {
+		if due, err := i.schedule.IsDue(t); err == nil && due {
+			i.next = t
+			return true
+		}
+	}
+	next, ok, err := i.schedule.Next(t)
+	if err != nil {
+		i.task.Logger.Error(fmt.Sprintf("Scheduled task %s fail to compute next run: %v", i.task.Id, err))
+		return false
+	}
+	i.next = next
+	return ok
+}
@@ -0,0 +1,140 @@
+package schedule
+
+import (
+	"bytes"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestNewQueue(t *testing.T) {
+	b := bytes.NewBufferString("")
+	logger := slog.New(slog.NewTextHandler(b, nil))
+	now := time.Date(2023, time.January, 25, 15, 4, 13, 0, time.UTC)
+	tasks := types.ScheduledTasks{
+		&types.ScheduledTask{Id: "hourly", CronExpr: "0 * * * *", Logger: logger},
+		&types.ScheduledTask{Id: "seconds", CronExpr: "*/15 * * * * *", Logger: logger},
+		&types.ScheduledTask{Id: "dependent", DependsOn: []string{"hourly"}, Logger: logger},
+		&types.ScheduledTask{Id: "wrong", CronExpr: "0 0 30 2 *", Logger: logger},
+	}
+	tests := []struct {
+		name       string
+		taskFilter []string
+		want       time.Time
+		wantFound  bool
+	}{
+		{
+			name:      "SuccessEarliest",
+			want:      time.Date(2023, time.January, 25, 15, 4, 15, 0, time.UTC),
+			wantFound: true,
+		},
+		{
+			name:       "SuccessWithTaskFilter",
+			taskFilter: []string{"hourly"},
+			want:       time.Date(2023, time.January, 25, 16, 0, 0, 0, time.UTC),
+			wantFound:  true,
+		},
+		{
+			name:       "SuccessEmpty",
+			taskFilter: []string{"dependent", "wrong"},
+			wantFound:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := NewQueue(tasks, now, now, tt.taskFilter).Next()
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Contains(t, b.String(), "Scheduled task wrong fail to compute next run")
+}
+
+func TestQueue_PopDue(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC)
+	tasks := types.ScheduledTasks{
+		&types.ScheduledTask{Id: "minute", CronExpr: "* * * * *", Logger: logger},
+		&types.ScheduledTask{Id: "seconds", CronExpr: "*/30 * * * * *", Logger: logger},
+		&types.ScheduledTask{Id: "five", CronExpr: "*/5 * * * *", Logger: logger},
+	}
+	queue := NewQueue(tasks, start, start, []string{})
+
+	assert.Equal(t, []DueTasks{}, queue.PopDue(start.Add(29*time.Second)))
+	assert.Equal(
+		t,
+		[]DueTasks{{At: start.Add(30 * time.Second), Ids: []string{"seconds"}}},
+		queue.PopDue(start.Add(30*time.Second+500*time.Millisecond)),
+	)
+	next, _ := queue.Next()
+	assert.Equal(t, start.Add(time.Minute), next)
+
+	// tasks due at the same time are grouped
+	assert.Equal(
+		t,
+		[]DueTasks{{At: start.Add(time.Minute), Ids: []string{"minute", "seconds"}}},
+		queue.PopDue(start.Add(time.Minute)),
+	)
+	next, _ = queue.Next()
+	assert.Equal(t, start.Add(90*time.Second), next)
+
+	assert.Equal(
+		t,
+		[]DueTasks{
+			{At: start.Add(90 * time.Second), Ids: []string{"seconds"}},
+			{At: start.Add(2 * time.Minute), Ids: []string{"minute", "seconds"}},
+		},
+		queue.PopDue(start.Add(2*time.Minute)),
+	)
+	next, _ = queue.Next()
+	assert.Equal(t, start.Add(150*time.Second), next)
+}
+
+func TestQueue_PopDueSkipMissed(t *testing.T) {
+	b := bytes.NewBufferString("")
+	logger := slog.New(slog.NewTextHandler(b, nil))
+	start := time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC)
+	tasks := types.ScheduledTasks{
+		&types.ScheduledTask{Id: "minute", CronExpr: "* * * * *", Logger: logger},
+	}
+	queue := NewQueue(tasks, start, start, []string{})
+
+	// the host was suspended during one hour
+	assert.Equal(
+		t,
+		[]DueTasks{
+			{At: start.Add(time.Minute), Ids: []string{"minute"}},
+			{At: start.Add(time.Hour), Ids: []string{"minute"}},
+		},
+		queue.PopDue(start.Add(time.Hour)),
+	)
+	next, _ := queue.Next()
+	assert.Equal(t, start.Add(61*time.Minute), next)
+	assert.Contains(t, b.String(), "Scheduled task minute missed runs between 2023-01-25 15:02:00 and 2023-01-25 16:00:00")
+}
+
+func TestQueue_Descriptors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Date(2023, time.January, 25, 15, 0, 10, 0, time.UTC)
+	tasks := types.ScheduledTasks{
+		&types.ScheduledTask{Id: "reboot", CronExpr: "@reboot", Logger: logger},
+		&types.ScheduledTask{Id: "every", CronExpr: "@every 90s", Logger: logger},
+		&types.ScheduledTask{Id: "hourly", CronExpr: "@hourly", Logger: logger},
+	}
+	queue := NewQueue(tasks, start, start, []string{})
+
+	next, _ := queue.Next()
+	assert.Equal(t, start.Add(90*time.Second), next)
+	assert.Equal(t, []DueTasks{{At: start.Add(90 * time.Second), Ids: []string{"every"}}}, queue.PopDue(next))
+	next, _ = queue.Next()
+	assert.Equal(t, start.Add(180*time.Second), next)
+
+	assert.Equal(
+		t,
+		[]DueTasks{{At: time.Date(2023, time.January, 25, 16, 0, 0, 0, time.UTC), Ids: []string{"hourly"}}},
+		NewQueue(tasks, start, start, []string{"reboot", "hourly"}).PopDue(time.Date(2023, time.January, 25, 16, 0, 0, 0, time.UTC)),
+	)
+}
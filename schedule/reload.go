@@ -0,0 +1,59 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/spf13/afero"
+	"time"
+)
+
+// Reloader loads the config again in a copy of the context, validated and prepared like at start.
+// Start reloads on SIGHUP and, when Watch is set, when the file at Path changes (checked at each Watch).
+type Reloader struct {
+	Load  func() (*context.Context, error)
+	Path  string
+	Watch time.Duration
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (r *Reloader) stat(fs afero.Fs) fileState {
+	info, err := fs.Stat(r.Path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// reload returns the context loaded, with the latest results of the tasks carried over by id.
+// The dispatcher of the notifications is kept with the targets of the new config, to keep the last status of the tasks.
+// The current context is kept when the new config is not valid.
+func reload(ctx *context.Context, reloader *Reloader) (*context.Context, bool) {
+	next, err := reloader.Load()
+	if err != nil {
+		ctx.Logger.Error(fmt.Sprintf("failed to reload config, the current one is kept: %v", err))
+		return ctx, false
+	}
+
+	latest := map[string]*types.TaskResult{}
+	for _, task := range ctx.Config.Scheduled {
+		if result := task.GetLatestTaskResult(); result != nil {
+			latest[task.Id] = result
+		}
+	}
+	for _, task := range next.Config.Scheduled {
+		if result, ok := latest[task.Id]; ok {
+			task.SetLatestTaskResult(result)
+		}
+	}
+	if ctx.Notifier != nil && next.Notifier != nil {
+		ctx.Notifier.UpdateTargets(next.Notifier)
+		next.Notifier = ctx.Notifier
+	}
+	next.Logger.Info(fmt.Sprintf("config reloaded with %d scheduled tasks", len(next.Config.Scheduled)))
+	return next, true
+}
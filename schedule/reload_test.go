@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/config"
+	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/notify"
+	"github.com/alexandreh2ag/go-task/types"
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// loadTasks returns a loader of a copy of ctx with the tasks.
+func loadTasks(ctx *context.Context, tasks types.ScheduledTasks) func() (*context.Context, error) {
+	return func() (*context.Context, error) {
+		next := *ctx
+		cfg := config.DefaultConfig()
+		cfg.Scheduled = tasks
+		next.Config = &cfg
+		_ = types.PrepareScheduledTasks(ctx.Fs, cfg.Scheduled, ctx.Logger, types.ScheduledDefaults{}, map[string]string{}, nil, nil)
+		return &next, nil
+	}
+}
+
+func Test_reload(t *testing.T) {
+	b := bytes.NewBufferString("")
+	ctx := context.TestContext(b)
+	result := &types.TaskResult{Status: types.Succeed}
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "kept", Command: "echo", CronExpr: "* * * * *", LatestTaskResult: result},
+		&types.ScheduledTask{Id: "removed", Command: "echo", CronExpr: "* * * * *", LatestTaskResult: &types.TaskResult{}},
+	}
+
+	got, ok := reload(ctx, &Reloader{Load: loadTasks(ctx, types.ScheduledTasks{
+		&types.ScheduledTask{Id: "kept", Command: "echo new", CronExpr: "0 * * * *"},
+		&types.ScheduledTask{Id: "added", Command: "echo", CronExpr: "* * * * *"},
+	})})
+	assert.True(t, ok)
+	assert.NotSame(t, ctx, got)
+	assert.Len(t, got.Config.Scheduled, 2)
+	assert.Equal(t, "echo new", got.Config.Scheduled[0].Command)
+	assert.Same(t, result, got.Config.Scheduled[0].LatestTaskResult)
+	assert.Nil(t, got.Config.Scheduled[1].LatestTaskResult)
+	assert.Contains(t, b.String(), "config reloaded with 2 scheduled tasks")
+
+	ctx.Notifier, _ = notify.NewDispatcher(notify.Targets{}, ctx.Logger)
+	reloaded, _ := notify.NewDispatcher(notify.Targets{}, ctx.Logger)
+	got, ok = reload(ctx, &Reloader{Load: func() (*context.Context, error) {
+		next := *ctx
+		next.Notifier = reloaded
+		return &next, nil
+	}})
+	assert.True(t, ok)
+	assert.Same(t, ctx.Notifier, got.Notifier)
+
+	got, ok = reload(ctx, &Reloader{Load: func() (*context.Context, error) { return nil, errors.New("configuration file is not valid") }})
+	assert.False(t, ok)
+	assert.Same(t, ctx, got)
+	assert.Contains(t, b.String(), "failed to reload config, the current one is kept: configuration file is not valid")
+}
+
+func TestStart_SuccessReloadWhenConfigFileChanges(t *testing.T) {
+	b := &syncBuffer{}
+	ctx := context.TestContext(b)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
+	ctx.Clock = fakeClock
+	_ = afero.WriteFile(ctx.Fs, "/gtask.yml", []byte("scheduled: []"), 0644)
+	output := path.Join(t.TempDir(), "output")
+	result := &types.TaskResult{Status: types.Succeed}
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "daily", Command: "echo", CronExpr: "0 12 * * *", Logger: ctx.Logger, LatestTaskResult: result},
+	}
+	tasks := types.ScheduledTasks{
+		&types.ScheduledTask{Id: "daily", Command: "echo", CronExpr: "0 12 * * *"},
+		&types.ScheduledTask{Id: "seconds", Command: fmt.Sprintf("sh -c 'echo seconds >> %s'", output), CronExpr: "*/15 * * * * *"},
+	}
+	reloader := &Reloader{Load: loadTasks(ctx, tasks), Path: "/gtask.yml", Watch: 7 * time.Second}
+
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, 0, "", []string{}, reloader, 0, nil, true, "") }()
+
+	// the file did not change
+	fakeClock.BlockUntil(2)
+	fakeClock.Advance(7 * time.Second)
+	fakeClock.BlockUntil(2)
+	assert.NotContains(t, b.String(), "reloading")
+
+	// the fire time missed since the last run (00:00:15) runs at once
+	_ = afero.WriteFile(ctx.Fs, "/gtask.yml", []byte("scheduled: [{id: 'seconds'}]"), 0644)
+	fakeClock.Advance(7 * time.Second)
+	assert.Eventually(t, func() bool { return countLines(t, output) == 1 }, time.Second, 10*time.Millisecond)
+	fakeClock.BlockUntil(2)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+
+	assert.Contains(t, b.String(), "config file /gtask.yml changed, reloading...")
+	assert.Same(t, result, tasks[0].LatestTaskResult)
+}
+
+func TestStart_SuccessKeepConfigOnSighupWithInvalidConfig(t *testing.T) {
+	b := &syncBuffer{}
+	ctx := context.TestContext(b)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
+	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "seconds", Command: fmt.Sprintf("sh -c 'echo seconds >> %s'", output), CronExpr: "*/15 * * * * *", Logger: ctx.Logger},
+	}
+	reloader := &Reloader{Load: func() (*context.Context, error) { return nil, errors.New("configuration file is not valid") }}
+
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, 0, "", []string{}, reloader, 0, nil, true, "") }()
+
+	fakeClock.BlockUntil(1)
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(b.String(), "failed to reload config, the current one is kept")
+	}, time.Second, 10*time.Millisecond)
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool { return countLines(t, output) == 1 }, time.Second, 10*time.Millisecond)
+	fakeClock.BlockUntil(1)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+	assert.Contains(t, b.String(), "hangup signal received, reloading config...")
+}
@@ -1,11 +1,18 @@
 package schedule
 
 import (
+	stdContext "context"
+	"errors"
 	"fmt"
-	"github.com/adhocore/gronx"
 	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/cron"
+	"github.com/alexandreh2ag/go-task/graph"
+	"github.com/alexandreh2ag/go-task/heartbeat"
+	"github.com/alexandreh2ag/go-task/lock"
 	"github.com/alexandreh2ag/go-task/types"
+	"github.com/jonboulle/clockwork"
 	"github.com/spf13/afero"
+	"io"
 	"os"
 	"os/signal"
 	"path"
@@ -19,10 +26,8 @@ const (
 	BlocSeparator = "===================="
 )
 
-var (
-	cronExprNextTick = "*/%d * * * *"
-	tickUnit         = time.Minute
-)
+// ErrShutdown is the cause of the interruption of the tasks still running when the daemon exits.
+var ErrShutdown = errors.New("daemon shutdown")
 
 func GetCurrentTime(now time.Time, timezone string) (time.Time, error) {
 
@@ -33,63 +38,211 @@ func GetCurrentTime(now time.Time, timezone string) (time.Time, error) {
 		}
 		now = now.In(tz)
 	}
-	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location()), nil
+	// truncate the instant: rebuilding the wall clock is ambiguous when the clock goes back (DST)
+	return now.Truncate(time.Minute), nil
 }
 
-func Start(ctx *context.Context, tick int, timezone string, taskFilter []string, noResultPrint bool, resultPath string) error {
-	var refTime time.Time
-	firstRun := true
-
-	now := ctx.Clock.Now()
-	nextTick, err := gronx.NextTickAfter(fmt.Sprintf(cronExprNextTick, tick), now, false)
-	if err != nil {
-		return fmt.Errorf("could not calculate next tick of expr %s: %v", fmt.Sprintf(cronExprNextTick, tick), err)
+// Start keeps the next fire time of each task in a queue, sleeps until the earliest one and runs the due tasks.
+// When tick is set, it is the longest sleep: the lease of the leader election is renewed at least at each tick.
+// With a reloader, the config is reloaded between two runs: the running tasks keep the previous one.
+// On exit (signal or app canceled), the running tasks are waited until shutdownTimeout then interrupted.
+// With dryRun, it prints to it what the due tasks would do instead of running them.
+func Start(ctx *context.Context, tick time.Duration, timezone string, taskFilter []string, reloader *Reloader, shutdownTimeout time.Duration, dryRun io.Writer, noResultPrint bool, resultPath string) error {
+	location := time.Local
+	if timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return err
+		}
 	}
-	ctx.Logger.Info(fmt.Sprintf("next tick: %v", nextTick.Format("2006-01-02T15:04:05")))
-	ctx.Clock.Sleep(nextTick.Sub(now))
 
-	refTime, err = GetCurrentTime(ctx.Clock.Now(), timezone)
-	if err != nil {
-		return err
+	if ctx.Leadership != nil {
+		defer ctx.Leadership.Resign()
 	}
-
-	ticker := ctx.Clock.NewTicker(time.Duration(tick) * tickUnit)
-	defer ticker.Stop()
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
 
+	var watch <-chan time.Time
+	var watched fileState
+	if reloader != nil {
+		signal.Notify(sigs, syscall.SIGHUP)
+		if reloader.Watch > 0 {
+			watched = reloader.stat(ctx.Fs)
+			ticker := ctx.Clock.NewTicker(reloader.Watch)
+			defer ticker.Stop()
+			watch = ticker.Chan()
+		}
+	}
+
+	var inflight sync.WaitGroup
+	// the runs outlive the app context, they are canceled by shutdown after the wait of the running tasks
+	runs, cancelRuns := stdContext.WithCancelCause(stdContext.WithoutCancel(ctx))
+	defer cancelRuns(nil)
+
+	start := ctx.Clock.Now().In(location)
+	last := start
+	queue := NewQueue(ctx.Config.Scheduled, start, EveryAnchor(ctx, start), taskFilter)
+	// the fire times after the last run are computed again with the tasks of the new config
+	reloadQueue := func() {
+		if next, ok := reload(ctx, reloader); ok {
+			ctx = next
+			queue = NewQueue(ctx.Config.Scheduled, last, EveryAnchor(ctx, start), taskFilter)
+		}
+	}
+	if rebootTasks := RebootTasks(ctx.Config.Scheduled, taskFilter); len(rebootTasks) > 0 {
+		runDue(ctx, runs, &inflight, []DueTasks{{At: start.Truncate(time.Second), Ids: rebootTasks}}, taskFilter, dryRun, noResultPrint, resultPath)
+	}
 	for {
-		if firstRun {
-			firstRun = false
-			ctx.Logger.Debug("first tick", "now", time.Now())
-			go Run(ctx, refTime, taskFilter, false, noResultPrint, resultPath)
+		now := ctx.Clock.Now().In(location)
+		wakeAt, ok := queue.Next()
+		if tick > 0 && (!ok || now.Add(tick).Before(wakeAt)) {
+			wakeAt, ok = now.Add(tick), true
 		}
+
+		var timer clockwork.Timer
+		var wait <-chan time.Time
+		if ok {
+			ctx.Logger.Debug(fmt.Sprintf("next tick: %v", wakeAt.In(location).Format("2006-01-02T15:04:05")))
+			timer = ctx.Clock.NewTimer(wakeAt.Sub(now))
+			wait = timer.Chan()
+		} else {
+			ctx.Logger.Info("no scheduled task to run, waiting for exit")
+		}
+
 		select {
-		case <-ticker.Chan():
-			ctx.Logger.Debug("tick", "now", time.Now())
-			// can ignore error because schedule.GetCurrentTime used at top
-			refTime, _ = GetCurrentTime(ctx.Clock.Now(), timezone)
+		case <-wait:
+			ctx.Logger.Debug("tick", "now", ctx.Clock.Now())
+			last = ctx.Clock.Now().In(location)
+			runDue(ctx, runs, &inflight, queue.PopDue(last), taskFilter, dryRun, noResultPrint, resultPath)
 
-			go Run(ctx, refTime, taskFilter, false, noResultPrint, resultPath)
+		case <-watch:
+			stopTimer(timer)
+			if state := reloader.stat(ctx.Fs); state != watched {
+				watched = state
+				ctx.Logger.Info(fmt.Sprintf("config file %s changed, reloading...", reloader.Path))
+				reloadQueue()
+			}
 
 		case sig := <-sigs:
+			stopTimer(timer)
+			if sig == syscall.SIGHUP {
+				ctx.Logger.Info(fmt.Sprintf("%s signal received, reloading config...", sig.String()))
+				reloadQueue()
+				continue
+			}
 			ctx.Logger.Info(fmt.Sprintf("%s signal received, exiting...", sig.String()))
+			shutdown(ctx, &inflight, cancelRuns, shutdownTimeout)
 			return nil
 
 		case <-ctx.Done():
+			stopTimer(timer)
 			ctx.Logger.Info(fmt.Sprintf("stop asked by app, exiting..."))
+			shutdown(ctx, &inflight, cancelRuns, shutdownTimeout)
 			return nil
 		}
 	}
+}
 
+// EveryAnchor returns the start of the intervals of @every: the every_epoch of config or the fallback.
+func EveryAnchor(ctx *context.Context, fallback time.Time) time.Time {
+	if ctx.Config.EveryEpoch != "" {
+		if epoch, err := time.Parse(time.RFC3339, ctx.Config.EveryEpoch); err == nil {
+			return epoch
+		}
+	}
+	return fallback
 }
 
-func Run(ctx *context.Context, ref time.Time, taskFilter []string, force bool, noResultPrint bool, resultPath string) []*types.TaskResult {
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+// RebootTasks returns the tasks with @reboot which run once when the daemon starts.
+func RebootTasks(tasks types.ScheduledTasks, taskFilter []string) []string {
+	ids := []string{}
+	for _, task := range tasks {
+		if task.CronExpr == cron.Reboot && (len(taskFilter) == 0 || slices.Contains(taskFilter, task.Id)) {
+			ids = append(ids, task.Id)
+		}
+	}
+	return ids
+}
 
-	gron := gronx.New()
-	results := []*types.TaskResult{}
+func stopTimer(timer clockwork.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// shutdown waits the running tasks until timeout, then cancels them: the process group of each command receives SIGTERM
+// then SIGKILL after types.TerminateDelay, the runs end with the status interrupted.
+func shutdown(ctx *context.Context, inflight *sync.WaitGroup, cancelRuns stdContext.CancelCauseFunc, timeout time.Duration) {
+	drained := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(drained)
+	}()
+
+	if timeout > 0 {
+		ctx.Logger.Info(fmt.Sprintf("waiting running tasks up to %s", timeout))
+	}
+	if waitDrained(ctx, drained, timeout) {
+		return
+	}
+	ctx.Logger.Warn("interrupting running tasks")
+	cancelRuns(ErrShutdown)
+	if waitDrained(ctx, drained, 2*types.TerminateDelay) {
+		return
+	}
+	ctx.Logger.Error("running tasks did not end, exiting anyway")
+}
+
+func waitDrained(ctx *context.Context, drained <-chan struct{}, timeout time.Duration) bool {
+	if timeout <= 0 {
+		select {
+		case <-drained:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case <-drained:
+		return true
+	case <-ctx.Clock.After(timeout):
+		return false
+	}
+}
+
+// runDue renews the lease of the leader election then runs the due tasks in background when the replica is the leader.
+// runDue starts the due tasks in a copy of ctx derived from runs, the copy is canceled once they end to release it from runs.
+func runDue(ctx *context.Context, runs stdContext.Context, inflight *sync.WaitGroup, due []DueTasks, taskFilter []string, dryRun io.Writer, noResultPrint bool, resultPath string) {
+	if ctx.Leadership != nil && !ctx.Leadership.Check() {
+		ctx.Logger.Debug("not the leader, tick ignored")
+		return
+	}
+	runCtx := ctx.WithContext(runs)
+	var started sync.WaitGroup
+	for _, dueTasks := range due {
+		if dryRun != nil {
+			printPlans(runCtx, dryRun, dueTasks.At, dueTasks.Ids, taskFilter)
+			continue
+		}
+		inflight.Add(1)
+		started.Add(1)
+		go func(dueTasks DueTasks) {
+			defer inflight.Done()
+			defer started.Done()
+			RunTasks(runCtx, dueTasks.At, dueTasks.Ids, taskFilter, noResultPrint, resultPath)
+		}(dueTasks)
+	}
+	go func() {
+		started.Wait()
+		runCtx.Cancel()
+	}()
+}
+
+func Run(ctx *context.Context, ref time.Time, taskFilter []string, force bool, noResultPrint bool, resultPath string) []*types.TaskResult {
+	anchor := EveryAnchor(ctx, time.Unix(0, 0))
+	dueTasks := []string{}
 
 	for _, task := range ctx.Config.Scheduled {
 		if len(taskFilter) != 0 && !slices.Contains(taskFilter, task.Id) {
@@ -97,72 +250,253 @@ func Run(ctx *context.Context, ref time.Time, taskFilter []string, force bool, n
 			continue
 		}
 
-		if task.LatestTaskResult != nil && task.LatestTaskResult.Status != types.Pending {
-			task.LatestTaskResult = nil
+		if latest := task.GetLatestTaskResult(); latest != nil && latest.Status != types.Pending {
+			task.SetLatestTaskResult(nil)
 			task.Logger.Error(fmt.Sprintf("Latest result cheduled task %s flushed", task.Id))
 		}
 
-		mustRun, err := gron.IsDue(task.CronExpr, ref)
-		if err != nil {
-			task.Logger.Error(fmt.Sprintf("Scheduled task %s fail to check if must run", task.Id))
+		if isDue(task, ref, anchor) || force {
+			task.Logger.Info(fmt.Sprintf("Scheduled task %s will run", task.Id))
+			dueTasks = append(dueTasks, task.Id)
+		} else {
+			task.Logger.Debug(fmt.Sprintf("Scheduled task %s must not run", task.Id))
 		}
+	}
 
-		if mustRun || force {
-			task.Logger.Info(fmt.Sprintf("Scheduled task %s will run", task.Id))
-			wg.Add(1)
-			go func(task *types.ScheduledTask, noResultPrint bool, resultPath string) {
-				defer wg.Done()
-
-				result := task.Execute()
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
-				output := FormatTaskResult(result)
-
-				if !noResultPrint {
-					fmt.Println(output)
-				}
+	return RunTasks(ctx, ref, dueTasks, taskFilter, noResultPrint, resultPath)
+}
 
-				if resultPath != "" {
-					err = WriteToLogFile(ctx, resultPath, output)
-					if err != nil {
-						task.Logger.Error(err.Error())
-					}
+// isDue checks the cron expression of the task at ref, in the timezone of the task when it has one.
+// ref is a whole minute, so @every must fire on whole minutes: the other intervals are rejected.
+func isDue(task *types.ScheduledTask, ref time.Time, anchor time.Time) bool {
+	if task.CronExpr == "" {
+		return false
+	}
+	mustRun := false
+	schedule, err := cron.Parse(task.CronExpr, anchor)
+	if every, ok := schedule.(*cron.EverySchedule); ok && (every.Interval%time.Minute != 0 || !every.Anchor.Truncate(time.Minute).Equal(every.Anchor)) {
+		task.Logger.Error(fmt.Sprintf("Scheduled task %s can't run with schedule run: %s does not fire on whole minutes from %s", task.Id, task.CronExpr, every.Anchor.UTC().Format(time.RFC3339)))
+		return false
+	}
+	if err == nil {
+		taskRef := ref
+		if task.Timezone != "" {
+			taskRef, err = GetCurrentTime(ref, task.Timezone)
+		}
+		if err == nil {
+			mustRun, err = schedule.IsDue(taskRef)
+		}
+	}
+	if err != nil {
+		task.Logger.Error(fmt.Sprintf("Scheduled task %s fail to check if must run", task.Id))
+	}
+	return mustRun
+}
+
+// RunTasks runs the due tasks and the tasks which depend on them.
+func RunTasks(ctx *context.Context, ref time.Time, dueTasks []string, taskFilter []string, noResultPrint bool, resultPath string) []*types.TaskResult {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	pinger := heartbeat.NewPinger(ctx.Config.Heartbeat, ctx.Clock)
+	results := []*types.TaskResult{}
+	finished := map[string]*types.TaskResult{}
+
+	tasks := selectTasks(ctx.Config.Scheduled, dueTasks, taskFilter)
+	done := map[string]chan struct{}{}
+	for _, task := range tasks {
+		done[task.Id] = make(chan struct{})
+	}
+
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task *types.ScheduledTask, noResultPrint bool, resultPath string) {
+			defer wg.Done()
+			defer close(done[task.Id])
+
+			var result *types.TaskResult
+			if reason := waitDependencies(task, done, finished, &mu); reason != "" {
+				result = task.Skip(reason)
+			} else {
+				result = executeTask(ctx, task, ref, pinger)
+			}
+			mu.Lock()
+			results = append(results, result)
+			finished[task.Id] = result
+			mu.Unlock()
+			output := FormatTaskResult(result)
+			if ctx.Notifier != nil {
+				ctx.Notifier.Dispatch(result, output)
+			}
+
+			if !noResultPrint {
+				fmt.Println(output)
+			}
+
+			if resultPath != "" {
+				err := WriteToLogFile(ctx, resultPath, output)
+				if err != nil {
+					task.Logger.Error(err.Error())
 				}
+			}
 
-			}(task, noResultPrint, resultPath)
-		} else {
-			task.Logger.Debug(fmt.Sprintf("Scheduled task %s must not run", task.Id))
-		}
+		}(task, noResultPrint, resultPath)
 	}
 	wg.Wait()
 
 	return results
 }
 
+// executeTask takes the lock of the run before executing an exclusive task.
+func executeTask(ctx *context.Context, task *types.ScheduledTask, ref time.Time, pinger *heartbeat.Pinger) *types.TaskResult {
+	if reason, excluded := excludedBy(ctx, task, ref); excluded {
+		return task.Skip(reason)
+	}
+
+	bound := ctx.Config.Jitter.Default
+	if task.Jitter != nil {
+		bound = *task.Jitter
+	}
+	if delay := ctx.Config.Jitter.Delay(task.Id, bound); delay > 0 {
+		task.Logger.Debug(fmt.Sprintf("Scheduled task %s delayed by %s (jitter)", task.Id, delay))
+		select {
+		case <-ctx.Clock.After(delay):
+		case <-ctx.Done():
+			return task.Skip(fmt.Sprintf("interrupted before start (%v)", stdContext.Cause(ctx)))
+		}
+	}
+
+	if task.Exclusive && ctx.Locker != nil {
+		key := lock.Key{Id: task.Id, At: ref}
+		acquired, err := ctx.Locker.Acquire(key)
+		if err != nil {
+			return task.Fail(fmt.Errorf("failed to acquire lock: %v", err))
+		}
+		if !acquired {
+			return task.Skip("locked elsewhere")
+		}
+		defer func() {
+			if err := ctx.Locker.Release(key); err != nil {
+				task.Logger.Error(fmt.Sprintf("failed to release lock of %s: %v", task.Id, err))
+			}
+		}()
+	}
+
+	// a run skipped by its condition does not ping the heartbeat, the failures to evaluate it are left to Execute
+	if run, err := task.EvalCondition(); err == nil && !run {
+		return task.Skip(fmt.Sprintf("condition `%s` is false", task.Expression))
+	}
+
+	pinger.Start(task)
+	result := task.Execute(ctx)
+	pinger.Finish(result)
+	return result
+}
+
+// excludedBy checks the calendars excluded by the task at ref, in the timezone of the task.
+func excludedBy(ctx *context.Context, task *types.ScheduledTask, ref time.Time) (string, bool) {
+	location, err := task.GetLocation(ref.Location())
+	if err != nil {
+		return "", false
+	}
+	return ctx.Calendars.Excluded(task.ExcludeCalendars, ref.In(location))
+}
+
+// selectTasks returns the due tasks and the tasks which depend on them (when allowed by the filter).
+func selectTasks(scheduled types.ScheduledTasks, dueTasks []string, taskFilter []string) types.ScheduledTasks {
+	g := graph.Graph{}
+	for _, task := range scheduled {
+		g[task.Id] = task.DependsOn
+	}
+	ids := g.Dependents(dueTasks)
+
+	tasks := types.ScheduledTasks{}
+	for _, task := range scheduled {
+		if !slices.Contains(ids, task.Id) {
+			continue
+		}
+		if !slices.Contains(dueTasks, task.Id) {
+			if len(taskFilter) != 0 && !slices.Contains(taskFilter, task.Id) {
+				continue
+			}
+			task.Logger.Info(fmt.Sprintf("Scheduled task %s will run after %v", task.Id, task.DependsOn))
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// waitDependencies waits the end of the dependencies running in the same run and
+// returns the reason to skip the task when one of them did not succeed.
+func waitDependencies(task *types.ScheduledTask, done map[string]chan struct{}, finished map[string]*types.TaskResult, mu *sync.Mutex) string {
+	for _, dependency := range task.DependsOn {
+		wait, ok := done[dependency]
+		if !ok {
+			continue
+		}
+		<-wait
+		mu.Lock()
+		result := finished[dependency]
+		mu.Unlock()
+		if result.Status != types.Succeed {
+			return fmt.Sprintf("upstream task %s finished with status '%s'", dependency, result.StatusString())
+		}
+	}
+	return ""
+}
+
 func FormatTaskResult(result *types.TaskResult) string {
 	var outputStr = ""
+	var reasonStr = ""
 	var errorStr = ""
+	var hooksStr = ""
+	var usageStr = ""
+
+	if result.Usage != nil {
+		usageStr = fmt.Sprintf("Usage: %s\n", result.Usage)
+	}
 
 	if result.Output.String() != "" {
 		outputStr = fmt.Sprintf("output:\n%s\n", result.Output.String())
 	}
 
+	if result.Reason != "" {
+		reasonStr = fmt.Sprintf("Reason: %s\n", result.Reason)
+	}
+
 	if result.Error != nil {
 		errorStr = fmt.Sprintf("Due to the following error: %s\n", result.Error.Error())
 	}
-	return fmt.Sprintf(
-		"%s\nTask %s finish with status '%s'\nStart at %s, finish at %s (%s)\n%s%s%s\n",
+	for _, hook := range result.Hooks {
+		hooksStr += fmt.Sprintf(
+			"Hook %s `%s` finish with status '%s' (%s)\n",
+			hook.Type,
+			hook.Command,
+			hook.StatusString(),
+			hook.FinishAt.Sub(hook.StartAt),
+		)
+		if hook.Output.String() != "" {
+			hooksStr += fmt.Sprintf("output:\n%s\n", hook.Output.String())
+		}
+		if hook.Error != nil {
+			hooksStr += fmt.Sprintf("Due to the following error: %s\n", hook.Error.Error())
+		}
+	}
+	return result.Task.Mask(fmt.Sprintf(
+		"%s\nTask %s finish with status '%s'\nStart at %s, finish at %s (%s)\n%s%s%s%s%s%s\n",
 		BlocSeparator,
 		result.Task.Id,
 		result.StatusString(),
 		result.StartAt.Format("2006-01-02T15:04:05 MST"),
 		result.FinishAt.Format("2006-01-02T15:04:05 MST"),
 		result.FinishAt.Sub(result.StartAt),
+		usageStr,
 		outputStr,
+		reasonStr,
 		errorStr,
+		hooksStr,
 		BlocSeparator,
-	)
+	))
 }
 
 func WriteToLogFile(ctx *context.Context, resultPath string, data string) error {
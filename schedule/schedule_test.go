@@ -2,18 +2,34 @@ package schedule
 
 import (
 	"bytes"
+	stdContext "context"
 	"errors"
 	"fmt"
+	"github.com/alexandreh2ag/go-task/calendar"
 	"github.com/alexandreh2ag/go-task/context"
+	"github.com/alexandreh2ag/go-task/jitter"
+	"github.com/alexandreh2ag/go-task/leader"
+	"github.com/alexandreh2ag/go-task/lock"
 	mockOs "github.com/alexandreh2ag/go-task/mocks/os"
 	mockAfero "github.com/alexandreh2ag/go-task/mocks/spf13"
+	"github.com/alexandreh2ag/go-task/notify"
+	"github.com/alexandreh2ag/go-task/secret"
 	"github.com/alexandreh2ag/go-task/types"
 	"github.com/jonboulle/clockwork"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -21,6 +37,7 @@ import (
 func TestGetCurrentTime(t *testing.T) {
 
 	arizonaTZ, _ := time.LoadLocation("US/Arizona")
+	parisTZ, _ := time.LoadLocation("Europe/Paris")
 
 	tests := []struct {
 		name     string
@@ -43,6 +60,13 @@ func TestGetCurrentTime(t *testing.T) {
 			want:     time.Date(2023, time.January, 25, 8, 4, 0, 0, arizonaTZ),
 			wantErr:  false,
 		},
+		{
+			name:     "SuccessWithTimezoneDuringFallBack",
+			timezone: "Europe/Paris",
+			now:      time.Date(2023, time.October, 29, 1, 30, 13, 0, time.UTC),
+			want:     time.Date(2023, time.October, 29, 1, 30, 0, 0, time.UTC).In(parisTZ),
+			wantErr:  false,
+		},
 		{
 			name:     "ErrorWithWrongTimezone",
 			timezone: "US/Wrong",
@@ -286,7 +310,7 @@ func TestRun(t *testing.T) {
 				result.Task = nil
 				result.StartAt = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
 				result.FinishAt = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
-
+				result.Usage = nil
 			}
 
 			assert.Equal(t, len(tt.want), len(got))
@@ -313,6 +337,290 @@ func TestRun_SuccessWithOneTaskResult(t *testing.T) {
 	assert.ElementsMatch(t, want, scheduledTasks)
 }
 
+func TestRun_SuccessWithDependencies(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "upload", Command: fmt.Sprintf("sh -c 'echo upload >> %s'", output), DependsOn: []string{"transform"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "transform", Command: fmt.Sprintf("sh -c 'echo transform >> %s'", output), DependsOn: []string{"export"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "export", Command: fmt.Sprintf("sh -c 'sleep 0.1; echo export >> %s'", output), CronExpr: "0 2 * * *", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "other", Command: "echo other", CronExpr: "* * * * *", Logger: ctx.Logger},
+	}
+	ref := time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)
+
+	got := Run(ctx, ref, []string{}, false, true, "")
+
+	assert.Len(t, got, 4)
+	assert.Equal(t, "other", got[0].Task.Id)
+	for _, result := range got {
+		assert.Equal(t, types.Succeed, result.Status)
+	}
+	content, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "export\ntransform\nupload\n", string(content))
+}
+
+func TestRun_SuccessDependenciesNotDue(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "export", Command: "echo export", CronExpr: "0 2 * * *", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "transform", Command: "echo transform", DependsOn: []string{"export"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "report", Command: "echo report", CronExpr: "* * * * *", DependsOn: []string{"export"}, Logger: ctx.Logger},
+	}
+	ref := time.Date(2023, time.January, 25, 15, 4, 0, 0, time.UTC)
+
+	got := Run(ctx, ref, []string{}, false, true, "")
+
+	assert.Len(t, got, 1)
+	assert.Equal(t, "report", got[0].Task.Id)
+	assert.Equal(t, types.Succeed, got[0].Status)
+}
+
+func TestRun_SuccessDependenciesWithTaskFilter(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "export", Command: "echo export", CronExpr: "0 2 * * *", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "transform", Command: "echo transform", DependsOn: []string{"export"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "upload", Command: "echo upload", DependsOn: []string{"transform"}, Logger: ctx.Logger},
+	}
+	ref := time.Date(2023, time.January, 25, 15, 4, 0, 0, time.UTC)
+
+	got := Run(ctx, ref, []string{"export", "transform"}, true, true, "")
+
+	ids := []string{}
+	for _, result := range got {
+		ids = append(ids, result.Task.Id)
+	}
+	assert.Equal(t, []string{"export", "transform"}, ids)
+}
+
+func TestRun_SkipDependentsWhenUpstreamFailed(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "export", Command: "wrong", CronExpr: "0 2 * * *", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "transform", Command: "echo transform", DependsOn: []string{"export"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "upload", Command: "echo upload", DependsOn: []string{"transform"}, Logger: ctx.Logger},
+	}
+	ref := time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)
+
+	got := Run(ctx, ref, []string{}, false, true, "")
+
+	assert.Len(t, got, 3)
+	reasons := map[string]string{}
+	for _, result := range got {
+		reasons[result.Task.Id] = result.Reason
+		if result.Task.Id != "export" {
+			assert.Equal(t, types.Skipped, result.Status)
+			assert.Empty(t, result.Output.String())
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"export":    "",
+		"transform": "upstream task export finished with status 'failed'",
+		"upload":    "upstream task transform finished with status 'skipped'",
+	}, reasons)
+}
+
+func Test_runDue(t *testing.T) {
+	tests := []struct {
+		name       string
+		leadership bool
+		isLeader   bool
+		due        bool
+		dryRun     bool
+		wantRun    bool
+	}{
+		{name: "SuccessWithoutLeaderElection", due: true, wantRun: true},
+		{name: "SuccessNotDue", due: false, wantRun: false},
+		{name: "SuccessLeader", leadership: true, isLeader: true, due: true, wantRun: true},
+		{name: "SuccessNotLeader", leadership: true, isLeader: false, due: true, wantRun: false},
+		{name: "SuccessDryRun", due: true, dryRun: true, wantRun: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			output := path.Join(t.TempDir(), "output")
+			ctx.Config.Scheduled = types.ScheduledTasks{
+				&types.ScheduledTask{Id: "test", Command: "touch " + output, CronExpr: "* * * * *", Logger: ctx.Logger},
+			}
+			if tt.leadership {
+				ctx.Leadership = leader.NewLeadership(&fakeElector{isLeader: tt.isLeader}, "me", ctx.Clock, ctx.Logger)
+			}
+
+			due := []DueTasks{}
+			if tt.due {
+				due = append(due, DueTasks{At: time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC), Ids: []string{"test"}})
+			}
+
+			out := &bytes.Buffer{}
+			var dryRun io.Writer
+			if tt.dryRun {
+				dryRun = out
+			}
+			runs := &afterFuncContext{Context: stdContext.Background(), done: make(chan struct{})}
+			runDue(ctx, runs, &sync.WaitGroup{}, due, []string{}, dryRun, true, "")
+			if tt.dryRun {
+				assert.Contains(t, out.String(), "Task test would run at 2023-01-25T02:00:00 UTC (dry run)")
+			}
+
+			// the copy of the context is released from runs once the tasks end
+			assert.Eventually(t, func() bool { return runs.registered.Load() == runs.stopped.Load() }, time.Second, 10*time.Millisecond)
+			if tt.wantRun {
+				assert.Eventually(t, func() bool {
+					_, err := os.Stat(output)
+					return err == nil
+				}, time.Second, 10*time.Millisecond)
+			} else {
+				time.Sleep(50 * time.Millisecond)
+				_, err := os.Stat(output)
+				assert.True(t, os.IsNotExist(err))
+			}
+		})
+	}
+}
+
+// afterFuncContext counts the contexts derived from it and the ones canceled.
+type afterFuncContext struct {
+	stdContext.Context
+	done       chan struct{}
+	registered atomic.Int32
+	stopped    atomic.Int32
+}
+
+func (c *afterFuncContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *afterFuncContext) AfterFunc(f func()) func() bool {
+	c.registered.Add(1)
+	return func() bool {
+		c.stopped.Add(1)
+		return true
+	}
+}
+
+type errorLocker struct{}
+
+func (errorLocker) Acquire(_ lock.Key) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (errorLocker) Release(_ lock.Key) error {
+	return nil
+}
+
+func TestRun_SuccessWithExclusiveTasks(t *testing.T) {
+	ref := time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	replicas := []*context.Context{}
+	for i := 0; i < 2; i++ {
+		ctx := context.TestContext(io.Discard)
+		locker, err := lock.NewFileLocker(dir)
+		assert.NoError(t, err)
+		ctx.Locker = locker
+		ctx.Config.Scheduled = types.ScheduledTasks{
+			&types.ScheduledTask{Id: "exclusive", Command: "echo exclusive", CronExpr: "* * * * *", Exclusive: true, Logger: ctx.Logger},
+			&types.ScheduledTask{Id: "everywhere", Command: "echo everywhere", CronExpr: "* * * * *", Logger: ctx.Logger},
+		}
+		replicas = append(replicas, ctx)
+	}
+
+	first := Run(replicas[0], ref, []string{}, false, true, "")
+	second := Run(replicas[1], ref, []string{}, false, true, "")
+
+	statuses := map[string]int{}
+	for _, result := range first {
+		statuses["first "+result.Task.Id] = result.Status
+	}
+	for _, result := range second {
+		statuses["second "+result.Task.Id] = result.Status
+		if result.Task.Id == "exclusive" {
+			assert.Equal(t, "locked elsewhere", result.Reason)
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"first exclusive":   types.Succeed,
+		"first everywhere":  types.Succeed,
+		"second exclusive":  types.Skipped,
+		"second everywhere": types.Succeed,
+	}, statuses)
+
+	next := Run(replicas[1], ref.Add(time.Minute), []string{"exclusive"}, false, true, "")
+	assert.Len(t, next, 1)
+	assert.Equal(t, types.Succeed, next[0].Status)
+}
+
+func TestRun_FailedWithLockError(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Locker = errorLocker{}
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "exclusive", Command: "echo exclusive", CronExpr: "* * * * *", Exclusive: true, Logger: ctx.Logger},
+	}
+
+	got := Run(ctx, time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC), []string{}, false, true, "")
+
+	assert.Len(t, got, 1)
+	assert.Equal(t, types.Failed, got[0].Status)
+	assert.EqualError(t, got[0].Error, "failed to acquire lock: connection refused")
+	assert.Empty(t, got[0].Output.String())
+}
+
+func TestRun_SuccessWithNotification(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	notifier, err := notify.NewDispatcher(notify.Targets{{Id: "hook", Type: notify.TypeWebhook, Url: server.URL}}, ctx.Logger)
+	assert.NoError(t, err)
+	ctx.Notifier = notifier
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "test", Command: "wrong", CronExpr: "* * * * *", Logger: ctx.Logger, NotifyOn: []string{"failure"}},
+		&types.ScheduledTask{Id: "test2", Command: "echo test", CronExpr: "* * * * *", Logger: ctx.Logger, NotifyOn: []string{"failure"}},
+	}
+	ref := time.Date(2023, time.January, 25, 15, 4, 0, 0, time.UTC)
+
+	_ = Run(ctx, ref, []string{}, false, true, "")
+
+	assert.Equal(t, 1, requests)
+}
+
+func TestRun_HeartbeatWithoutSkippedRuns(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	var mu sync.Mutex
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	calendars, err := calendar.Load(ctx.Fs, calendar.Configs{{Id: "holidays", Dates: []string{"2023-01-25"}}}, ctx.Logger)
+	assert.NoError(t, err)
+	ctx.Calendars = calendars
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "run", Command: "echo", CronExpr: "* * * * *", HeartbeatUrl: server.URL + "/run", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "condition", Command: "echo", CronExpr: "* * * * *", Envs: map[string]string{"MY_VAR": "5"}, Expression: "MY_VAR != 5", HeartbeatUrl: server.URL + "/condition", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "calendar", Command: "echo", CronExpr: "* * * * *", ExcludeCalendars: []string{"holidays"}, HeartbeatUrl: server.URL + "/calendar", Logger: ctx.Logger},
+	}
+
+	got := Run(ctx, time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC), []string{}, false, true, "")
+
+	reasons := map[string]string{}
+	for _, result := range got {
+		reasons[result.Task.Id] = result.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"run":       "",
+		"condition": "condition `MY_VAR != 5` is false",
+		"calendar":  "excluded by calendar holidays (date 2023-01-25)",
+	}, reasons)
+	assert.Equal(t, []string{"/run/start", "/run/0"}, paths)
+}
+
 func TestFormatTaskResult(t *testing.T) {
 
 	tests := []struct {
@@ -335,6 +643,22 @@ func TestFormatTaskResult(t *testing.T) {
 				BlocSeparator,
 			),
 		},
+		{
+			name: "SuccessWithUsage",
+			result: &types.TaskResult{
+				Status:   types.Succeed,
+				Output:   *bytes.NewBuffer([]byte("my output\n")),
+				Task:     &types.ScheduledTask{Id: "test"},
+				StartAt:  time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+				FinishAt: time.Date(1970, time.January, 1, 0, 35, 1, 0, time.UTC),
+				Usage:    &types.Usage{MaxRSS: 12 << 20, UserTime: 4 * time.Minute, SystemTime: 3 * time.Second, VoluntaryContextSwitches: 120, InvoluntaryContextSwitches: 8},
+			},
+			want: fmt.Sprintf(
+				"%s\nTask test finish with status 'succeed'\nStart at 1970-01-01T00:30:00 UTC, finish at 1970-01-01T00:35:01 UTC (5m1s)\nUsage: max RSS 12.0 MiB, user CPU 4m0s, system CPU 3s, context switches 120 voluntary / 8 involuntary\noutput:\nmy output\n\n%s\n",
+				BlocSeparator,
+				BlocSeparator,
+			),
+		},
 		{
 			name: "SuccessWithFailedResultAndEmptyOutput",
 			result: &types.TaskResult{
@@ -351,6 +675,51 @@ func TestFormatTaskResult(t *testing.T) {
 				BlocSeparator,
 			),
 		},
+		{
+			name: "SuccessWithSkippedResultAndReason",
+			result: &types.TaskResult{
+				Status:   types.Skipped,
+				Reason:   "upstream task export finished with status 'failed'",
+				Task:     &types.ScheduledTask{Id: "test"},
+				StartAt:  time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+				FinishAt: time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+			},
+			want: fmt.Sprintf(
+				"%s\nTask test finish with status 'skipped'\nStart at 1970-01-01T00:30:00 UTC, finish at 1970-01-01T00:30:00 UTC (0s)\nReason: upstream task export finished with status 'failed'\n%s\n",
+				BlocSeparator,
+				BlocSeparator,
+			),
+		},
+		{
+			name: "SuccessWithHooks",
+			result: &types.TaskResult{
+				Status:   types.Succeed,
+				Task:     &types.ScheduledTask{Id: "test"},
+				StartAt:  time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+				FinishAt: time.Date(1970, time.January, 1, 0, 30, 1, 0, time.UTC),
+				Hooks: []*types.HookResult{
+					{
+						Type:     types.HookBefore,
+						Command:  "echo lock",
+						Output:   *bytes.NewBufferString("lock"),
+						StartAt:  time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+						FinishAt: time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+					},
+					{
+						Type:     types.HookAfter,
+						Command:  "wrong",
+						Error:    errors.New("not found"),
+						StartAt:  time.Date(1970, time.January, 1, 0, 30, 1, 0, time.UTC),
+						FinishAt: time.Date(1970, time.January, 1, 0, 30, 2, 0, time.UTC),
+					},
+				},
+			},
+			want: fmt.Sprintf(
+				"%s\nTask test finish with status 'succeed'\nStart at 1970-01-01T00:30:00 UTC, finish at 1970-01-01T00:30:01 UTC (1s)\nHook before `echo lock` finish with status 'succeed' (0s)\noutput:\nlock\nHook after `wrong` finish with status 'failed' (1s)\nDue to the following error: not found\n%s\n",
+				BlocSeparator,
+				BlocSeparator,
+			),
+		},
 		{
 			name: "SuccessWithFailedResultAndOutput",
 			result: &types.TaskResult{
@@ -483,80 +852,502 @@ func TestWriteToLogFile(t *testing.T) {
 	}
 }
 
-func TestStart_SuccessFirstTick(t *testing.T) {
-	tickUnit = time.Millisecond
+func countLines(t *testing.T, file string) int {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(content), "\n")
+}
 
-	b := bytes.NewBufferString("")
+func TestStart_SuccessWithSecondsExpr(t *testing.T) {
+	b := &syncBuffer{}
 	ctx := context.TestContext(b)
 	ctx.LogLevel.Set(slog.LevelDebug)
-	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
 	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "seconds", Command: fmt.Sprintf("sh -c 'echo seconds >> %s'", output), CronExpr: "*/15 * * * * *", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "minutes", Command: "echo minutes", CronExpr: "0 12 * * *", Logger: ctx.Logger},
+	}
 
-	go func() {
-		err := Start(ctx, 1, "Europe/Paris", []string{}, true, "")
-		assert.NoError(t, err)
-	}()
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, time.Minute, "", []string{}, nil, 0, nil, true, "") }()
+
+	fakeClock.BlockUntil(1)
+	assert.Contains(t, b.String(), "next tick: 1970-01-01T00:00:15")
+	fakeClock.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool { return countLines(t, output) == 1 }, time.Second, 10*time.Millisecond)
+
+	fakeClock.BlockUntil(1)
+	assert.Contains(t, b.String(), "next tick: 1970-01-01T00:00:30")
+	fakeClock.Advance(15 * time.Second)
+	assert.Eventually(t, func() bool { return countLines(t, output) == 2 }, time.Second, 10*time.Millisecond)
 
 	fakeClock.BlockUntil(1)
-	fakeClock.Advance(1 * time.Second)
 	ctx.Cancel()
-	assert.Contains(t, b.String(), "msg=\"first tick\"")
-	assert.NotContains(t, b.String(), "msg=tick")
+	assert.NoError(t, <-done)
 }
 
-func TestStart_SuccessFirstTickAndTick(t *testing.T) {
-	tickUnit = time.Millisecond
-
-	b := bytes.NewBufferString("")
+func TestStart_SuccessWakeUpAtTick(t *testing.T) {
+	b := &syncBuffer{}
 	ctx := context.TestContext(b)
 	ctx.LogLevel.Set(slog.LevelDebug)
-	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
 	ctx.Clock = fakeClock
+	elector := &fakeElector{isLeader: true}
+	ctx.Leadership = leader.NewLeadership(elector, "me", fakeClock, ctx.Logger)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "daily", Command: "echo daily", CronExpr: "0 12 * * *", Logger: ctx.Logger},
+	}
 
-	go func() {
-		err := Start(ctx, 1, "Europe/Paris", []string{}, true, "")
-		assert.NoError(t, err)
-	}()
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, time.Minute, "", []string{}, nil, 0, nil, true, "") }()
 
 	fakeClock.BlockUntil(1)
-	fakeClock.Advance(1 * time.Second)
-	time.Sleep(50 * time.Millisecond)
-	fakeClock.Advance(1 * time.Second)
+	assert.Contains(t, b.String(), "next tick: 1970-01-01T00:01:10")
+	fakeClock.Advance(time.Minute)
+	fakeClock.BlockUntil(1)
 	ctx.Cancel()
-	assert.Contains(t, b.String(), "msg=\"first tick\"")
-	assert.Contains(t, b.String(), "msg=tick")
+	assert.NoError(t, <-done)
+
+	// the lease is renewed without running the task
+	assert.Equal(t, fakeClock.Now(), ctx.Leadership.Status().LastCheck)
+	assert.Nil(t, ctx.Config.Scheduled[0].LatestTaskResult)
 }
 
-func TestStart_ErrorWhenNextTickAfterFailed(t *testing.T) {
-	tickUnit = time.Millisecond
+func TestStart_SuccessEachMatchWithTick(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 30, 0, time.UTC))
+	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "minute", Command: fmt.Sprintf("sh -c 'echo minute >> %s'", output), CronExpr: "*/1 * * * *", Logger: ctx.Logger},
+	}
 
-	b := bytes.NewBufferString("")
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, 5*time.Minute, "", []string{}, nil, 0, nil, true, "") }()
+
+	for i := 1; i <= 3; i++ {
+		fakeClock.BlockUntil(1)
+		fakeClock.Advance(time.Minute)
+		assert.Eventually(t, func() bool { return countLines(t, output) == i }, time.Second, 10*time.Millisecond)
+	}
+	fakeClock.BlockUntil(1)
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestStart_SuccessWithRebootAndEvery(t *testing.T) {
+	b := &syncBuffer{}
 	ctx := context.TestContext(b)
 	ctx.LogLevel.Set(slog.LevelDebug)
-	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
 	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "reboot", Command: fmt.Sprintf("sh -c 'echo reboot >> %s'", output), CronExpr: "@reboot", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "every", Command: fmt.Sprintf("sh -c 'echo every >> %s'", output), CronExpr: "@every 45s", Logger: ctx.Logger},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, 0, "", []string{}, nil, 0, nil, true, "") }()
+
+	// @reboot runs at start, @every is anchored at start
+	assert.Eventually(t, func() bool { return countLines(t, output) == 1 }, time.Second, 10*time.Millisecond)
+	fakeClock.BlockUntil(1)
+	assert.Contains(t, b.String(), "next tick: 1970-01-01T00:00:55")
+	fakeClock.Advance(45 * time.Second)
+	assert.Eventually(t, func() bool { return countLines(t, output) == 2 }, time.Second, 10*time.Millisecond)
+	fakeClock.BlockUntil(1)
+	assert.Contains(t, b.String(), "next tick: 1970-01-01T00:01:40")
+	ctx.Cancel()
+	assert.NoError(t, <-done)
+
+	content, _ := os.ReadFile(output)
+	assert.Equal(t, "reboot\nevery\n", string(content))
+}
+
+func TestStart_SuccessWithEveryEpoch(t *testing.T) {
+	b := &syncBuffer{}
+	ctx := context.TestContext(b)
+	ctx.LogLevel.Set(slog.LevelDebug)
+	ctx.Clock = clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
+	ctx.Config.EveryEpoch = "1970-01-01T00:00:00Z"
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "every", Command: "echo", CronExpr: "@every 45s", Logger: ctx.Logger},
+	}
 
-	err := Start(ctx, 0, "Europe/Paris", []string{}, true, "")
-	assert.Error(t, err)
-	assert.Equal(t, err.Error(), "could not calculate next tick of expr */0 * * * *: tried so hard")
+	done := make(chan error, 1)
+	go func() { done <- Start(ctx, 0, "", []string{}, nil, 0, nil, true, "") }()
+
+	ctx.Clock.(clockwork.FakeClock).BlockUntil(1)
+	assert.Contains(t, b.String(), "next tick: 1970-01-01T00:00:45")
+	ctx.Cancel()
+	assert.NoError(t, <-done)
 }
 
-func TestStart_ErrorWhenGetCurrentTimeFailed(t *testing.T) {
-	tickUnit = time.Millisecond
+func TestRun_SuccessWithDescriptors(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "reboot", Command: "echo", CronExpr: "@reboot", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "every", Command: "echo", CronExpr: "@every 3m", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "hourly", Command: "echo", CronExpr: "@hourly", Logger: ctx.Logger},
+	}
+	ids := func(results []*types.TaskResult) []string {
+		ids := []string{}
+		for _, result := range results {
+			ids = append(ids, result.Task.Id)
+		}
+		slices.Sort(ids)
+		return ids
+	}
 
+	// @every is anchored at unix epoch without every_epoch
+	assert.Equal(t, []string{"every", "hourly"}, ids(Run(ctx, time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC), []string{}, false, true, "")))
+	assert.Equal(t, []string{}, ids(Run(ctx, time.Date(2023, time.January, 25, 15, 1, 0, 0, time.UTC), []string{}, false, true, "")))
+
+	ctx.Config.EveryEpoch = "2023-01-25T15:01:00Z"
+	assert.Equal(t, []string{"every"}, ids(Run(ctx, time.Date(2023, time.January, 25, 15, 4, 0, 0, time.UTC), []string{}, false, true, "")))
+}
+
+func TestRun_RejectEveryNotOnWholeMinutes(t *testing.T) {
 	b := bytes.NewBufferString("")
 	ctx := context.TestContext(b)
-	ctx.LogLevel.Set(slog.LevelDebug)
-	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "seconds", Command: "echo", CronExpr: "@every 90s", Logger: ctx.Logger},
+	}
+	// 90s would only be due every 3 minutes at the minutes checked
+	for minute := 0; minute < 3; minute++ {
+		assert.Empty(t, Run(ctx, time.Date(2023, time.January, 25, 15, minute, 0, 0, time.UTC), []string{}, false, true, ""))
+	}
+	assert.Contains(t, b.String(), "Scheduled task seconds can't run with schedule run: @every 90s does not fire on whole minutes from 1970-01-01T00:00:00Z")
+
+	ctx.Config.Scheduled[0].CronExpr = "@every 1m"
+	ctx.Config.EveryEpoch = "2023-01-25T15:00:30Z"
+	assert.Empty(t, Run(ctx, time.Date(2023, time.January, 25, 15, 1, 0, 0, time.UTC), []string{}, false, true, ""))
+	assert.Contains(t, b.String(), "@every 1m does not fire on whole minutes from 2023-01-25T15:00:30Z")
+}
+
+func TestStart_ErrorWithWrongTimezone(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	ctx.Clock = clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 59, 0, time.UTC))
+
+	err := Start(ctx, time.Minute, "Europe/Wrong", []string{}, nil, 0, nil, true, "")
+	assert.EqualError(t, err, "unknown time zone Europe/Wrong")
+}
+
+func TestStart_SuccessWithTaskTimezoneOverDST(t *testing.T) {
+	tests := []struct {
+		name      string
+		timezone  string
+		expr      string
+		start     time.Time
+		wantRuns  int
+		wantTicks []string
+	}{
+		{
+			name:      "SpringForwardRunsOnceAtNextValidTime",
+			timezone:  "Europe/Paris",
+			expr:      "30 2 * * *",
+			start:     time.Date(2023, time.March, 25, 23, 0, 0, 0, time.UTC),
+			wantRuns:  1,
+			wantTicks: []string{"next tick: 2023-03-26T01:00:00"},
+		},
+		{
+			name:      "FallBackRunsOnce",
+			timezone:  "Europe/Paris",
+			expr:      "30 2 * * *",
+			start:     time.Date(2023, time.October, 28, 23, 0, 0, 0, time.UTC),
+			wantRuns:  1,
+			wantTicks: []string{"next tick: 2023-10-29T00:30:00"},
+		},
+		{
+			name:      "SpringForwardNewYork",
+			timezone:  "America/New_York",
+			expr:      "30 2 * * *",
+			start:     time.Date(2023, time.March, 12, 5, 0, 0, 0, time.UTC),
+			wantRuns:  1,
+			wantTicks: []string{"next tick: 2023-03-12T07:00:00"},
+		},
+		{
+			name:      "FallBackNewYork",
+			timezone:  "America/New_York",
+			expr:      "30 1 * * *",
+			start:     time.Date(2023, time.November, 5, 4, 0, 0, 0, time.UTC),
+			wantRuns:  1,
+			wantTicks: []string{"next tick: 2023-11-05T05:30:00"},
+		},
+		{
+			name:      "FallBackWildcardHourRunsDuringRepeatedHour",
+			timezone:  "Europe/Paris",
+			expr:      "0 * * * *",
+			start:     time.Date(2023, time.October, 28, 23, 0, 0, 0, time.UTC),
+			wantRuns:  6,
+			wantTicks: []string{"next tick: 2023-10-29T01:00:00", "next tick: 2023-10-29T02:00:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &syncBuffer{}
+			ctx := context.TestContext(b)
+			ctx.LogLevel.Set(slog.LevelDebug)
+			fakeClock := clockwork.NewFakeClockAt(tt.start)
+			ctx.Clock = fakeClock
+			output := path.Join(t.TempDir(), "output")
+			ctx.Config.Scheduled = types.ScheduledTasks{
+				&types.ScheduledTask{Id: "dst", Command: fmt.Sprintf("sh -c 'echo dst >> %s'", output), CronExpr: tt.expr, Timezone: tt.timezone, Logger: ctx.Logger},
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- Start(ctx, 15*time.Minute, "UTC", []string{}, nil, 0, nil, true, "") }()
+
+			// 6 hours around the transition
+			for i := 0; i < 24; i++ {
+				fakeClock.BlockUntil(1)
+				fakeClock.Advance(15 * time.Minute)
+			}
+			assert.Eventually(t, func() bool { return countLines(t, output) == tt.wantRuns }, time.Second, 10*time.Millisecond)
+			fakeClock.BlockUntil(1)
+			ctx.Cancel()
+			assert.NoError(t, <-done)
+			assert.Equal(t, tt.wantRuns, countLines(t, output))
+			for _, tick := range tt.wantTicks {
+				assert.Contains(t, b.String(), tick)
+			}
+		})
+	}
+}
+
+func TestRun_SuccessWithTaskTimezone(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     time.Time
+		wantRun bool
+	}{
+		{name: "SuccessAtTaskTime", ref: time.Date(2023, time.October, 28, 0, 30, 0, 0, time.UTC), wantRun: true},
+		{name: "SuccessNotAtGlobalTime", ref: time.Date(2023, time.October, 28, 2, 30, 0, 0, time.UTC), wantRun: false},
+		{name: "SuccessSpringForwardAtNextValidTime", ref: time.Date(2023, time.March, 26, 1, 0, 0, 0, time.UTC), wantRun: true},
+		{name: "SuccessFallBackFirstOccurrence", ref: time.Date(2023, time.October, 29, 0, 30, 0, 0, time.UTC), wantRun: true},
+		{name: "SuccessFallBackSecondOccurrence", ref: time.Date(2023, time.October, 29, 1, 30, 0, 0, time.UTC), wantRun: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			ctx.Config.Scheduled = types.ScheduledTasks{
+				&types.ScheduledTask{Id: "paris", Command: "echo", CronExpr: "30 2 * * *", Timezone: "Europe/Paris", Logger: ctx.Logger},
+			}
+			results := Run(ctx, tt.ref, []string{}, false, true, "")
+			assert.Equal(t, tt.wantRun, len(results) == 1)
+		})
+	}
+}
+
+func TestRun_SuccessWithJitter(t *testing.T) {
+	tests := []struct {
+		name          string
+		taskJitter    *time.Duration
+		defaultJitter time.Duration
+		wantBound     time.Duration
+	}{
+		{name: "SuccessTaskJitter", taskJitter: durationPtr(2 * time.Minute), wantBound: 2 * time.Minute},
+		{name: "SuccessDefaultJitter", defaultJitter: time.Hour, wantBound: time.Hour},
+		{name: "SuccessTaskJitterOverridesDefault", taskJitter: durationPtr(30 * time.Second), defaultJitter: time.Hour, wantBound: 30 * time.Second},
+		{name: "SuccessTaskDisablesDefaultJitter", taskJitter: durationPtr(0), defaultJitter: time.Hour, wantBound: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TestContext(io.Discard)
+			fakeClock := clockwork.NewFakeClockAt(time.Date(2023, time.January, 25, 15, 0, 0, 0, time.UTC))
+			ctx.Clock = fakeClock
+			ctx.Config.Jitter = jitter.Config{Default: tt.defaultJitter, Deterministic: true}
+			ctx.Config.Scheduled = types.ScheduledTasks{
+				&types.ScheduledTask{Id: "jitter", Command: "echo", CronExpr: "0 * * * *", Jitter: tt.taskJitter, Logger: ctx.Logger},
+			}
+			delay := ctx.Config.Jitter.Delay("jitter", tt.wantBound)
+			if tt.wantBound == 0 {
+				got := Run(ctx, fakeClock.Now(), []string{}, false, true, "")
+				assert.Equal(t, types.Succeed, got[0].Status)
+				return
+			}
+			assert.Greater(t, delay, time.Duration(0))
+
+			results := make(chan []*types.TaskResult)
+			go func() { results <- Run(ctx, fakeClock.Now(), []string{}, false, true, "") }()
+
+			fakeClock.BlockUntil(1)
+			fakeClock.Advance(delay - time.Nanosecond)
+			select {
+			case <-results:
+				assert.Fail(t, "task ran before its jitter delay")
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+			fakeClock.Advance(time.Nanosecond)
+			got := <-results
+			assert.Len(t, got, 1)
+			assert.Equal(t, types.Succeed, got[0].Status)
+		})
+	}
+}
+
+func TestRun_SkipExcludedByCalendar(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	ctx := context.TestContext(io.Discard)
+	calendars, err := calendar.Load(ctx.Fs, calendar.Configs{
+		{Id: "business", Ranges: []calendar.RangeConfig{{Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}, From: "09:00", To: "18:00"}}},
+		{Id: "holidays", Dates: []string{"2023-12-25"}},
+	}, ctx.Logger)
+	assert.NoError(t, err)
+	ctx.Calendars = calendars
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "paris", Command: "echo", CronExpr: "0 * * * *", Timezone: "Europe/Paris", ExcludeCalendars: []string{"holidays", "business"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "utc", Command: "echo", CronExpr: "0 * * * *", ExcludeCalendars: []string{"business"}, Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "dependent", Command: "echo", DependsOn: []string{"utc"}, ExcludeCalendars: []string{"holidays"}, Logger: ctx.Logger},
+	}
+	tests := []struct {
+		name       string
+		ref        time.Time
+		wantStatus map[string]int
+		wantReason map[string]string
+	}{
+		{
+			name:       "SuccessBusinessHoursInTaskTimezone",
+			ref:        time.Date(2023, time.January, 25, 8, 0, 0, 0, time.UTC),
+			wantStatus: map[string]int{"paris": types.Skipped, "utc": types.Succeed, "dependent": types.Succeed},
+			wantReason: map[string]string{"paris": "excluded by calendar business (mon,tue,wed,thu,fri 09:00-18:00)"},
+		},
+		{
+			name:       "SuccessHoliday",
+			ref:        time.Date(2023, time.December, 25, 6, 0, 0, 0, paris),
+			wantStatus: map[string]int{"paris": types.Skipped, "utc": types.Succeed, "dependent": types.Skipped},
+			wantReason: map[string]string{"paris": "excluded by calendar holidays (date 2023-12-25)", "dependent": "excluded by calendar holidays (date 2023-12-25)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Run(ctx, tt.ref, []string{}, false, true, "")
+			assert.Len(t, results, 3)
+			for _, result := range results {
+				assert.Equal(t, tt.wantStatus[result.Task.Id], result.Status, result.Task.Id)
+				assert.Equal(t, tt.wantReason[result.Task.Id], result.Reason, result.Task.Id)
+			}
+		})
+	}
+}
+
+func TestStart_SuccessShutdownWaitsRunningTasks(t *testing.T) {
+	b := bytes.NewBufferString("")
+	ctx := context.TestContext(b)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
+	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "reboot", Command: fmt.Sprintf("sh -c 'touch %s; sleep 0.2'", output), CronExpr: "@reboot", Logger: ctx.Logger},
+	}
+
+	done := make(chan error)
+	go func() { done <- Start(ctx, 0, "", []string{}, nil, time.Minute, nil, true, "") }()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(output)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	ctx.Cancel()
+
+	assert.NoError(t, <-done)
+	assert.Contains(t, b.String(), "waiting running tasks up to 1m0s")
+	assert.Equal(t, types.Succeed, ctx.Config.Scheduled[0].LatestTaskResult.Status)
+}
+
+func TestStart_SuccessShutdownInterruptsRunningTasks(t *testing.T) {
+	b := bytes.NewBufferString("")
+	ctx := context.TestContext(b)
+	fakeClock := clockwork.NewFakeClockAt(time.Date(1970, time.January, 1, 0, 0, 10, 0, time.UTC))
 	ctx.Clock = fakeClock
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "reboot", Command: fmt.Sprintf("sh -c 'touch %s; sleep 5'", output), CronExpr: "@reboot", Logger: ctx.Logger},
+	}
+
+	done := make(chan error)
+	go func() { done <- Start(ctx, 0, "", []string{}, nil, time.Minute, nil, true, "") }()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(output)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	ctx.Cancel()
+
+	// SIGTERM after the shutdown timeout
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(time.Minute)
+
+	assert.NoError(t, <-done)
+	result := ctx.Config.Scheduled[0].LatestTaskResult
+	assert.Equal(t, types.Interrupted, result.Status)
+	assert.Equal(t, "interrupted (daemon shutdown): signal: terminated", result.Error.Error())
+	assert.Contains(t, b.String(), "interrupting running tasks")
+}
 
+func TestRun_InterruptedWhenContextCanceled(t *testing.T) {
+	ctx := context.TestContext(io.Discard)
+	output := path.Join(t.TempDir(), "output")
+	ctx.Config.Scheduled = types.ScheduledTasks{
+		&types.ScheduledTask{Id: "long", Command: fmt.Sprintf("sh -c 'touch %s; sleep 5'", output), CronExpr: "* * * * *", Logger: ctx.Logger},
+		&types.ScheduledTask{Id: "dependent", Command: "echo", DependsOn: []string{"long"}, Logger: ctx.Logger},
+	}
 	go func() {
-		err := Start(ctx, 1, "Europe/Wrong", []string{}, true, "")
-		assert.Error(t, err)
-		assert.Equal(t, err.Error(), "unknown time zone Europe/Wrong")
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(output)
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+		ctx.Cancel()
 	}()
 
-	fakeClock.BlockUntil(1)
-	fakeClock.Advance(1 * time.Second)
-	time.Sleep(50 * time.Millisecond)
+	results := Run(ctx, time.Date(2023, time.January, 25, 2, 0, 0, 0, time.UTC), []string{}, false, true, "")
+	assert.Len(t, results, 2)
+	assert.Equal(t, types.Interrupted, ctx.Config.Scheduled[0].LatestTaskResult.Status)
+	assert.Equal(t, "interrupted (context canceled): signal: terminated", ctx.Config.Scheduled[0].LatestTaskResult.Error.Error())
+	assert.Equal(t, types.Skipped, ctx.Config.Scheduled[1].LatestTaskResult.Status)
+}
+
+func TestFormatTaskResult_MaskSecrets(t *testing.T) {
+	t.Setenv("GTASK_TESTING_SECRET", "p@ss")
+	resolver, err := secret.NewResolver(secret.Config{}, afero.NewMemMapFs())
+	assert.NoError(t, err)
+	task := &types.ScheduledTask{Id: "test", Envs: map[string]string{"DB_PASSWORD": "env://GTASK_TESTING_SECRET"}}
+	assert.NoError(t, task.ResolveSecrets(resolver))
+
+	result := &types.TaskResult{
+		Status:   types.Failed,
+		Error:    errors.New("exec: \"p@ss\": executable file not found in $PATH"),
+		Task:     task,
+		StartAt:  time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+		FinishAt: time.Date(1970, time.January, 1, 0, 30, 0, 0, time.UTC),
+	}
+	got := FormatTaskResult(result)
+	assert.Contains(t, got, "Due to the following error: exec: \"***\": executable file not found in $PATH\n")
+	assert.NotContains(t, got, "p@ss")
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+// syncBuffer is a bytes.Buffer safe for the writes of the scheduler goroutine and the reads of the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
 }
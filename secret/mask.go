@@ -0,0 +1,101 @@
+package secret
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Mask replaces the secrets.
+const Mask = "***"
+
+// Masker hides secrets in texts, a nil masker keeps them as is.
+type Masker struct {
+	replacer *strings.Replacer
+}
+
+// NewMasker returns a masker of the secrets or nil when there is none.
+func NewMasker(secrets []string) *Masker {
+	values := []string{}
+	for _, secret := range secrets {
+		if secret != "" {
+			values = append(values, secret)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	// the longest secrets first to hide a secret which contains another one
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, Mask)
+	}
+	return &Masker{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (m *Masker) Mask(text string) string {
+	if m == nil {
+		return text
+	}
+	return m.replacer.Replace(text)
+}
+
+// Handler returns a log handler which masks the messages and the string attributes before handler.
+func (m *Masker) Handler(handler slog.Handler) slog.Handler {
+	if m == nil {
+		return handler
+	}
+	return &maskHandler{handler: handler, masker: m}
+}
+
+type maskHandler struct {
+	handler slog.Handler
+	masker  *Masker
+}
+
+func (h *maskHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *maskHandler) Handle(ctx context.Context, record slog.Record) error {
+	masked := slog.NewRecord(record.Time, record.Level, h.masker.Mask(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		masked.AddAttrs(h.maskAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, masked)
+}
+
+func (h *maskHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		masked = append(masked, h.maskAttr(attr))
+	}
+	return &maskHandler{handler: h.handler.WithAttrs(masked), masker: h.masker}
+}
+
+func (h *maskHandler) WithGroup(name string) slog.Handler {
+	return &maskHandler{handler: h.handler.WithGroup(name), masker: h.masker}
+}
+
+func (h *maskHandler) maskAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.masker.Mask(value.String()))
+	case slog.KindGroup:
+		attrs := value.Group()
+		masked := make([]any, 0, len(attrs))
+		for _, groupAttr := range attrs {
+			masked = append(masked, h.maskAttr(groupAttr))
+		}
+		return slog.Group(attr.Key, masked...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, h.masker.Mask(err.Error()))
+		}
+	}
+	return attr
+}
@@ -0,0 +1,38 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestMasker_Mask(t *testing.T) {
+	assert.Nil(t, NewMasker(nil))
+	assert.Nil(t, NewMasker([]string{""}))
+
+	var masker *Masker
+	assert.Equal(t, "password", masker.Mask("password"))
+
+	masker = NewMasker([]string{"pass", "password", ""})
+	assert.Equal(t, "the *** is ***, not ***word", masker.Mask("the password is pass, not passwordword"))
+}
+
+func TestMasker_Handler(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	handler := slog.NewTextHandler(buffer, &slog.HandlerOptions{ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+		if attr.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return attr
+	}})
+
+	var masker *Masker
+	assert.Equal(t, handler, masker.Handler(handler))
+
+	masker = NewMasker([]string{"p@ss"})
+	logger := slog.New(masker.Handler(handler)).With("token", "p@ss").WithGroup("task")
+	logger.Info("login with p@ss", "error", errors.New("wrong p@ss"), "count", 2, slog.Group("db", "password", "p@ss"))
+	assert.Equal(t, "level=INFO msg=\"login with ***\" token=*** task.error=\"wrong ***\" task.count=2 task.db.password=***\n", buffer.String())
+}
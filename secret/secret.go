@@ -0,0 +1,103 @@
+package secret
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"os"
+	"strings"
+)
+
+const (
+	// Prefix is the optional prefix of the secret references (secret:<scheme>://<ref>), it makes any scheme a reference.
+	Prefix = "secret:"
+
+	SchemeFile  = "file"
+	SchemeEnv   = "env"
+	SchemeVault = "vault"
+)
+
+type Config struct {
+	Vault *VaultConfig `mapstructure:"vault" validate:"omitempty"`
+}
+
+// Provider returns the secrets of a scheme.
+type Provider interface {
+	// Get returns the secret of the reference without its scheme (like /run/secrets/db_pass for file:///run/secrets/db_pass).
+	Get(ref string) (string, error)
+}
+
+// Resolver replaces the secret references (<scheme>://<ref> or secret:<scheme>://<ref>) by their secret with the provider of their scheme.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver returns a resolver with the file and env providers, and the vault one when it is configured.
+func NewResolver(cfg Config, fs afero.Fs) (*Resolver, error) {
+	resolver := &Resolver{providers: map[string]Provider{}}
+	resolver.Register(SchemeFile, &FileProvider{fs: fs})
+	resolver.Register(SchemeEnv, &EnvProvider{})
+	if cfg.Vault != nil {
+		vault, err := NewVaultProvider(*cfg.Vault, resolver)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(SchemeVault, vault)
+	}
+	return resolver, nil
+}
+
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// Resolve returns the secret of value when it is a reference, value as is otherwise.
+// Without Prefix, only the values with the scheme of a provider (or vault) are references, like file:///run/secrets/db_pass.
+func (r *Resolver) Resolve(value string) (string, bool, error) {
+	reference, prefixed := strings.CutPrefix(value, Prefix)
+	scheme, ref, found := strings.Cut(reference, "://")
+	if !prefixed && (!found || !r.isScheme(scheme)) {
+		return value, false, nil
+	}
+	if !found {
+		return "", true, fmt.Errorf("failed to resolve %s: reference must be %s<scheme>://<ref>", value, Prefix)
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return "", true, fmt.Errorf("failed to resolve %s: unsupported scheme %s", value, scheme)
+	}
+	secret, err := provider.Get(ref)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to resolve %s: %v", value, err)
+	}
+	return secret, true, nil
+}
+
+// isScheme returns true for the schemes of the providers and for vault, which is a reference even when it is not configured.
+func (r *Resolver) isScheme(scheme string) bool {
+	_, ok := r.providers[scheme]
+	return ok || scheme == SchemeVault
+}
+
+// FileProvider reads the secret in a file, without its trailing new line (like docker secrets).
+type FileProvider struct {
+	fs afero.Fs
+}
+
+func (p *FileProvider) Get(ref string) (string, error) {
+	content, err := afero.ReadFile(p.fs, ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r"), nil
+}
+
+// EnvProvider reads the secret in a variable of the environment of gtask.
+type EnvProvider struct{}
+
+func (p *EnvProvider) Get(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("variable %s is not set", ref)
+	}
+	return value, nil
+}
@@ -0,0 +1,63 @@
+package secret
+
+import (
+	"errors"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type stubProvider struct {
+	secrets map[string]string
+}
+
+func (p *stubProvider) Get(ref string) (string, error) {
+	secret, ok := p.secrets[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return secret, nil
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Setenv("GTASK_TESTING_SECRET", "from-env")
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/run/secrets/db_pass", []byte("from-file\n"), 0600)
+	resolver, err := NewResolver(Config{}, fs)
+	assert.NoError(t, err)
+	resolver.Register("stub", &stubProvider{secrets: map[string]string{"db/pass": "from-stub"}})
+
+	tests := []struct {
+		name         string
+		value        string
+		want         string
+		wantIsSecret bool
+		wantErr      string
+	}{
+		{name: "SuccessPlainValue", value: "plain", want: "plain"},
+		{name: "SuccessUrl", value: "https://example.com", want: "https://example.com"},
+		{name: "SuccessOtherScheme", value: "ftp://example.com/file", want: "ftp://example.com/file"},
+		{name: "SuccessFile", value: "file:///run/secrets/db_pass", want: "from-file", wantIsSecret: true},
+		{name: "SuccessEnv", value: "env://GTASK_TESTING_SECRET", want: "from-env", wantIsSecret: true},
+		{name: "SuccessCustomProvider", value: "stub://db/pass", want: "from-stub", wantIsSecret: true},
+		{name: "SuccessPrefixedFile", value: "secret:file:///run/secrets/db_pass", want: "from-file", wantIsSecret: true},
+		{name: "SuccessPrefixedEnv", value: "secret:env://GTASK_TESTING_SECRET", want: "from-env", wantIsSecret: true},
+		{name: "ErrorFile", value: "file:///run/secrets/missing", wantIsSecret: true, wantErr: "failed to resolve file:///run/secrets/missing: open /run/secrets/missing: file does not exist"},
+		{name: "ErrorEnv", value: "env://GTASK_TESTING_MISSING", wantIsSecret: true, wantErr: "failed to resolve env://GTASK_TESTING_MISSING: variable GTASK_TESTING_MISSING is not set"},
+		{name: "ErrorVaultNotConfigured", value: "vault://secret/app#pass", wantIsSecret: true, wantErr: "failed to resolve vault://secret/app#pass: unsupported scheme vault"},
+		{name: "ErrorPrefixedUnknownScheme", value: "secret:ftp://example.com/file", wantIsSecret: true, wantErr: "failed to resolve secret:ftp://example.com/file: unsupported scheme ftp"},
+		{name: "ErrorNoScheme", value: "secret:db_pass", wantIsSecret: true, wantErr: "failed to resolve secret:db_pass: reference must be secret:<scheme>://<ref>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isSecret, err := resolver.Resolve(tt.value)
+			assert.Equal(t, tt.wantIsSecret, isSecret)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultVaultKvVersion = 2
+	// VaultTokenEnv is the variable of the token when the config has none.
+	VaultTokenEnv = "VAULT_TOKEN"
+
+	vaultTimeout = 10 * time.Second
+)
+
+// VaultConfig defines the HashiCorp Vault server of the vault://<mount>/<path>#<key> references.
+// Token can be a secret reference (like file:///run/secrets/vault_token).
+type VaultConfig struct {
+	Address   string `mapstructure:"address" validate:"required,url"`
+	Token     string `mapstructure:"token"`
+	Namespace string `mapstructure:"namespace"`
+	KvVersion int    `mapstructure:"kv_version" validate:"omitempty,oneof=1 2"`
+}
+
+// VaultProvider reads the secrets in a KV secrets engine, each path is read once.
+type VaultProvider struct {
+	cfg    VaultConfig
+	token  string
+	client *http.Client
+	cache  map[string]map[string]any
+}
+
+func NewVaultProvider(cfg VaultConfig, resolver *Resolver) (*VaultProvider, error) {
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv(VaultTokenEnv)
+	}
+	token, _, err := resolver.Resolve(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve vault token: %v", err)
+	}
+	if cfg.KvVersion == 0 {
+		cfg.KvVersion = DefaultVaultKvVersion
+	}
+	return &VaultProvider{
+		cfg:    cfg,
+		token:  token,
+		client: &http.Client{Timeout: vaultTimeout},
+		cache:  map[string]map[string]any{},
+	}, nil
+}
+
+func (p *VaultProvider) Get(ref string) (string, error) {
+	path, key, found := strings.Cut(ref, "#")
+	mount, secretPath, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if !found || key == "" || mount == "" || secretPath == "" {
+		return "", fmt.Errorf("reference must be <mount>/<path>#<key>")
+	}
+
+	data, ok := p.cache[path]
+	if !ok {
+		var err error
+		data, err = p.read(mount, secretPath)
+		if err != nil {
+			return "", err
+		}
+		p.cache[path] = data
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in %s", key, path)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+func (p *VaultProvider) read(mount, secretPath string) (map[string]any, error) {
+	url := fmt.Sprintf("%s/v1/%s/%s", strings.TrimSuffix(p.cfg.Address, "/"), mount, secretPath)
+	if p.cfg.KvVersion == 2 {
+		url = fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(p.cfg.Address, "/"), mount, secretPath)
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from vault", resp.StatusCode)
+	}
+
+	var payload struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid response from vault: %v", err)
+	}
+	if p.cfg.KvVersion == 1 {
+		return payload.Data, nil
+	}
+	data, ok := payload.Data["data"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid response from vault: no data")
+	}
+	return data, nil
+}
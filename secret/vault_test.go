@@ -0,0 +1,97 @@
+package secret
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newVaultServer(t *testing.T, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app/db":
+			assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+			_, _ = fmt.Fprint(w, `{"data": {"data": {"password": "p@ss", "port": 5432}, "metadata": {"version": 3}}}`)
+		case "/v1/kv/app/db":
+			_, _ = fmt.Fprint(w, `{"data": {"password": "v1-pass"}}`)
+		case "/v1/secret/data/wrong":
+			_, _ = fmt.Fprint(w, `{"data": {"metadata": {"version": 1}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultProvider_Get(t *testing.T) {
+	requests := 0
+	server := newVaultServer(t, &requests)
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/run/secrets/vault_token", []byte("s.token\n"), 0600)
+	resolver, err := NewResolver(Config{Vault: &VaultConfig{Address: server.URL + "/", Token: "file:///run/secrets/vault_token", Namespace: "team"}}, fs)
+	assert.NoError(t, err)
+
+	got, isSecret, err := resolver.Resolve("vault://secret/app/db#password")
+	assert.NoError(t, err)
+	assert.True(t, isSecret)
+	assert.Equal(t, "p@ss", got)
+
+	got, _, err = resolver.Resolve("vault://secret/app/db#port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", got)
+	assert.Equal(t, 1, requests)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{name: "ErrorMissingKey", value: "vault://secret/app/db#user", wantErr: "key user not found in secret/app/db"},
+		{name: "ErrorWrongRef", value: "vault://secret#password", wantErr: "reference must be <mount>/<path>#<key>"},
+		{name: "ErrorNoKey", value: "vault://secret/app/db", wantErr: "reference must be <mount>/<path>#<key>"},
+		{name: "ErrorNotFound", value: "vault://secret/missing#password", wantErr: "unexpected status code 404 from vault"},
+		{name: "ErrorWrongResponse", value: "vault://secret/wrong#password", wantErr: "invalid response from vault: no data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := resolver.Resolve(tt.value)
+			assert.EqualError(t, err, fmt.Sprintf("failed to resolve %s: %s", tt.value, tt.wantErr))
+		})
+	}
+}
+
+func TestVaultProvider_GetKvVersion1(t *testing.T) {
+	requests := 0
+	server := newVaultServer(t, &requests)
+	t.Setenv(VaultTokenEnv, "s.token")
+	resolver, err := NewResolver(Config{Vault: &VaultConfig{Address: server.URL, KvVersion: 1}}, afero.NewMemMapFs())
+	assert.NoError(t, err)
+
+	got, _, err := resolver.Resolve("vault://kv/app/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1-pass", got)
+}
+
+func TestNewVaultProvider_ErrorToken(t *testing.T) {
+	_, err := NewResolver(Config{Vault: &VaultConfig{Address: "http://localhost:8200", Token: "env://GTASK_TESTING_MISSING"}}, afero.NewMemMapFs())
+	assert.EqualError(t, err, "failed to resolve vault token: failed to resolve env://GTASK_TESTING_MISSING: variable GTASK_TESTING_MISSING is not set")
+}
+
+func TestVaultProvider_GetForbidden(t *testing.T) {
+	requests := 0
+	server := newVaultServer(t, &requests)
+	resolver, err := NewResolver(Config{Vault: &VaultConfig{Address: server.URL, Token: "wrong"}}, afero.NewMemMapFs())
+	assert.NoError(t, err)
+
+	_, _, err = resolver.Resolve("vault://secret/app/db#password")
+	assert.EqualError(t, err, "failed to resolve vault://secret/app/db#password: unexpected status code 403 from vault")
+}
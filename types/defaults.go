@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// WorkerDefaults are the values of the workers which do not define their own.
+type WorkerDefaults struct {
+	Directory string            `mapstructure:"directory" validate:"omitempty,dirpath"`
+	User      string            `mapstructure:"user" validate:"omitempty,alphanum"`
+	Envs      map[string]string `mapstructure:"environments"`
+}
+
+// ScheduledDefaults are the values of the scheduled tasks which do not define their own.
+type ScheduledDefaults struct {
+	Directory string            `mapstructure:"directory" validate:"omitempty,dirpath"`
+	User      string            `mapstructure:"user" validate:"omitempty,account"`
+	Envs      map[string]string `mapstructure:"environments"`
+	Timeout   time.Duration     `mapstructure:"timeout" validate:"omitempty,min=0"`
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/env"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	HookBefore    = "before"
+	HookAfter     = "after"
+	HookOnSuccess = "on_success"
+	HookOnFailure = "on_failure"
+)
+
+type HookResult struct {
+	Type     string
+	Command  string
+	Error    error
+	Output   bytes.Buffer
+	StartAt  time.Time
+	FinishAt time.Time
+}
+
+func (h *HookResult) StatusString() string {
+	if h.Error != nil {
+		return "failed"
+	}
+	return "succeed"
+}
+
+// runHooks runs the commands one after the other and stops at the first failure.
+// Like the command, each hook runs in its own process group, terminated when ctx is done or after the timeout of the task.
+func (s *ScheduledTask) runHooks(ctx context.Context, hookType string, commands []string, result *TaskResult) error {
+	if len(commands) == 0 {
+		return nil
+	}
+	// the hooks run as the command
+	runAs, err := s.lookupAccount()
+	if err != nil {
+		s.Logger.Error(err.Error())
+		return err
+	}
+	envs, err := s.hookEnvs(hookType, result, runAs)
+	if err != nil {
+		s.Logger.Error(err.Error())
+		return err
+	}
+	for _, command := range commands {
+		hookResult := &HookResult{Type: hookType, Command: command}
+		result.Hooks = append(result.Hooks, hookResult)
+
+		s.Logger.Debug(fmt.Sprintf("Hook %s (id: %s) run `%s` in %s", hookType, s.Id, command, s.Directory))
+		hookResult.StartAt = time.Now()
+		hookResult.Error = s.runHook(ctx, splitCommand(os.Expand(command, env.GetEnvVars(envs))), envs, runAs, &hookResult.Output)
+		hookResult.FinishAt = time.Now()
+		s.maskBuffer(&hookResult.Output)
+		if hookResult.Error != nil {
+			s.Logger.Error(fmt.Sprintf("Hook %s (id: %s) `%s` failed: %v", hookType, s.Id, command, hookResult.Error))
+			return fmt.Errorf("%s hook `%s` failed: %v", hookType, command, hookResult.Error)
+		}
+	}
+	return nil
+}
+
+// runHook runs the command of a hook until it ends, ctx is done or the timeout of the task expires.
+func (s *ScheduledTask) runHook(ctx context.Context, args []string, envs map[string]string, runAs *account, output *bytes.Buffer) error {
+	hookCtx := ctx
+	if s.GetTimeout() > 0 {
+		var cancel context.CancelFunc
+		hookCtx, cancel = context.WithTimeout(ctx, s.GetTimeout())
+		defer cancel()
+	}
+
+	cmd, exited := groupCommand(hookCtx, args, runAs, s.clock())
+	defer close(exited)
+	cmd.Dir = s.Directory
+	cmd.Env = envList(envs)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	if err != nil && errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("killed after timeout of %s: %v", s.GetTimeout(), err)
+	}
+	return err
+}
+
+// hookEnvs returns the environment of the command with the result of the command for the hooks which run after it.
+func (s *ScheduledTask) hookEnvs(hookType string, result *TaskResult, runAs *account) (map[string]string, error) {
+	envs := s.inheritedEnv(runAs)
+	for key, value := range s.Envs {
+		envs[key] = value
+	}
+	if hookType == HookBefore {
+		return envs, nil
+	}
+
+	if result.outputPath == "" {
+		file, err := os.CreateTemp("", fmt.Sprintf("gtask-%s-*.log", s.Id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to write output of %s for hooks: %v", s.Id, err)
+		}
+		_, err = file.Write(result.Output.Bytes())
+		_ = file.Close()
+		if err != nil {
+			_ = os.Remove(file.Name())
+			return nil, fmt.Errorf("failed to write output of %s for hooks: %v", s.Id, err)
+		}
+		result.outputPath = file.Name()
+	}
+
+	envs[GtaskStatusKey] = result.StatusString()
+	envs[GtaskExitCodeKey] = strconv.Itoa(result.ExitCode())
+	envs[GtaskDurationKey] = fmt.Sprintf("%.3f", result.FinishAt.Sub(result.StartAt).Seconds())
+	envs[GtaskOutputPathKey] = result.outputPath
+	return envs, nil
+}
+
+func envList(envs map[string]string) []string {
+	list := make([]string, 0, len(envs))
+	for key, value := range envs {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,215 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func hookSummary(result *TaskResult) []string {
+	summary := []string{}
+	for _, hook := range result.Hooks {
+		summary = append(summary, hook.Type+": "+hook.StatusString()+": "+hook.Output.String())
+	}
+	return summary
+}
+
+func TestScheduledTask_Execute_Hooks(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		name       string
+		task       *ScheduledTask
+		wantStatus int
+		wantError  string
+		wantOutput string
+		wantHooks  []string
+	}{
+		{
+			name:       "SuccessWithoutHooks",
+			task:       &ScheduledTask{Id: "test", Command: "echo main"},
+			wantStatus: Succeed,
+			wantOutput: "main\n",
+			wantHooks:  []string{},
+		},
+		{
+			name: "SuccessWithAllHooks",
+			task: &ScheduledTask{
+				Id:        "test",
+				Command:   "echo main",
+				Envs:      map[string]string{"MY_VAR": "foo"},
+				Before:    []string{"sh -c 'echo before $MY_VAR'", "echo before2"},
+				After:     []string{"sh -c 'echo after $GTASK_STATUS $GTASK_EXIT_CODE'"},
+				OnSuccess: []string{"cat $GTASK_OUTPUT_PATH"},
+				OnFailure: []string{"echo failure"},
+			},
+			wantStatus: Succeed,
+			wantOutput: "main\n",
+			wantHooks: []string{
+				"before: succeed: before foo\n",
+				"before: succeed: before2\n",
+				"on_success: succeed: main\n",
+				"after: succeed: after succeed 0\n",
+			},
+		},
+		{
+			name: "SuccessOnFailure",
+			task: &ScheduledTask{
+				Id:        "test",
+				Command:   "sh -c 'echo boom; exit 3'",
+				After:     []string{"echo after"},
+				OnSuccess: []string{"echo success"},
+				OnFailure: []string{"sh -c 'echo failure $GTASK_STATUS $GTASK_EXIT_CODE'"},
+			},
+			wantStatus: Failed,
+			wantError:  "exit status 3",
+			wantOutput: "boom\n",
+			wantHooks: []string{
+				"on_failure: succeed: failure failed 3\n",
+				"after: succeed: after\n",
+			},
+		},
+		{
+			name: "FailedBeforeHook",
+			task: &ScheduledTask{
+				Id:        "test",
+				Command:   "echo main",
+				Before:    []string{"sh -c 'echo locked; exit 1'", "echo never"},
+				After:     []string{"echo after"},
+				OnFailure: []string{"echo failure"},
+			},
+			wantStatus: Failed,
+			wantError:  "before hook `sh -c 'echo locked; exit 1'` failed: exit status 1",
+			wantHooks: []string{
+				"before: failed: locked\n",
+				"on_failure: succeed: failure\n",
+				"after: succeed: after\n",
+			},
+		},
+		{
+			name: "FailedBeforeHookTimeout",
+			task: &ScheduledTask{
+				Id:        "test",
+				Command:   "echo main",
+				Timeout:   durationPtr(200 * time.Millisecond),
+				Before:    []string{"sh -c 'echo start; sleep 5'"},
+				OnFailure: []string{"echo failure"},
+			},
+			wantStatus: Failed,
+			wantError:  "before hook `sh -c 'echo start; sleep 5'` failed: killed after timeout of 200ms: signal: terminated",
+			wantHooks: []string{
+				"before: failed: start\n",
+				"on_failure: succeed: failure\n",
+			},
+		},
+		{
+			name: "SuccessAfterHookFailed",
+			task: &ScheduledTask{
+				Id:      "test",
+				Command: "echo main",
+				After:   []string{"wrong", "echo never"},
+			},
+			wantStatus: Succeed,
+			wantOutput: "main\n",
+			wantHooks:  []string{"after: failed: "},
+		},
+		{
+			name: "SuccessSkippedWithoutHooks",
+			task: &ScheduledTask{
+				Id:         "test",
+				Command:    "echo main",
+				Envs:       map[string]string{"MY_VAR": "5"},
+				Expression: "MY_VAR != 5",
+				Before:     []string{"echo before"},
+				After:      []string{"echo after"},
+			},
+			wantStatus: Skipped,
+			wantHooks:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.Logger = logger
+			res := tt.task.Execute(context.Background())
+			assert.Equal(t, tt.wantStatus, res.Status)
+			if tt.wantError == "" {
+				assert.NoError(t, res.Error)
+			} else {
+				assert.EqualError(t, res.Error, tt.wantError)
+			}
+			assert.Equal(t, tt.wantOutput, res.Output.String())
+			assert.Equal(t, tt.wantHooks, hookSummary(res))
+		})
+	}
+}
+
+func TestScheduledTask_Execute_HooksInterrupted(t *testing.T) {
+	task := &ScheduledTask{
+		Id:        "test",
+		Command:   "echo main",
+		Before:    []string{"sh -c 'sleep 5 & wait'"},
+		OnFailure: []string{"echo cleanup"},
+		After:     []string{"echo after"},
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	res := task.Execute(ctx)
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Equal(t, Interrupted, res.Status)
+	assert.EqualError(t, res.Error, "interrupted (context canceled): before hook `sh -c 'sleep 5 & wait'` failed: signal: terminated")
+	// the hooks after the command still run once the task is interrupted
+	assert.Equal(t, []string{"before: failed: ", "on_failure: succeed: cleanup\n", "after: succeed: after\n"}, hookSummary(res))
+}
+
+func TestScheduledTask_Execute_PostHooksBoundedOnInterrupt(t *testing.T) {
+	fakeClock := clockwork.NewFakeClock()
+	started := path.Join(t.TempDir(), "started")
+	task := &ScheduledTask{
+		Id:      "test",
+		Command: "sh -c 'sleep 5 & wait'",
+		After:   []string{fmt.Sprintf("sh -c 'touch %s; sleep 5 & wait'", started)},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Clock:   fakeClock,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	results := make(chan *TaskResult)
+	go func() { results <- task.Execute(ctx) }()
+	assert.Eventually(t, func() bool { _, err := os.Stat(started); return err == nil }, 3*time.Second, 10*time.Millisecond)
+	// the after hooks are canceled TerminateDelay after the interruption
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(TerminateDelay)
+	res := <-results
+	assert.Equal(t, Interrupted, res.Status)
+	assert.Equal(t, []string{"after: failed: "}, hookSummary(res))
+	assert.EqualError(t, res.Hooks[0].Error, "signal: terminated")
+}
+
+func TestScheduledTask_Execute_HooksEnvs(t *testing.T) {
+	task := &ScheduledTask{
+		Id:      "test",
+		Command: "echo main",
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		After:   []string{"sh -c 'echo $GTASK_DURATION; echo $GTASK_OUTPUT_PATH'"},
+	}
+	res := task.Execute(context.Background())
+	assert.Len(t, res.Hooks, 1)
+	var duration float64
+	var outputPath string
+	_, err := fmt.Sscanf(res.Hooks[0].Output.String(), "%f\n%s\n", &duration, &outputPath)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, duration, 0.0)
+	assert.NotEmpty(t, outputPath)
+	_, err = os.Stat(outputPath)
+	assert.True(t, os.IsNotExist(err))
+}
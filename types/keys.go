@@ -6,3 +6,10 @@ const (
 	GtaskDirKey       = "GTASK_DIR"
 	GtaskIDKey        = "GTASK_ID"
 )
+
+const (
+	GtaskStatusKey     = "GTASK_STATUS"
+	GtaskExitCodeKey   = "GTASK_EXIT_CODE"
+	GtaskDurationKey   = "GTASK_DURATION"
+	GtaskOutputPathKey = "GTASK_OUTPUT_PATH"
+)
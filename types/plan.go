@@ -0,0 +1,49 @@
+package types
+
+import "fmt"
+
+const (
+	PlanRun    = "run"
+	PlanSkip   = "skip"
+	PlanFail   = "fail"
+	PlanFilter = "filter"
+)
+
+// TaskPlan is what would happen to a task, computed without running anything (dry run).
+type TaskPlan struct {
+	Task      *ScheduledTask
+	Action    string
+	Reason    string
+	Args      []string
+	Directory string
+	User      string
+	Env       []string
+}
+
+// Plan evaluates the condition and expands the command like Execute does, without running it.
+func (s *ScheduledTask) Plan() *TaskPlan {
+	plan := &TaskPlan{Task: s, Action: PlanRun, Directory: s.Directory}
+	resultEval, err := s.EvalCondition()
+	if err != nil {
+		plan.Action = PlanFail
+		plan.Reason = err.Error()
+		return plan
+	}
+	if !resultEval {
+		plan.Action = PlanSkip
+		plan.Reason = fmt.Sprintf("condition `%s` is false", s.Expression)
+		return plan
+	}
+	runAs, err := s.lookupAccount()
+	if err != nil {
+		plan.Action = PlanFail
+		plan.Reason = err.Error()
+		return plan
+	}
+	if runAs != nil {
+		plan.User = runAs.String()
+	}
+	plan.Args = s.commandArgs(runAs)
+	plan.Env = s.commandEnv(runAs)
+	return plan
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScheduledTask_Plan(t *testing.T) {
+	t.Setenv("PLAN_ENV", "prod")
+	tests := []struct {
+		name       string
+		expression string
+		want       *TaskPlan
+	}{
+		{
+			name: "SuccessRun",
+			want: &TaskPlan{Action: PlanRun, Args: []string{"echo", "my_task", "hello world"}, Directory: "/tmp"},
+		},
+		{
+			name:       "SuccessRunWithCondition",
+			expression: "PLAN_ENV == \"prod\" and MY_VAR == 5",
+			want:       &TaskPlan{Action: PlanRun, Args: []string{"echo", "my_task", "hello world"}, Directory: "/tmp"},
+		},
+		{
+			name:       "SuccessSkip",
+			expression: "MY_VAR == 6",
+			want:       &TaskPlan{Action: PlanSkip, Reason: "condition `MY_VAR == 6` is false", Directory: "/tmp"},
+		},
+		{
+			name:       "SuccessFail",
+			expression: "MY_VAR $= 5",
+			want:       &TaskPlan{Action: PlanFail, Directory: "/tmp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ScheduledTask{
+				Id:         "my_task",
+				Command:    "echo ${MY_ID} 'hello world'",
+				Directory:  "/tmp",
+				Expression: tt.expression,
+				Envs:       map[string]string{"MY_VAR": "5", "MY_ID": "my_task"},
+			}
+			got := s.Plan()
+			assert.Equal(t, s, got.Task)
+			assert.Equal(t, tt.want.Action, got.Action)
+			assert.Equal(t, tt.want.Args, got.Args)
+			assert.Equal(t, tt.want.Directory, got.Directory)
+			assert.Nil(t, s.LatestTaskResult)
+			if tt.want.Action == PlanFail {
+				assert.Contains(t, got.Reason, "failed to evaluate expression for my_task")
+			} else {
+				assert.Equal(t, tt.want.Reason, got.Reason)
+			}
+			if tt.want.Action == PlanRun {
+				assert.Contains(t, got.Env, "PLAN_ENV=prod")
+			} else {
+				assert.Nil(t, got.Env)
+			}
+		})
+	}
+}
@@ -2,15 +2,23 @@ package types
 
 import (
 	"bytes"
+	"context"
 	"dario.cat/mergo"
+	"errors"
 	"fmt"
 	"github.com/alexandreh2ag/go-task/condition"
 	"github.com/alexandreh2ag/go-task/env"
+	"github.com/alexandreh2ag/go-task/limits"
 	"github.com/alexandreh2ag/go-task/log"
+	"github.com/alexandreh2ag/go-task/secret"
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/afero"
 	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -19,35 +27,62 @@ const (
 	Succeed
 	Failed
 	Skipped
+	TimedOut
+	Interrupted
 )
 
+// TerminateDelay is the delay between SIGTERM and SIGKILL sent to the process group of a command canceled.
+const TerminateDelay = 10 * time.Second
+
 type ScheduledTasks = []*ScheduledTask
 
 type ScheduledTask struct {
 	Id               string            `mapstructure:"id" validate:"required,excludesall=!@#$ "`
-	CronExpr         string            `mapstructure:"expr" validate:"required,cron-expr"`
+	CronExpr         string            `mapstructure:"expr" validate:"required_without=DependsOn,omitempty,cron-expr"`
 	Command          string            `mapstructure:"command" validate:"required"`
 	Expression       string            `mapstructure:"if"`
 	Directory        string            `mapstructure:"directory" validate:"omitempty,required,dirpath"`
 	Envs             map[string]string `mapstructure:"environments"`
+	InheritEnv       any               `mapstructure:"inherit_env" validate:"omitempty,inherit-env"`
+	EnvFile          []string          `mapstructure:"env_file" validate:"omitempty,dive,required"`
+	Timeout          *time.Duration    `mapstructure:"timeout" validate:"omitempty,min=0"`
+	NotifyOn         []string          `mapstructure:"notify_on" validate:"omitempty,dive,oneof=failure recovery success timeout output"`
+	MailTo           []string          `mapstructure:"mail_to" validate:"omitempty,dive,email"`
+	HeartbeatUrl     string            `mapstructure:"heartbeat_url" validate:"omitempty,url"`
+	DependsOn        []string          `mapstructure:"depends_on" validate:"omitempty,dive,required"`
+	Before           []string          `mapstructure:"before" validate:"omitempty,dive,required"`
+	After            []string          `mapstructure:"after" validate:"omitempty,dive,required"`
+	OnSuccess        []string          `mapstructure:"on_success" validate:"omitempty,dive,required"`
+	OnFailure        []string          `mapstructure:"on_failure" validate:"omitempty,dive,required"`
+	Exclusive        bool              `mapstructure:"exclusive"`
+	Timezone         string            `mapstructure:"timezone" validate:"omitempty,timezone"`
+	Jitter           *time.Duration    `mapstructure:"jitter" validate:"omitempty,min=0"`
+	ExcludeCalendars []string          `mapstructure:"exclude_calendars" validate:"omitempty,dive,required"`
+	User             string            `mapstructure:"user" validate:"omitempty,required,account"`
+	Group            string            `mapstructure:"group" validate:"omitempty,required,account"`
+	Limits           *limits.Config    `mapstructure:"limits" validate:"omitempty"`
 	LatestTaskResult *TaskResult
 
 	Logger *slog.Logger
+	// Clock times the SIGKILL of the process group of a command canceled, the real clock when nil.
+	Clock  clockwork.Clock
+	masker *secret.Masker
+	// mu guards LatestTaskResult, the runs of a task can overlap.
+	mu sync.Mutex
 }
 
-func (s *ScheduledTask) Execute() *TaskResult {
+// Execute runs the command until it ends or ctx is canceled: its process group is then terminated and the run is interrupted.
+func (s *ScheduledTask) Execute(ctx context.Context) *TaskResult {
 	var cmd *exec.Cmd
 	result := &TaskResult{Status: Pending, Task: s}
-	s.LatestTaskResult = result
+	s.SetLatestTaskResult(result)
 
-	contextEnv := env.GetEnvs()
-	_ = mergo.Merge(&contextEnv, s.Envs, mergo.WithOverride)
-	resultEval, err := condition.EvalExpression(s.Expression, contextEnv)
+	resultEval, err := s.EvalCondition()
 	if err != nil {
 		result.Status = Failed
 		result.StartAt = time.Now()
 		result.FinishAt = time.Now()
-		result.Error = fmt.Errorf("failed to evaluate expression for %s: %v", s.Id, err)
+		result.Error = err
 		return result
 	}
 	if !resultEval {
@@ -58,23 +93,79 @@ func (s *ScheduledTask) Execute() *TaskResult {
 		return result
 	}
 
-	args := splitCommand(os.Expand(s.Command, env.GetEnvVars(s.Envs)))
-	if len(args) > 1 {
-		cmd = exec.Command(args[0], args[1:]...)
-	} else {
-		cmd = exec.Command(args[0])
+	runAs, err := s.lookupAccount()
+	if err != nil {
+		result.Status = Failed
+		result.StartAt = time.Now()
+		result.FinishAt = time.Now()
+		result.Error = err
+		return result
+	}
+
+	defer s.runPostHooks(ctx, result)
+	if err = s.runHooks(ctx, HookBefore, s.Before, result); err != nil {
+		result.Status = Failed
+		result.StartAt = time.Now()
+		result.FinishAt = time.Now()
+		result.Error = err
+		if ctx.Err() != nil {
+			result.Status = Interrupted
+			result.Error = fmt.Errorf("interrupted (%v): %v", context.Cause(ctx), err)
+		}
+		return result
+	}
+
+	execCtx := ctx
+	if s.GetTimeout() > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, s.GetTimeout())
+		defer cancel()
+	}
+
+	cmd, exited := groupCommand(execCtx, s.commandArgs(runAs), runAs, s.clock())
+
+	if s.Limits != nil && s.Limits.Cgroup != nil {
+		cgroup, err := s.Limits.Cgroup.Create(fmt.Sprintf("gtask-%s-%d", s.Id, time.Now().UnixNano()))
+		if err == nil {
+			err = cgroup.Attach(cmd.SysProcAttr)
+		}
+		if err != nil {
+			if cgroup != nil {
+				_ = cgroup.Remove()
+			}
+			result.Status = Failed
+			result.StartAt = time.Now()
+			result.FinishAt = time.Now()
+			result.Error = fmt.Errorf("failed to create cgroup of task %s: %v", s.Id, err)
+			return result
+		}
+		defer func() {
+			if errRemove := cgroup.Remove(); errRemove != nil {
+				s.Logger.Warn(fmt.Sprintf("failed to remove cgroup %s: %v", cgroup.Path, errRemove))
+			}
+		}()
 	}
 
 	cmd.Dir = s.Directory
+	cmd.Env = s.commandEnv(runAs)
 	cmd.Stdout = &result.Output
 	cmd.Stderr = &result.Output
 
 	s.Logger.Debug(fmt.Sprintf("Command (id: %s) run `%s` in %s", s.Id, s.Command, s.Directory))
 	result.StartAt = time.Now()
-	result.Error = cmd.Run()
+	result.Error = s.run(cmd)
 	result.FinishAt = time.Now()
+	result.Usage = newUsage(cmd.ProcessState)
+	close(exited)
+	s.maskBuffer(&result.Output)
 
-	if result.Error != nil {
+	if ctx.Err() != nil {
+		result.Status = Interrupted
+		result.Error = fmt.Errorf("interrupted (%v): %v", context.Cause(ctx), result.Error)
+	} else if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+		result.Status = TimedOut
+		result.Error = fmt.Errorf("killed after timeout of %s: %v", s.GetTimeout(), result.Error)
+	} else if result.Error != nil {
 		result.Status = Failed
 	} else {
 		result.Status = Succeed
@@ -84,13 +175,222 @@ func (s *ScheduledTask) Execute() *TaskResult {
 	return result
 }
 
+// ResolveSecrets replaces the secret references of the environments by their secret, the secrets are then masked.
+func (s *ScheduledTask) ResolveSecrets(resolver *secret.Resolver) error {
+	var secrets []string
+	for key, value := range s.Envs {
+		resolved, isSecret, err := resolver.Resolve(value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve environment %s of task %s: %v", key, s.Id, err)
+		}
+		if isSecret {
+			s.Envs[key] = resolved
+			secrets = append(secrets, resolved)
+		}
+	}
+	s.masker = secret.NewMasker(secrets)
+	if s.masker != nil && s.Logger != nil {
+		s.Logger = slog.New(s.masker.Handler(s.Logger.Handler()))
+	}
+	return nil
+}
+
+// Mask replaces the secrets of the task in text by secret.Mask.
+func (s *ScheduledTask) Mask(text string) string {
+	return s.masker.Mask(text)
+}
+
+func (s *ScheduledTask) maskBuffer(buffer *bytes.Buffer) {
+	if s.masker == nil {
+		return
+	}
+	masked := s.masker.Mask(buffer.String())
+	buffer.Reset()
+	buffer.WriteString(masked)
+}
+
+// EvalCondition evaluates the condition `if` of the task with the environment of gtask and the environments of the task.
+func (s *ScheduledTask) EvalCondition() (bool, error) {
+	contextEnv := env.GetEnvs()
+	_ = mergo.Merge(&contextEnv, s.Envs, mergo.WithOverride)
+	resultEval, err := condition.EvalExpression(s.Expression, contextEnv)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression for %s: %v", s.Id, err)
+	}
+	return resultEval, nil
+}
+
+// commandArgs returns the argv of the command, expanded with the environments of the task and of its user.
+func (s *ScheduledTask) commandArgs(runAs *account) []string {
+	envs := s.Envs
+	if runAs != nil {
+		envs = runAs.env()
+		for key, value := range s.Envs {
+			envs[key] = value
+		}
+	}
+	return splitCommand(os.Expand(s.Command, env.GetEnvVars(envs)))
+}
+
+// commandEnv returns the environment of the command: the variables inherited then the environments of the task.
+func (s *ScheduledTask) commandEnv(runAs *account) []string {
+	envs := s.inheritedEnv(runAs)
+	for key, value := range s.Envs {
+		envs[key] = value
+	}
+	return envList(envs)
+}
+
+// inheritedEnv returns the variables of gtask, with HOME, USER and LOGNAME of the user of the task, kept by inherit_env.
+func (s *ScheduledTask) inheritedEnv(runAs *account) map[string]string {
+	envs := env.GetEnvs()
+	if runAs != nil {
+		for key, value := range runAs.env() {
+			envs[key] = value
+		}
+	}
+	return env.Inherited(envs, s.InheritEnv)
+}
+
+// run starts the command, with the limits of the task applied before its program runs, and waits for it.
+func (s *ScheduledTask) run(cmd *exec.Cmd) error {
+	start := cmd.Start
+	if s.Limits != nil {
+		start = func() error { return s.Limits.Start(cmd) }
+	}
+	if err := start(); err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
+// groupCommand returns the command of args run as runAs in its own process group, terminated with its children
+// by terminateGroup when ctx is done. exited must be closed once the command is waited.
+// The output is read until every process which holds it exits (ex: a child left in background),
+// the wait of the output is bounded only once the command is canceled.
+func groupCommand(ctx context.Context, args []string, runAs *account, clock clockwork.Clock) (*exec.Cmd, chan struct{}) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if runAs != nil {
+		cmd.SysProcAttr.Credential = runAs.credential
+	}
+	exited := make(chan struct{})
+	cmd.Cancel = func() error {
+		cmd.WaitDelay = TerminateDelay + time.Second
+		return terminateGroup(cmd.Process.Pid, exited, clock)
+	}
+	return cmd, exited
+}
+
+// terminateGroup sends SIGTERM to the process group then SIGKILL after TerminateDelay when it has not exited.
+func terminateGroup(pid int, exited <-chan struct{}, clock clockwork.Clock) error {
+	go func() {
+		select {
+		case <-exited:
+		case <-clock.After(TerminateDelay):
+			_ = syscall.Kill(-pid, syscall.SIGKILL)
+		}
+	}()
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+// GetTimeout returns the timeout of the task, 0 when it has none.
+func (s *ScheduledTask) GetTimeout() time.Duration {
+	if s.Timeout == nil {
+		return 0
+	}
+	return *s.Timeout
+}
+
+func (s *ScheduledTask) clock() clockwork.Clock {
+	if s.Clock == nil {
+		return clockwork.NewRealClock()
+	}
+	return s.Clock
+}
+
+// runPostHooks runs on_success or on_failure hooks then after hooks, their failures do not change the status of the task.
+// They still run once ctx is done (ex: daemon shutdown), they are then canceled after TerminateDelay.
+func (s *ScheduledTask) runPostHooks(ctx context.Context, result *TaskResult) {
+	hookCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancel(nil)
+	stop := context.AfterFunc(ctx, func() {
+		select {
+		case <-hookCtx.Done():
+		case <-s.clock().After(TerminateDelay):
+			cancel(context.Cause(ctx))
+		}
+	})
+	defer stop()
+
+	defer func() {
+		if result.outputPath != "" {
+			_ = os.Remove(result.outputPath)
+			result.outputPath = ""
+		}
+	}()
+	if result.Status == Succeed {
+		_ = s.runHooks(hookCtx, HookOnSuccess, s.OnSuccess, result)
+	} else {
+		_ = s.runHooks(hookCtx, HookOnFailure, s.OnFailure, result)
+	}
+	_ = s.runHooks(hookCtx, HookAfter, s.After, result)
+}
+
+// Skip records a result skipped before the command runs with the reason of the skip.
+func (s *ScheduledTask) Skip(reason string) *TaskResult {
+	now := time.Now()
+	result := &TaskResult{Status: Skipped, Reason: reason, Task: s, StartAt: now, FinishAt: now}
+	s.SetLatestTaskResult(result)
+	s.Logger.Info(fmt.Sprintf("Scheduled task %s skipped: %s", s.Id, reason))
+
+	return result
+}
+
+// Fail records a result failed before the command runs.
+func (s *ScheduledTask) Fail(err error) *TaskResult {
+	now := time.Now()
+	result := &TaskResult{Status: Failed, Error: err, Task: s, StartAt: now, FinishAt: now}
+	s.SetLatestTaskResult(result)
+	s.Logger.Error(fmt.Sprintf("Scheduled task %s failed: %v", s.Id, err))
+
+	return result
+}
+
+// GetLatestTaskResult returns the result of the latest run of the task, nil when it has not run.
+func (s *ScheduledTask) GetLatestTaskResult() *TaskResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.LatestTaskResult
+}
+
+// SetLatestTaskResult records result as the result of the latest run of the task.
+func (s *ScheduledTask) SetLatestTaskResult(result *TaskResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LatestTaskResult = result
+}
+
+// GetLocation returns the location of the timezone of the task, fallback when it has none.
+func (s *ScheduledTask) GetLocation(fallback *time.Location) (*time.Location, error) {
+	if s.Timezone == "" {
+		return fallback, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
 type TaskResult struct {
 	Status   int
+	Reason   string
 	Error    error
 	Output   bytes.Buffer
 	Task     *ScheduledTask
 	StartAt  time.Time
 	FinishAt time.Time
+	Hooks    []*HookResult
+	Usage    *Usage
+
+	outputPath string
 }
 
 func (t *TaskResult) StatusString() string {
@@ -103,22 +403,62 @@ func (t *TaskResult) StatusString() string {
 		return "failed"
 	case Skipped:
 		return "skipped"
+	case TimedOut:
+		return "timeout"
+	case Interrupted:
+		return "interrupted"
 	}
 	return "unknown"
 }
 
-func PrepareScheduledTasks(tasks ScheduledTasks, logger *slog.Logger, workingDir string, envVars map[string]string) {
+// ExitCode returns the exit code of the command or -1 when the command did not exit by itself.
+func (t *TaskResult) ExitCode() int {
+	if t.Error == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(t.Error, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// PrepareScheduledTasks completes the tasks with defaults, their environments are from the lowest to the highest precedence:
+// the global env files, the default environments, the env files of the task, its environments and envVars.
+func PrepareScheduledTasks(fs afero.Fs, tasks ScheduledTasks, logger *slog.Logger, defaults ScheduledDefaults, envVars map[string]string, inheritEnv any, envFiles []string) error {
 	for _, task := range tasks {
 		task.Logger = logger.With(log.TaskKey, task.Id)
-		task.Envs = env.ToUpperKeys(task.Envs)
-		_ = mergo.Merge(&task.Envs, envVars, mergo.WithOverride)
+		if task.User == "" {
+			task.User = defaults.User
+		}
+		if task.InheritEnv == nil {
+			task.InheritEnv = inheritEnv
+		}
+		if task.Timeout == nil && defaults.Timeout > 0 {
+			timeout := defaults.Timeout
+			task.Timeout = &timeout
+		}
 
 		if task.Directory == "" {
-			task.Directory = workingDir
+			task.Directory = defaults.Directory
+		}
+
+		globalEnvs, err := env.LoadFiles(fs, task.Directory, envFiles)
+		if err != nil {
+			return fmt.Errorf("failed to load env files of task %s: %v", task.Id, err)
+		}
+		fileEnvs, err := env.LoadFiles(fs, task.Directory, task.EnvFile)
+		if err != nil {
+			return fmt.Errorf("failed to load env files of task %s: %v", task.Id, err)
 		}
+		task.Envs = env.WithDefaults(env.ToUpperKeys(task.Envs), fileEnvs)
+		task.Envs = env.WithDefaults(task.Envs, env.ToUpperKeys(defaults.Envs))
+		task.Envs = env.WithDefaults(task.Envs, globalEnvs)
+		_ = mergo.Merge(&task.Envs, envVars, mergo.WithOverride)
 		task.Envs[GtaskIDKey] = task.Id
 		task.Envs[GtaskDirKey] = task.Directory
 	}
+	return nil
 }
 
 func splitCommand(command string) []string {
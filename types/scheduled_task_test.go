@@ -2,13 +2,22 @@ package types
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"github.com/alexandreh2ag/go-task/limits"
 	"github.com/alexandreh2ag/go-task/log"
+	"github.com/alexandreh2ag/go-task/secret"
 	gtaskValidator "github.com/alexandreh2ag/go-task/validator"
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"log/slog"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -20,7 +29,7 @@ func Test_ScheduledTask_SuccessValidate(t *testing.T) {
 		CronExpr: "* * * * *",
 		Command:  "fake",
 	}
-	err := validate.Struct(scheduled)
+	err := validate.Struct(&scheduled)
 
 	assert.NoError(t, err)
 }
@@ -32,8 +41,10 @@ func Test_ScheduledTask_SuccessValidateWithOptionalData(t *testing.T) {
 		CronExpr:  "* * * * *",
 		Command:   "fake",
 		Directory: "/tmp/test/",
+		User:      "www-data",
+		Group:     "_apt",
 	}
-	err := validate.Struct(scheduled)
+	err := validate.Struct(&scheduled)
 
 	assert.NoError(t, err)
 }
@@ -44,7 +55,7 @@ func Test_ScheduledTask_ErrorValidate(t *testing.T) {
 		Id:       "test",
 		CronExpr: "* * * * *",
 	}
-	err := validate.Struct(scheduled)
+	err := validate.Struct(&scheduled)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Field validation for 'Command' failed on the 'required' tag")
@@ -57,36 +68,60 @@ func Test_ScheduledTask_ErrorValidateComplex(t *testing.T) {
 		CronExpr:  "wrong",
 		Command:   "fake",
 		Directory: "wrong",
+		User:      "wrong user",
+		Group:     "wrong/group",
 	}
-	err := validate.Struct(scheduled)
+	err := validate.Struct(&scheduled)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Field validation for 'CronExpr' failed on the 'cron-expr' tag")
 	assert.Contains(t, err.Error(), "Field validation for 'Directory' failed on the 'dirpath' tag")
+	assert.Contains(t, err.Error(), "Field validation for 'User' failed on the 'account' tag")
+	assert.Contains(t, err.Error(), "Field validation for 'Group' failed on the 'account' tag")
+}
+
+func Test_ScheduledTask_SuccessValidateWithoutExprWithDependsOn(t *testing.T) {
+	validate := gtaskValidator.New()
+	scheduled := ScheduledTask{
+		Id:        "test",
+		Command:   "fake",
+		DependsOn: []string{"other"},
+	}
+	err := validate.Struct(&scheduled)
+	assert.NoError(t, err)
+
+	scheduled.DependsOn = nil
+	err = validate.Struct(&scheduled)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Field validation for 'CronExpr' failed on the 'required_without' tag")
 }
 
 func TestPrepareScheduledTasks(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/etc/gtask/.env", []byte("GLOBAL=global\nFOO=global\n"), 0644)
+	_ = afero.WriteFile(fs, "/app/foo/.env", []byte("FOO=file\nBAR=file\nBAZ=file\n"), 0644)
 	type args struct {
 		tasks      ScheduledTasks
 		logger     *slog.Logger
-		user       string
 		id         string
-		workingDir string
+		defaults   ScheduledDefaults
 		envs       map[string]string
+		inheritEnv any
+		envFiles   []string
 	}
 	tests := []struct {
-		name string
-		args args
-		want ScheduledTasks
+		name    string
+		args    args
+		want    ScheduledTasks
+		wantErr string
 	}{
 		{
 			name: "SuccessEmptyTasks",
 			args: args{
-				tasks:      ScheduledTasks{},
-				logger:     logger,
-				user:       "foo",
-				workingDir: "/app/foo/",
+				tasks:    ScheduledTasks{},
+				logger:   logger,
+				defaults: ScheduledDefaults{Directory: "/app/foo/", User: "foo"},
 			},
 			want: ScheduledTasks{},
 		},
@@ -95,22 +130,93 @@ func TestPrepareScheduledTasks(t *testing.T) {
 			args: args{
 				tasks: ScheduledTasks{
 					&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *"},
-					&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/"},
+					&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", User: "bar"},
+				},
+				logger:   logger,
+				defaults: ScheduledDefaults{Directory: "/app/foo/", User: "foo"},
+				envs:     map[string]string{"foo": "bar"},
+			},
+			want: ScheduledTasks{
+				&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/foo/", User: "foo", Logger: logger.With(log.TaskKey, "test"), Envs: map[string]string{GtaskIDKey: "test", GtaskDirKey: "/app/foo/", "foo": "bar"}},
+				&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", User: "bar", Logger: logger.With(log.TaskKey, "test2"), Envs: map[string]string{GtaskIDKey: "test2", GtaskDirKey: "/app/bar/", "foo": "bar"}},
+			},
+		},
+		{
+			name: "SuccessInheritEnv",
+			args: args{
+				tasks: ScheduledTasks{
+					&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *"},
+					&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", InheritEnv: true},
 				},
 				logger:     logger,
-				user:       "foo",
-				workingDir: "/app/foo/",
-				envs:       map[string]string{"foo": "bar"},
+				defaults:   ScheduledDefaults{Directory: "/app/foo/"},
+				envs:       map[string]string{},
+				inheritEnv: []any{"PATH"},
 			},
 			want: ScheduledTasks{
-				&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/foo/", Logger: logger.With(log.TaskKey, "test"), Envs: map[string]string{GtaskIDKey: "test", GtaskDirKey: "/app/foo/", "foo": "bar"}},
-				&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", Logger: logger.With(log.TaskKey, "test2"), Envs: map[string]string{GtaskIDKey: "test2", GtaskDirKey: "/app/bar/", "foo": "bar"}},
+				&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/foo/", InheritEnv: []any{"PATH"}, Logger: logger.With(log.TaskKey, "test"), Envs: map[string]string{GtaskIDKey: "test", GtaskDirKey: "/app/foo/"}},
+				&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/foo/", InheritEnv: true, Logger: logger.With(log.TaskKey, "test2"), Envs: map[string]string{GtaskIDKey: "test2", GtaskDirKey: "/app/foo/"}},
 			},
 		},
+		{
+			name: "SuccessEnvFiles",
+			args: args{
+				tasks: ScheduledTasks{
+					&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", EnvFile: []string{".env"}, Envs: map[string]string{"baz": "task"}},
+				},
+				logger:   logger,
+				defaults: ScheduledDefaults{Directory: "/app/foo/"},
+				envs:     map[string]string{"BAR": "flag"},
+				envFiles: []string{"/etc/gtask/.env"},
+			},
+			want: ScheduledTasks{
+				&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/foo/", EnvFile: []string{".env"}, Logger: logger.With(log.TaskKey, "test"), Envs: map[string]string{GtaskIDKey: "test", GtaskDirKey: "/app/foo/", "GLOBAL": "global", "FOO": "file", "BAR": "flag", "BAZ": "task"}},
+			},
+		},
+		{
+			name: "SuccessDefaults",
+			args: args{
+				tasks: ScheduledTasks{
+					&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", EnvFile: []string{".env"}, Envs: map[string]string{"baz": "task"}},
+					&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", User: "bar", Timeout: durationPtr(time.Hour)},
+					&ScheduledTask{Id: "test3", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", User: "bar", Timeout: durationPtr(0)},
+				},
+				logger: logger,
+				defaults: ScheduledDefaults{
+					Directory: "/app/foo/",
+					User:      "foo",
+					Envs:      map[string]string{"global": "default", "foo": "default", "qux": "default"},
+					Timeout:   time.Minute,
+				},
+				envs:     map[string]string{"BAR": "flag"},
+				envFiles: []string{"/etc/gtask/.env"},
+			},
+			want: ScheduledTasks{
+				&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/foo/", User: "foo", Timeout: durationPtr(time.Minute), EnvFile: []string{".env"}, Logger: logger.With(log.TaskKey, "test"), Envs: map[string]string{GtaskIDKey: "test", GtaskDirKey: "/app/foo/", "GLOBAL": "default", "FOO": "file", "BAR": "flag", "BAZ": "task", "QUX": "default"}},
+				&ScheduledTask{Id: "test2", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", User: "bar", Timeout: durationPtr(time.Hour), Logger: logger.With(log.TaskKey, "test2"), Envs: map[string]string{GtaskIDKey: "test2", GtaskDirKey: "/app/bar/", "GLOBAL": "default", "FOO": "default", "BAR": "flag", "QUX": "default"}},
+				&ScheduledTask{Id: "test3", Command: "cmd", CronExpr: "* * * * *", Directory: "/app/bar/", User: "bar", Timeout: durationPtr(0), Logger: logger.With(log.TaskKey, "test3"), Envs: map[string]string{GtaskIDKey: "test3", GtaskDirKey: "/app/bar/", "GLOBAL": "default", "FOO": "default", "BAR": "flag", "QUX": "default"}},
+			},
+		},
+		{
+			name: "ErrorEnvFile",
+			args: args{
+				tasks: ScheduledTasks{
+					&ScheduledTask{Id: "test", Command: "cmd", CronExpr: "* * * * *", EnvFile: []string{"missing.env"}},
+				},
+				logger:   logger,
+				defaults: ScheduledDefaults{Directory: "/app/foo/"},
+			},
+			wantErr: "failed to load env files of task test: failed to read env file /app/foo/missing.env",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			PrepareScheduledTasks(tt.args.tasks, tt.args.logger, tt.args.workingDir, tt.args.envs)
+			err := PrepareScheduledTasks(fs, tt.args.tasks, tt.args.logger, tt.args.defaults, tt.args.envs, tt.args.inheritEnv, tt.args.envFiles)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
 			assert.Equal(t, tt.want, tt.args.tasks)
 		})
 	}
@@ -143,6 +249,16 @@ func TestTaskResult_StatusString(t1 *testing.T) {
 			Status: Skipped,
 			want:   "skipped",
 		},
+		{
+			name:   "SuccessWithTimedOut",
+			Status: TimedOut,
+			want:   "timeout",
+		},
+		{
+			name:   "SuccessWithInterrupted",
+			Status: Interrupted,
+			want:   "interrupted",
+		},
 		{
 			name:   "SuccessWithUnknown",
 			Status: -1,
@@ -239,11 +355,15 @@ func TestScheduledTask_Execute(t *testing.T) {
 				Envs:       tt.fields.Envs,
 			}
 			tt.want.Task = s
-			res := s.Execute()
+			res := s.Execute(context.Background())
 			assert.WithinDuration(t, s.LatestTaskResult.StartAt, s.LatestTaskResult.FinishAt, time.Second)
 			assert.NotNil(t, s.LatestTaskResult.Task)
 			s.LatestTaskResult.StartAt = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
 			s.LatestTaskResult.FinishAt = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+			if res.Usage != nil {
+				assert.Greater(t, res.Usage.MaxRSS, int64(0))
+				res.Usage = nil
+			}
 			assert.Equalf(t, tt.want, res, "Execute()")
 		})
 	}
@@ -260,7 +380,7 @@ func TestScheduledTask_Execute_Eval_Fail(t *testing.T) {
 		Id:         "my_task",
 	}
 
-	res := s.Execute()
+	res := s.Execute(context.Background())
 	assert.WithinDuration(t, s.LatestTaskResult.StartAt, s.LatestTaskResult.FinishAt, time.Second)
 	assert.NotNil(t, s.LatestTaskResult.Task)
 	s.LatestTaskResult.StartAt = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
@@ -270,6 +390,59 @@ func TestScheduledTask_Execute_Eval_Fail(t *testing.T) {
 	assert.Contains(t, res.Error.Error(), "failed to evaluate expression for my_task")
 }
 
+func TestScheduledTask_Execute_Timeout(t *testing.T) {
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: "sleep 5",
+		Timeout: durationPtr(50 * time.Millisecond),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	res := s.Execute(context.Background())
+	assert.WithinDuration(t, res.StartAt, res.FinishAt, time.Second)
+	assert.Equal(t, TimedOut, res.Status)
+	assert.Contains(t, res.Error.Error(), "killed after timeout of 50ms")
+}
+
+func TestScheduledTask_Skip(t *testing.T) {
+	s := &ScheduledTask{Id: "test", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	res := s.Skip("upstream failed")
+	assert.Equal(t, Skipped, res.Status)
+	assert.Equal(t, "upstream failed", res.Reason)
+	assert.Equal(t, s, res.Task)
+	assert.Equal(t, res, s.LatestTaskResult)
+	assert.False(t, res.StartAt.IsZero())
+}
+
+func TestScheduledTask_Fail(t *testing.T) {
+	s := &ScheduledTask{Id: "test", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	res := s.Fail(errors.New("lock unavailable"))
+	assert.Equal(t, Failed, res.Status)
+	assert.EqualError(t, res.Error, "lock unavailable")
+	assert.Equal(t, s, res.Task)
+	assert.Equal(t, res, s.LatestTaskResult)
+	assert.False(t, res.StartAt.IsZero())
+}
+
+func TestTaskResult_ExitCode(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 4").Run()
+	assert.Equal(t, 0, (&TaskResult{}).ExitCode())
+	assert.Equal(t, 4, (&TaskResult{Error: exitErr}).ExitCode())
+	assert.Equal(t, -1, (&TaskResult{Error: errors.New("fail")}).ExitCode())
+}
+
+func Test_ScheduledTask_ErrorValidateNotifyOn(t *testing.T) {
+	validate := gtaskValidator.New()
+	scheduled := ScheduledTask{
+		Id:       "test",
+		CronExpr: "* * * * *",
+		Command:  "fake",
+		NotifyOn: []string{"failure", "wrong"},
+	}
+	err := validate.Struct(&scheduled)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Field validation for 'NotifyOn[1]' failed on the 'oneof' tag")
+}
+
 func Test_splitCommand(t *testing.T) {
 
 	tests := []struct {
@@ -329,3 +502,266 @@ func Test_splitCommand(t *testing.T) {
 		})
 	}
 }
+
+func Test_ScheduledTask_ErrorValidateTimezone(t *testing.T) {
+	validate := gtaskValidator.New()
+	scheduled := ScheduledTask{
+		Id:       "test",
+		CronExpr: "* * * * *",
+		Command:  "fake",
+		Timezone: "Europe/Wrong",
+	}
+	err := validate.Struct(&scheduled)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Field validation for 'Timezone' failed on the 'timezone' tag")
+}
+
+func TestScheduledTask_GetLocation(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	tests := []struct {
+		name     string
+		timezone string
+		want     *time.Location
+		wantErr  bool
+	}{
+		{name: "SuccessFallback", timezone: "", want: time.UTC},
+		{name: "SuccessWithTimezone", timezone: "Europe/Paris", want: paris},
+		{name: "ErrorWrongTimezone", timezone: "Europe/Wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&ScheduledTask{Timezone: tt.timezone}).GetLocation(time.UTC)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScheduledTask_Execute_Interrupted(t *testing.T) {
+	output := path.Join(t.TempDir(), "output")
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: fmt.Sprintf("sh -c 'touch %s; sleep 5 & wait'", output),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	done := make(chan *TaskResult)
+	go func() { done <- s.Execute(ctx) }()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(output)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// the children of the command are in its process group
+	cancel(errors.New("shutdown"))
+	select {
+	case res := <-done:
+		assert.Equal(t, Interrupted, res.Status)
+		assert.Equal(t, "interrupted", res.StatusString())
+		assert.Equal(t, "interrupted (shutdown): signal: terminated", res.Error.Error())
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "command not interrupted")
+	}
+
+	res := s.Execute(ctx)
+	assert.Equal(t, Interrupted, res.Status)
+}
+
+func TestScheduledTask_Execute_KilledAfterTerminateDelay(t *testing.T) {
+	output := path.Join(t.TempDir(), "output")
+	fakeClock := clockwork.NewFakeClock()
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: fmt.Sprintf("sh -c 'trap \"\" TERM; touch %s; sleep 30'", output),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Clock:   fakeClock,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan *TaskResult, 1)
+	go func() { done <- s.Execute(ctx) }()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(output)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// SIGTERM is ignored, SIGKILL is sent once TerminateDelay has elapsed on the clock of the task
+	cancel()
+	fakeClock.BlockUntil(1)
+	assert.Never(t, func() bool { return len(done) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	fakeClock.Advance(TerminateDelay)
+	select {
+	case res := <-done:
+		assert.Equal(t, Interrupted, res.Status)
+		assert.Equal(t, "interrupted (context canceled): signal: killed", res.Error.Error())
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "command not killed")
+	}
+}
+
+func TestScheduledTask_Execute_BackgroundChild(t *testing.T) {
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: "sh -c '(sleep 0.2; echo late) & echo now'",
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	// the output is read until the child left in background exits
+	res := s.Execute(context.Background())
+	assert.Equal(t, Succeed, res.Status)
+	assert.NoError(t, res.Error)
+	assert.Equal(t, "now\nlate\n", res.Output.String())
+}
+
+func TestScheduledTask_Execute_Overlapping(t *testing.T) {
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: "sleep 0.1",
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	results := make(chan *TaskResult, 2)
+	for i := 0; i < 2; i++ {
+		go func() { results <- s.Execute(context.Background()) }()
+	}
+	assert.Eventually(t, func() bool { return s.GetLatestTaskResult() != nil }, time.Second, time.Millisecond)
+	first, second := <-results, <-results
+	assert.Equal(t, Succeed, first.Status)
+	assert.Equal(t, Succeed, second.Status)
+	assert.Contains(t, []*TaskResult{first, second}, s.GetLatestTaskResult())
+}
+
+func Test_ScheduledTask_ErrorValidateLimits(t *testing.T) {
+	validate := gtaskValidator.New()
+	scheduled := ScheduledTask{
+		Id:       "test",
+		CronExpr: "* * * * *",
+		Command:  "fake",
+		Limits:   &limits.Config{Memory: "512MiB", Nice: 10, Ionice: "idle", Cgroup: &limits.CgroupConfig{MemoryMax: "1G", CpuMax: 0.5}},
+	}
+	assert.NoError(t, validate.Struct(&scheduled))
+
+	scheduled.Limits = &limits.Config{Memory: "512X", Nice: 30, Ionice: "wrong", Cgroup: &limits.CgroupConfig{CpuMax: -1}}
+	err := validate.Struct(&scheduled)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Field validation for 'Memory' failed on the 'byte-size' tag")
+	assert.Contains(t, err.Error(), "Field validation for 'Nice' failed on the 'max' tag")
+	assert.Contains(t, err.Error(), "Field validation for 'Ionice' failed on the 'oneof' tag")
+	assert.Contains(t, err.Error(), "Field validation for 'CpuMax' failed on the 'gt' tag")
+}
+
+func TestScheduledTask_Execute_Limits(t *testing.T) {
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: "sh -c 'ulimit -n; ulimit -t'",
+		Limits:  &limits.Config{OpenFiles: 64, CpuTime: time.Minute},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	res := s.Execute(context.Background())
+	assert.NoError(t, res.Error)
+	assert.Equal(t, "64\n60\n", res.Output.String())
+	assert.NotNil(t, res.Usage)
+
+	s.Limits = &limits.Config{Cgroup: &limits.CgroupConfig{Parent: t.TempDir()}}
+	res = s.Execute(context.Background())
+	assert.Equal(t, Failed, res.Status)
+	assert.ErrorContains(t, res.Error, "failed to create cgroup of task my_task: cgroup v2 is not available in")
+	assert.Nil(t, res.Usage)
+}
+
+func TestScheduledTask_Execute_Cgroup(t *testing.T) {
+	if _, err := os.Stat(path.Join(limits.DefaultCgroupParent, "cgroup.controllers")); err != nil || os.Geteuid() != 0 {
+		t.Skip("cgroup v2 needs to be mounted and writable")
+	}
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: "cat /proc/self/cgroup",
+		Limits:  &limits.Config{Cgroup: &limits.CgroupConfig{MemoryMax: "64M"}},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	res := s.Execute(context.Background())
+	assert.NoError(t, res.Error)
+	assert.Contains(t, res.Output.String(), "0::/gtask-my_task-")
+	matches, _ := filepath.Glob(path.Join(limits.DefaultCgroupParent, "gtask-my_task-*"))
+	assert.Empty(t, matches)
+}
+
+func TestScheduledTask_Execute_InheritEnv(t *testing.T) {
+	t.Setenv("GTASK_TESTING_SECRET", "secret")
+	t.Setenv("GTASK_TESTING_TZ", "UTC")
+	tests := []struct {
+		name       string
+		inheritEnv any
+		want       string
+	}{
+		{
+			name:       "SuccessWithoutInherit",
+			inheritEnv: false,
+			want:       "FOO=bar\nGTASK_ID=test\n",
+		},
+		{
+			name:       "SuccessWithAllowlist",
+			inheritEnv: []any{"GTASK_TESTING_TZ"},
+			want:       "FOO=bar\nGTASK_ID=test\nGTASK_TESTING_TZ=UTC\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ScheduledTask{
+				Id:         "test",
+				Command:    "/usr/bin/env",
+				Envs:       map[string]string{"FOO": "bar", GtaskIDKey: "test"},
+				InheritEnv: tt.inheritEnv,
+				After:      []string{"/usr/bin/env"},
+				Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			res := s.Execute(context.Background())
+			assert.NoError(t, res.Error)
+			assert.Equal(t, tt.want, res.Output.String())
+			assert.NotContains(t, res.Hooks[0].Output.String(), "GTASK_TESTING_SECRET")
+			assert.Contains(t, res.Hooks[0].Output.String(), "GTASK_STATUS=succeed")
+		})
+	}
+
+	s := &ScheduledTask{Id: "test", Command: "/usr/bin/env", Envs: map[string]string{"FOO": "bar"}, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	res := s.Execute(context.Background())
+	assert.Contains(t, res.Output.String(), "GTASK_TESTING_SECRET=secret\n")
+	assert.Contains(t, res.Output.String(), "FOO=bar\n")
+}
+
+func TestScheduledTask_ResolveSecrets(t *testing.T) {
+	t.Setenv("GTASK_TESTING_SECRET", "p@ss")
+	resolver, err := secret.NewResolver(secret.Config{}, afero.NewMemMapFs())
+	assert.NoError(t, err)
+	logs := &bytes.Buffer{}
+	s := &ScheduledTask{
+		Id:      "my_task",
+		Command: "sh -c 'echo password=$DB_PASSWORD; exit 1'",
+		Envs:    map[string]string{"DB_PASSWORD": "env://GTASK_TESTING_SECRET", "DB_USER": "app", "REPO_URL": "https://example.com/repo.git"},
+		After:   []string{"sh -c 'echo $DB_PASSWORD; cat $GTASK_OUTPUT_PATH'"},
+		Logger:  slog.New(slog.NewTextHandler(logs, nil)),
+	}
+	assert.NoError(t, s.ResolveSecrets(resolver))
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "p@ss", "DB_USER": "app", "REPO_URL": "https://example.com/repo.git"}, s.Envs)
+	assert.Equal(t, "user app with ***", s.Mask("user app with p@ss"))
+
+	res := s.Execute(context.Background())
+	assert.Equal(t, Failed, res.Status)
+	assert.Equal(t, "password=***\n", res.Output.String())
+	assert.Equal(t, "***\npassword=***\n", res.Hooks[0].Output.String())
+
+	s.Logger.Error("failed with p@ss")
+	assert.Contains(t, logs.String(), "failed with ***")
+	assert.NotContains(t, logs.String(), "p@ss")
+
+	s.Envs["DB_PASSWORD"] = "env://GTASK_TESTING_MISSING"
+	err = s.ResolveSecrets(resolver)
+	assert.EqualError(t, err, "failed to resolve environment DB_PASSWORD of task my_task: failed to resolve env://GTASK_TESTING_MISSING: variable GTASK_TESTING_MISSING is not set")
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// Usage is the resources used by the command, its children waited for included.
+type Usage struct {
+	// MaxRSS is the peak resident set size in bytes.
+	MaxRSS                     int64
+	UserTime                   time.Duration
+	SystemTime                 time.Duration
+	VoluntaryContextSwitches   int64
+	InvoluntaryContextSwitches int64
+}
+
+// newUsage returns the usage of the process ended, nil when it did not start.
+func newUsage(state *os.ProcessState) *Usage {
+	if state == nil {
+		return nil
+	}
+	usage := &Usage{UserTime: state.UserTime(), SystemTime: state.SystemTime()}
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// ru_maxrss is in bytes on darwin and in kilobytes elsewhere
+		usage.MaxRSS = int64(rusage.Maxrss)
+		if runtime.GOOS != "darwin" {
+			usage.MaxRSS *= 1024
+		}
+		usage.VoluntaryContextSwitches = int64(rusage.Nvcsw)
+		usage.InvoluntaryContextSwitches = int64(rusage.Nivcsw)
+	}
+	return usage
+}
+
+func (u *Usage) String() string {
+	return fmt.Sprintf(
+		"max RSS %s, user CPU %s, system CPU %s, context switches %d voluntary / %d involuntary",
+		formatBytes(u.MaxRSS),
+		u.UserTime,
+		u.SystemTime,
+		u.VoluntaryContextSwitches,
+		u.InvoluntaryContextSwitches,
+	)
+}
+
+// MarshalJSON encodes the CPU times in seconds to keep the usage easy to aggregate.
+func (u *Usage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		MaxRSS                     int64   `json:"max_rss_bytes"`
+		UserTime                   float64 `json:"user_cpu_seconds"`
+		SystemTime                 float64 `json:"system_cpu_seconds"`
+		VoluntaryContextSwitches   int64   `json:"voluntary_context_switches"`
+		InvoluntaryContextSwitches int64   `json:"involuntary_context_switches"`
+	}{
+		MaxRSS:                     u.MaxRSS,
+		UserTime:                   u.UserTime.Seconds(),
+		SystemTime:                 u.SystemTime.Seconds(),
+		VoluntaryContextSwitches:   u.VoluntaryContextSwitches,
+		InvoluntaryContextSwitches: u.InvoluntaryContextSwitches,
+	})
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func Test_newUsage(t *testing.T) {
+	assert.Nil(t, newUsage(nil))
+
+	cmd := exec.Command("sh", "-c", "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done; sleep 0.01")
+	assert.NoError(t, cmd.Run())
+	usage := newUsage(cmd.ProcessState)
+	assert.NotNil(t, usage)
+	assert.Greater(t, usage.MaxRSS, int64(1024))
+	assert.Equal(t, cmd.ProcessState.UserTime(), usage.UserTime)
+	assert.Equal(t, cmd.ProcessState.SystemTime(), usage.SystemTime)
+	assert.Greater(t, usage.UserTime+usage.SystemTime, time.Duration(0))
+	assert.Greater(t, usage.VoluntaryContextSwitches+usage.InvoluntaryContextSwitches, int64(0))
+}
+
+func TestUsage_String(t *testing.T) {
+	usage := &Usage{MaxRSS: 1536 << 10, UserTime: 1500 * time.Millisecond, SystemTime: 20 * time.Millisecond, VoluntaryContextSwitches: 4, InvoluntaryContextSwitches: 1}
+	assert.Equal(t, "max RSS 1.5 MiB, user CPU 1.5s, system CPU 20ms, context switches 4 voluntary / 1 involuntary", usage.String())
+}
+
+func TestUsage_MarshalJSON(t *testing.T) {
+	usage := &Usage{MaxRSS: 2048, UserTime: 1500 * time.Millisecond, SystemTime: 250 * time.Millisecond, VoluntaryContextSwitches: 12, InvoluntaryContextSwitches: 3}
+	got, err := json.Marshal(usage)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"max_rss_bytes": 2048, "user_cpu_seconds": 1.5, "system_cpu_seconds": 0.25, "voluntary_context_switches": 12, "involuntary_context_switches": 3}`, string(got))
+
+	got, err = json.Marshal(struct{ Usage *Usage }{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Usage": null}`, string(got))
+}
+
+func Test_formatBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 0, want: "0 B"},
+		{size: 1023, want: "1023 B"},
+		{size: 1024, want: "1.0 KiB"},
+		{size: 5 << 20, want: "5.0 MiB"},
+		{size: 3 << 30, want: "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatBytes(tt.size))
+		})
+	}
+}
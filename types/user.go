@@ -0,0 +1,102 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// geteuid is replaced in tests to check the privilege to switch user.
+var geteuid = os.Geteuid
+
+// account is the user which runs the command of a task when it is not the one of gtask.
+type account struct {
+	user       *user.User
+	credential *syscall.Credential
+}
+
+// lookupAccount resolves the user and the group of the task, it returns nil when the command runs as gtask.
+func (s *ScheduledTask) lookupAccount() (*account, error) {
+	if s.User == "" && s.Group == "" {
+		return nil, nil
+	}
+
+	u, err := user.Current()
+	if s.User != "" {
+		u, err = lookupUser(s.User)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user of task %s: %v", s.Id, err)
+	}
+	uid, _ := strconv.ParseUint(u.Uid, 10, 32)
+	gid, _ := strconv.ParseUint(u.Gid, 10, 32)
+	if s.Group != "" {
+		group, err := lookupGroup(s.Group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find group of task %s: %v", s.Id, err)
+		}
+		gid, _ = strconv.ParseUint(group.Gid, 10, 32)
+	}
+	if int(uid) == os.Getuid() && int(gid) == os.Getgid() {
+		return nil, nil
+	}
+
+	if euid := geteuid(); euid != 0 {
+		return nil, fmt.Errorf("gtask runs as uid %d, it must run as root to run task %s as %s", euid, s.Id, u.Username)
+	}
+
+	groupIds, err := u.GroupIds()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find groups of user %s: %v", u.Username, err)
+	}
+	groups := []uint32{}
+	for _, groupId := range groupIds {
+		if id, err := strconv.ParseUint(groupId, 10, 32); err == nil {
+			groups = append(groups, uint32(id))
+		}
+	}
+	return &account{
+		user:       u,
+		credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups},
+	}, nil
+}
+
+// env returns the environments of the user which replace the ones of gtask.
+func (a *account) env() map[string]string {
+	return map[string]string{
+		"HOME":    a.user.HomeDir,
+		"USER":    a.user.Username,
+		"LOGNAME": a.user.Username,
+	}
+}
+
+func (a *account) String() string {
+	return fmt.Sprintf("%s (uid %d, gid %d, groups %v)", a.user.Username, a.credential.Uid, a.credential.Gid, a.credential.Groups)
+}
+
+// lookupUser finds a user by name, or by uid.
+func lookupUser(name string) (*user.User, error) {
+	u, err := user.Lookup(name)
+	var unknown user.UnknownUserError
+	if errors.As(err, &unknown) {
+		if _, errId := strconv.Atoi(name); errId == nil {
+			return user.LookupId(name)
+		}
+	}
+	return u, err
+}
+
+// lookupGroup finds a group by name, or by gid.
+func lookupGroup(name string) (*user.Group, error) {
+	group, err := user.LookupGroup(name)
+	var unknown user.UnknownGroupError
+	if errors.As(err, &unknown) {
+		if _, errId := strconv.Atoi(name); errId == nil {
+			return user.LookupGroupId(name)
+		}
+	}
+	return group, err
+}
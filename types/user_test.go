@@ -0,0 +1,80 @@
+package types
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestScheduledTask_lookupAccount(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching user needs root")
+	}
+	tests := []struct {
+		name    string
+		user    string
+		group   string
+		euid    int
+		want    *syscall.Credential
+		wantErr string
+	}{
+		{name: "SuccessWithoutUser"},
+		{name: "SuccessSameUser", user: "root"},
+		{name: "SuccessUser", user: "nobody", want: &syscall.Credential{Uid: 65534, Gid: 65534, Groups: []uint32{65534}}},
+		{name: "SuccessUserId", user: "65534", want: &syscall.Credential{Uid: 65534, Gid: 65534, Groups: []uint32{65534}}},
+		{name: "SuccessUserAndGroup", user: "nobody", group: "www-data", want: &syscall.Credential{Uid: 65534, Gid: 33, Groups: []uint32{65534}}},
+		{name: "SuccessGroupOnly", group: "33", want: &syscall.Credential{Uid: 0, Gid: 33, Groups: []uint32{0}}},
+		{name: "ErrorUnknownUser", user: "wrong", wantErr: "failed to find user of task test: user: unknown user wrong"},
+		{name: "ErrorUnknownGroup", user: "nobody", group: "wrong", wantErr: "failed to find group of task test: group: unknown group wrong"},
+		{name: "ErrorWithoutPrivilege", user: "nobody", euid: 1000, wantErr: "gtask runs as uid 1000, it must run as root to run task test as nobody"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.euid != 0 {
+				geteuid = func() int { return tt.euid }
+				defer func() { geteuid = os.Geteuid }()
+			}
+			s := &ScheduledTask{Id: "test", User: tt.user, Group: tt.group}
+			got, err := s.lookupAccount()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got.credential)
+		})
+	}
+}
+
+func TestScheduledTask_Execute_AsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching user needs root")
+	}
+	s := &ScheduledTask{
+		Id:        "test",
+		Command:   "sh -c 'id -u; id -g; echo $HOME $USER $LOGNAME'",
+		Directory: "/tmp",
+		User:      "nobody",
+		Group:     "www-data",
+		After:     []string{"sh -c 'echo $(id -u) $USER'"},
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	res := s.Execute(context.Background())
+	assert.NoError(t, res.Error)
+	assert.Equal(t, "65534\n33\n/nonexistent nobody nobody\n", res.Output.String())
+	assert.Equal(t, "65534 nobody\n", res.Hooks[0].Output.String())
+
+	geteuid = func() int { return 1000 }
+	defer func() { geteuid = os.Geteuid }()
+	res = s.Execute(context.Background())
+	assert.Equal(t, Failed, res.Status)
+	assert.EqualError(t, res.Error, "gtask runs as uid 1000, it must run as root to run task test as nobody")
+}
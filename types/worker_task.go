@@ -4,6 +4,7 @@ import (
 	"dario.cat/mergo"
 	"fmt"
 	"github.com/alexandreh2ag/go-task/env"
+	"github.com/spf13/afero"
 )
 
 type WorkerTasks = []*WorkerTask
@@ -16,20 +17,37 @@ type WorkerTask struct {
 	User       string            `mapstructure:"user" validate:"omitempty,required,alphanum"`
 	Directory  string            `mapstructure:"directory" validate:"omitempty,required,dirpath"`
 	Envs       map[string]string `mapstructure:"environments"`
+	InheritEnv any               `mapstructure:"inherit_env" validate:"omitempty,inherit-env"`
+	EnvFile    []string          `mapstructure:"env_file" validate:"omitempty,dive,required"`
 }
 
-func PrepareWorkerTasks(tasks WorkerTasks, groupName, user, workingDir string, enVars map[string]string) {
+// PrepareWorkerTasks completes the tasks with defaults, their environments have the same precedence as the scheduled tasks.
+func PrepareWorkerTasks(fs afero.Fs, tasks WorkerTasks, groupName string, defaults WorkerDefaults, enVars map[string]string, inheritEnv any, envFiles []string) error {
 	for _, task := range tasks {
 		task.GroupName = groupName
-		task.Envs = env.ToUpperKeys(task.Envs)
-		_ = mergo.Merge(&task.Envs, enVars, mergo.WithOverride)
 		if task.User == "" {
-			task.User = user
+			task.User = defaults.User
+		}
+		if task.InheritEnv == nil {
+			task.InheritEnv = inheritEnv
 		}
 
 		if task.Directory == "" {
-			task.Directory = workingDir
+			task.Directory = defaults.Directory
+		}
+
+		globalEnvs, err := env.LoadFiles(fs, task.Directory, envFiles)
+		if err != nil {
+			return fmt.Errorf("failed to load env files of worker %s: %v", task.Id, err)
 		}
+		fileEnvs, err := env.LoadFiles(fs, task.Directory, task.EnvFile)
+		if err != nil {
+			return fmt.Errorf("failed to load env files of worker %s: %v", task.Id, err)
+		}
+		task.Envs = env.WithDefaults(env.ToUpperKeys(task.Envs), fileEnvs)
+		task.Envs = env.WithDefaults(task.Envs, env.ToUpperKeys(defaults.Envs))
+		task.Envs = env.WithDefaults(task.Envs, globalEnvs)
+		_ = mergo.Merge(&task.Envs, enVars, mergo.WithOverride)
 		taskVars := map[string]string{
 			GtaskGroupNameKey: task.GroupName,
 			GtaskDirKey:       task.Directory,
@@ -40,7 +58,7 @@ func PrepareWorkerTasks(tasks WorkerTasks, groupName, user, workingDir string, e
 
 		task.Envs = env.EvalAll(task.Envs)
 	}
-
+	return nil
 }
 
 func (w *WorkerTask) PrefixedName() string {
@@ -1,13 +1,14 @@
 package types
 
 import (
-	"github.com/go-playground/validator/v10"
+	gtaskValidator "github.com/alexandreh2ag/go-task/validator"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func Test_WorkerTask_SuccessValidate(t *testing.T) {
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	worker := WorkerTask{
 		Id:      "test",
 		Command: "fake",
@@ -18,7 +19,7 @@ func Test_WorkerTask_SuccessValidate(t *testing.T) {
 }
 
 func Test_WorkerTask_SuccessValidateWithOptionalData(t *testing.T) {
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	worker := WorkerTask{
 		Id:        "test",
 		Command:   "fake",
@@ -31,7 +32,7 @@ func Test_WorkerTask_SuccessValidateWithOptionalData(t *testing.T) {
 }
 
 func Test_WorkerTask_SuccessValidateIDWithUnderscore(t *testing.T) {
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	worker := WorkerTask{
 		Id:        "test_foo",
 		Command:   "fake",
@@ -44,7 +45,7 @@ func Test_WorkerTask_SuccessValidateIDWithUnderscore(t *testing.T) {
 }
 
 func Test_WorkerTask_ErrorValidate(t *testing.T) {
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	worker := WorkerTask{
 		Id: "test",
 	}
@@ -55,7 +56,7 @@ func Test_WorkerTask_ErrorValidate(t *testing.T) {
 }
 
 func Test_WorkerTask_ErrorValidateComplex(t *testing.T) {
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	worker := WorkerTask{
 		Id:        "test",
 		Command:   "fake",
@@ -70,7 +71,7 @@ func Test_WorkerTask_ErrorValidateComplex(t *testing.T) {
 }
 
 func Test_WorkerTask_ErrorValidateID(t *testing.T) {
-	validate := validator.New()
+	validate := gtaskValidator.New()
 	worker := WorkerTask{
 		Id:        "test foo",
 		Command:   "fake",
@@ -84,12 +85,15 @@ func Test_WorkerTask_ErrorValidateID(t *testing.T) {
 }
 
 func TestPrepareWorkerTasks(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/app/bar/.env", []byte("VAR1=file\nVAR3=\"${GTASK_USER}-file\"\n"), 0644)
 	type args struct {
 		tasks      WorkerTasks
-		user       string
-		workingDir string
+		defaults   WorkerDefaults
 		groupName  string
 		envVars    map[string]string
+		inheritEnv any
+		envFiles   []string
 	}
 	tests := []struct {
 		name string
@@ -99,11 +103,10 @@ func TestPrepareWorkerTasks(t *testing.T) {
 		{
 			name: "SuccessEmptyTasks",
 			args: args{
-				tasks:      WorkerTasks{},
-				user:       "foo",
-				groupName:  "bar",
-				workingDir: "/app/foo/",
-				envVars:    map[string]string{},
+				tasks:     WorkerTasks{},
+				defaults:  WorkerDefaults{Directory: "/app/foo/", User: "foo"},
+				groupName: "bar",
+				envVars:   map[string]string{},
 			},
 			want: WorkerTasks{},
 		},
@@ -114,9 +117,8 @@ func TestPrepareWorkerTasks(t *testing.T) {
 					&WorkerTask{Id: "test", Command: "cmd", GroupName: "bar"},
 					&WorkerTask{Id: "test2", Command: "cmd", GroupName: "bar", User: "bar", Directory: "/app/bar/"},
 				},
-				user:       "foo",
-				groupName:  "bar",
-				workingDir: "/app/foo/",
+				defaults:  WorkerDefaults{Directory: "/app/foo/", User: "foo"},
+				groupName: "bar",
 			},
 			want: WorkerTasks{
 				&WorkerTask{
@@ -153,9 +155,8 @@ func TestPrepareWorkerTasks(t *testing.T) {
 				tasks: WorkerTasks{
 					&WorkerTask{Id: "test", Command: "cmd", GroupName: "bar", User: "bar", Directory: "/app/bar/", Envs: map[string]string{"var1": "foo", "VAR2": "bar"}},
 				},
-				user:       "foo",
-				groupName:  "bar",
-				workingDir: "/app/foo/",
+				defaults:  WorkerDefaults{Directory: "/app/foo/", User: "foo"},
+				groupName: "bar",
 				envVars: map[string]string{
 					"VAR1": "bar",
 				},
@@ -177,10 +178,107 @@ func TestPrepareWorkerTasks(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "SuccessEnvFiles",
+			args: args{
+				tasks: WorkerTasks{
+					&WorkerTask{Id: "test", Command: "cmd", GroupName: "bar", User: "bar", Directory: "/app/bar/", EnvFile: []string{".env"}, Envs: map[string]string{"var1": "foo"}},
+				},
+				groupName: "bar",
+				envVars:   map[string]string{},
+			},
+			want: WorkerTasks{
+				&WorkerTask{
+					Id:        "test",
+					Command:   "cmd",
+					GroupName: "bar",
+					User:      "bar",
+					Directory: "/app/bar/",
+					EnvFile:   []string{".env"},
+					Envs: map[string]string{
+						"VAR1": "foo", "VAR3": "bar-file",
+						"GTASK_DIR":        "/app/bar/",
+						"GTASK_GROUP_NAME": "bar",
+						"GTASK_ID":         "bar-test",
+						"GTASK_USER":       "bar",
+					},
+				},
+			},
+		},
+		{
+			name: "SuccessDefaults",
+			args: args{
+				tasks: WorkerTasks{
+					&WorkerTask{Id: "test", Command: "cmd", GroupName: "bar", EnvFile: []string{".env"}, Envs: map[string]string{"var2": "task"}},
+				},
+				defaults:  WorkerDefaults{Directory: "/app/bar/", User: "foo", Envs: map[string]string{"var1": "default", "var2": "default", "var4": "${GTASK_USER}-default"}},
+				groupName: "bar",
+				envVars:   map[string]string{"VAR4": "flag"},
+			},
+			want: WorkerTasks{
+				&WorkerTask{
+					Id:        "test",
+					Command:   "cmd",
+					GroupName: "bar",
+					User:      "foo",
+					Directory: "/app/bar/",
+					EnvFile:   []string{".env"},
+					Envs: map[string]string{
+						"VAR1": "file", "VAR2": "task", "VAR3": "foo-file", "VAR4": "flag",
+						"GTASK_DIR":        "/app/bar/",
+						"GTASK_GROUP_NAME": "bar",
+						"GTASK_ID":         "bar-test",
+						"GTASK_USER":       "foo",
+					},
+				},
+			},
+		},
+		{
+			name: "SuccessInheritEnv",
+			args: args{
+				tasks: WorkerTasks{
+					&WorkerTask{Id: "test", Command: "cmd", GroupName: "bar", User: "bar", Directory: "/app/bar/"},
+					&WorkerTask{Id: "test2", Command: "cmd", GroupName: "bar", User: "bar", Directory: "/app/bar/", InheritEnv: true},
+				},
+				groupName:  "bar",
+				inheritEnv: false,
+			},
+			want: WorkerTasks{
+				&WorkerTask{
+					Id:         "test",
+					Command:    "cmd",
+					GroupName:  "bar",
+					User:       "bar",
+					Directory:  "/app/bar/",
+					InheritEnv: false,
+					Envs: map[string]string{
+						"GTASK_DIR":        "/app/bar/",
+						"GTASK_GROUP_NAME": "bar",
+						"GTASK_ID":         "bar-test",
+						"GTASK_USER":       "bar",
+					},
+				},
+				&WorkerTask{
+					Id:         "test2",
+					Command:    "cmd",
+					GroupName:  "bar",
+					User:       "bar",
+					Directory:  "/app/bar/",
+					InheritEnv: true,
+					Envs: map[string]string{
+						"GTASK_DIR":        "/app/bar/",
+						"GTASK_GROUP_NAME": "bar",
+						"GTASK_ID":         "bar-test2",
+						"GTASK_USER":       "bar",
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			PrepareWorkerTasks(tt.args.tasks, tt.args.groupName, tt.args.user, tt.args.workingDir, tt.args.envVars)
+			err := PrepareWorkerTasks(fs, tt.args.tasks, tt.args.groupName, tt.args.defaults, tt.args.envVars, tt.args.inheritEnv, tt.args.envFiles)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.want, tt.args.tasks)
 		})
 	}
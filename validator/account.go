@@ -0,0 +1,18 @@
+package validator
+
+import (
+	"github.com/go-playground/validator/v10"
+	"regexp"
+)
+
+const (
+	AccountKey = "account"
+)
+
+// accountRegex matches the POSIX user and group names (like www-data, _apt or a machine account ending with $) and the numeric ids.
+var accountRegex = regexp.MustCompile(`^([a-z_][a-z0-9_-]*[$]?|[0-9]+)$`)
+
+// ValidateAccount accepts a user or group name, or a numeric id.
+func ValidateAccount(fl validator.FieldLevel) bool {
+	return accountRegex.MatchString(fl.Field().String())
+}
@@ -0,0 +1,38 @@
+package validator
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateAccount(t *testing.T) {
+	type args struct {
+		User string `validate:"account"`
+	}
+	validate := validator.New()
+	_ = validate.RegisterValidation(AccountKey, ValidateAccount)
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{name: "successName", args: args{User: "backup"}, wantErr: assert.NoError},
+		{name: "successDash", args: args{User: "www-data"}, wantErr: assert.NoError},
+		{name: "successUnderscore", args: args{User: "_apt"}, wantErr: assert.NoError},
+		{name: "successMachineAccount", args: args{User: "host$"}, wantErr: assert.NoError},
+		{name: "successId", args: args{User: "1000"}, wantErr: assert.NoError},
+		{name: "failUpperCase", args: args{User: "Backup"}, wantErr: assert.Error},
+		{name: "failLeadingDash", args: args{User: "-backup"}, wantErr: assert.Error},
+		{name: "failSpace", args: args{User: "wrong user"}, wantErr: assert.Error},
+		{name: "failSlash", args: args{User: "wrong/group"}, wantErr: assert.Error},
+		{name: "failEmpty", args: args{User: ""}, wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.args)
+			tt.wantErr(t, err, "ValidateAccount is not valid")
+		})
+	}
+}
@@ -1,20 +1,15 @@
 package validator
 
 import (
+	"github.com/alexandreh2ag/go-task/cron"
 	"github.com/go-playground/validator/v10"
-	"regexp"
 )
 
 const (
-	cronRegexString = `(@(annually|yearly|monthly|weekly|daily|hourly|reboot))|(@every (\d+(ns|us|µs|ms|s|m|h))+)|((((\d+,)+\d+|(\d+-\d+)|\d+|\*|\*\/\d+) ?){5,7})`
-	CronExprKey     = "cron-expr"
-)
-
-var (
-	cronRegex = regexp.MustCompile(cronRegexString)
+	CronExprKey = "cron-expr"
 )
 
+// ValidateCronExpr accepts only the expressions which can be scheduled (see cron.Parse).
 func ValidateCronExpr(fl validator.FieldLevel) bool {
-	cronString := fl.Field().String()
-	return cronRegex.MatchString(cronString)
+	return cron.IsValid(fl.Field().String())
 }
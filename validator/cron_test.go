@@ -48,6 +48,86 @@ func TestValidateCronExpr(t *testing.T) {
 			args:    args{Cron: "1 1 1 * *"},
 			wantErr: assert.NoError,
 		},
+		{
+			name:    "successSeconds",
+			args:    args{Cron: "*/15 * * * * *"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successSecondsList",
+			args:    args{Cron: "0,30 */5 8-18 * * *"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successRangeStep",
+			args:    args{Cron: "0-30/5 * * * *"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successRangeList",
+			args:    args{Cron: "5-10,20 * * * *"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successNthWeekday",
+			args:    args{Cron: "0 12 * * 1#2"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successNames",
+			args:    args{Cron: "0 9 * JAN MON-FRI"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successLastDayOfMonth",
+			args:    args{Cron: "0 0 L * *"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successDescriptor",
+			args:    args{Cron: "@daily"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successEvery",
+			args:    args{Cron: "@every 1m30s"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successReboot",
+			args:    args{Cron: "@reboot"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "failEverySubSecond",
+			args:    args{Cron: "@every 500ms"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failUnsupportedDescriptor",
+			args:    args{Cron: "@always"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failSecondsOutOfRange",
+			args:    args{Cron: "70 * * * * *"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failTooManyFields",
+			args:    args{Cron: "* * * * * * * *"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failTooFewFields",
+			args:    args{Cron: "* * * *"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failWrongField",
+			args:    args{Cron: "wrong * * * * *"},
+			wantErr: assert.Error,
+		},
 		{
 			name:    "failStepDigitMissing",
 			args:    args{Cron: "* */ * * *"},
@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"github.com/alexandreh2ag/go-task/graph"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+)
+
+const (
+	DependsOnExistsKey  = "depends-on-exists"
+	DependsOnAcyclicKey = "depends-on-acyclic"
+)
+
+func ValidateDependsOnExists(fl validator.FieldLevel) bool {
+	return len(buildGraph(fl.Field()).Missing()) == 0
+}
+
+func ValidateDependsOnAcyclic(fl validator.FieldLevel) bool {
+	return buildGraph(fl.Field()).FindCycle() == nil
+}
+
+// buildGraph reads the fields Id and DependsOn of each item of a slice of tasks.
+func buildGraph(field reflect.Value) graph.Graph {
+	g := graph.Graph{}
+	if field.Kind() != reflect.Slice {
+		return g
+	}
+	for i := 0; i < field.Len(); i++ {
+		item := reflect.Indirect(field.Index(i))
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+		id := item.FieldByName("Id")
+		dependsOn := item.FieldByName("DependsOn")
+		if !id.IsValid() || !dependsOn.IsValid() {
+			continue
+		}
+		dependencies := []string{}
+		for j := 0; j < dependsOn.Len(); j++ {
+			dependencies = append(dependencies, dependsOn.Index(j).String())
+		}
+		g[id.String()] = dependencies
+	}
+	return g
+}
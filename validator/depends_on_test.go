@@ -0,0 +1,56 @@
+package validator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type dependsOnTask struct {
+	Id        string
+	DependsOn []string
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	type args struct {
+		Tasks []*dependsOnTask `validate:"depends-on-exists,depends-on-acyclic"`
+	}
+	validate := New()
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr []string
+	}{
+		{
+			name: "SuccessWithoutDependencies",
+			args: args{Tasks: []*dependsOnTask{{Id: "a"}, {Id: "b"}}},
+		},
+		{
+			name: "SuccessWithDependencies",
+			args: args{Tasks: []*dependsOnTask{{Id: "a"}, {Id: "b", DependsOn: []string{"a"}}, {Id: "c", DependsOn: []string{"a", "b"}}}},
+		},
+		{
+			name:    "ErrorUnknownDependency",
+			args:    args{Tasks: []*dependsOnTask{{Id: "a"}, {Id: "b", DependsOn: []string{"wrong"}}}},
+			wantErr: []string{"failed on the 'depends-on-exists' tag"},
+		},
+		{
+			name:    "ErrorCycle",
+			args:    args{Tasks: []*dependsOnTask{{Id: "a", DependsOn: []string{"b"}}, {Id: "b", DependsOn: []string{"a"}}}},
+			wantErr: []string{"failed on the 'depends-on-acyclic' tag"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.args)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, wantErr := range tt.wantErr {
+				assert.Contains(t, err.Error(), wantErr)
+			}
+		})
+	}
+}
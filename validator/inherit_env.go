@@ -0,0 +1,16 @@
+package validator
+
+import (
+	"github.com/alexandreh2ag/go-task/env"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	InheritEnvKey = "inherit-env"
+)
+
+// ValidateInheritEnv accepts true, false or a list of variable names (see env.ParseInherit).
+func ValidateInheritEnv(fl validator.FieldLevel) bool {
+	_, _, err := env.ParseInherit(fl.Field().Interface())
+	return err == nil
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateInheritEnv(t *testing.T) {
+	type args struct {
+		InheritEnv any `validate:"omitempty,inherit-env"`
+	}
+	validate := validator.New()
+	_ = validate.RegisterValidation(InheritEnvKey, ValidateInheritEnv)
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "successEmpty",
+			args:    args{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successBool",
+			args:    args{InheritEnv: true},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successList",
+			args:    args{InheritEnv: []any{"PATH", "TZ"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "failWrongList",
+			args:    args{InheritEnv: []any{"PATH", 5}},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failWrongType",
+			args:    args{InheritEnv: 5},
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.args)
+			tt.wantErr(t, err, "ValidateInheritEnv is not valid")
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package validator
+
+import (
+	"github.com/alexandreh2ag/go-task/limits"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ByteSizeKey = "byte-size"
+)
+
+// ValidateByteSize accepts only the sizes which can be parsed (see limits.ParseSize).
+func ValidateByteSize(fl validator.FieldLevel) bool {
+	_, err := limits.ParseSize(fl.Field().String())
+	return err == nil
+}
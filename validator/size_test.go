@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateByteSize(t *testing.T) {
+	type args struct {
+		Size string `validate:"byte-size"`
+	}
+	validate := validator.New()
+	_ = validate.RegisterValidation(ByteSizeKey, ValidateByteSize)
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "successBytes",
+			args:    args{Size: "1024"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "successUnit",
+			args:    args{Size: "512MiB"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "failWrongUnit",
+			args:    args{Size: "512MX"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "failEmpty",
+			args:    args{Size: ""},
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.args)
+			tt.wantErr(t, err, "ValidateByteSize is not valid")
+		})
+	}
+}
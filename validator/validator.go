@@ -5,5 +5,10 @@ import "github.com/go-playground/validator/v10"
 func New(options ...validator.Option) *validator.Validate {
 	validate := validator.New()
 	_ = validate.RegisterValidation(CronExprKey, ValidateCronExpr)
+	_ = validate.RegisterValidation(ByteSizeKey, ValidateByteSize)
+	_ = validate.RegisterValidation(InheritEnvKey, ValidateInheritEnv)
+	_ = validate.RegisterValidation(DependsOnExistsKey, ValidateDependsOnExists)
+	_ = validate.RegisterValidation(DependsOnAcyclicKey, ValidateDependsOnAcyclic)
+	_ = validate.RegisterValidation(AccountKey, ValidateAccount)
 	return validate
 }